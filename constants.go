@@ -24,8 +24,18 @@ const (
 
 	sdpAttributeSimulcast = "simulcast"
 
+	sdpAttributeBundleOnly = "bundle-only"
+
+	sdpAttributeMaxMessageSize = "max-message-size"
+
+	// sctpDefaultMaxMessageSize is assumed when the remote doesn't signal a=max-message-size
+	sctpDefaultMaxMessageSize = 65536
+
 	rtpOutboundMTU = 1200
 
+	// rtpFixedHeaderSize is the part of the MTU the packetizer keeps for the RTP header
+	rtpFixedHeaderSize = 12
+
 	rtpPayloadTypeBitmask = 0x7F
 
 	incomingUnhandledRTPSsrc = "Incoming unhandled RTP ssrc(%d), OnTrack will not be fired. %v"
@@ -7,6 +7,7 @@
 package webrtc
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -20,7 +21,14 @@ import (
 	"github.com/pion/webrtc/v4/pkg/rtcerr"
 )
 
-const dataChannelBufferSize = math.MaxUint16 // message size limit for Chromium
+const (
+	dataChannelBufferSize = math.MaxUint16 // message size limit for Chromium
+
+	// dataChannelDefaultBufferedAmountHighThreshold is the default high-water
+	// mark of SendContext
+	dataChannelDefaultBufferedAmountHighThreshold = 1024 * 1024
+)
+
 var errSCTPNotEstablished = errors.New("SCTP not established")
 
 // DataChannel represents a WebRTC DataChannel
@@ -29,17 +37,26 @@ var errSCTPNotEstablished = errors.New("SCTP not established")
 type DataChannel struct {
 	mu sync.RWMutex
 
-	statsID                    string
-	label                      string
-	ordered                    bool
-	maxPacketLifeTime          *uint16
-	maxRetransmits             *uint16
-	protocol                   string
-	negotiated                 bool
-	id                         *uint16
-	readyState                 atomic.Value // DataChannelState
-	bufferedAmountLowThreshold uint64
-	detachCalled               bool
+	statsID                     string
+	label                       string
+	ordered                     bool
+	maxPacketLifeTime           *uint16
+	maxRetransmits              *uint16
+	protocol                    string
+	negotiated                  bool
+	priority                    PriorityType
+	id                          *uint16
+	readyState                  atomic.Value // DataChannelState
+	bufferedAmountLowThreshold  uint64
+	bufferedAmountHighThreshold uint64
+	detachCalled                bool
+
+	// detach is set when this DataChannel is detached without
+	// SettingEngine.DetachDataChannels being enabled
+	detach bool
+
+	// messageQueue replaces onMessageHandler once Messages was called
+	messageQueue *dataChannelMessageQueue
 
 	// The binaryType represents attribute MUST, on getting, return the value to
 	// which it was last set. On setting, if the new value is either the string
@@ -58,6 +75,13 @@ type DataChannel struct {
 	onBufferedAmountLow func()
 	onErrorHandler      func(error)
 
+	// Guards the state of the buffered amount low threshold of the SCTP
+	// stream, see checkBufferedAmountLow
+	bufferedAmountMu         sync.Mutex
+	bufferedAmountLowArmed   bool
+	bufferedAmountLowClosed  bool
+	bufferedAmountLowWaiters []*bufferedAmountLowWaiter
+
 	sctpTransport *SCTPTransport
 	dataChannel   *datachannel.DataChannel
 
@@ -92,17 +116,20 @@ func (api *API) newDataChannel(params *DataChannelParameters, sctpTransport *SCT
 	}
 
 	d := &DataChannel{
-		sctpTransport:     sctpTransport,
-		statsID:           fmt.Sprintf("DataChannel-%d", time.Now().UnixNano()),
-		label:             params.Label,
-		protocol:          params.Protocol,
-		negotiated:        params.Negotiated,
-		id:                params.ID,
-		ordered:           params.Ordered,
-		maxPacketLifeTime: params.MaxPacketLifeTime,
-		maxRetransmits:    params.MaxRetransmits,
-		api:               api,
-		log:               log,
+		sctpTransport:               sctpTransport,
+		statsID:                     fmt.Sprintf("DataChannel-%d", time.Now().UnixNano()),
+		label:                       params.Label,
+		protocol:                    params.Protocol,
+		negotiated:                  params.Negotiated,
+		priority:                    params.Priority,
+		id:                          params.ID,
+		ordered:                     params.Ordered,
+		maxPacketLifeTime:           params.MaxPacketLifeTime,
+		maxRetransmits:              params.MaxRetransmits,
+		bufferedAmountHighThreshold: dataChannelDefaultBufferedAmountHighThreshold,
+		detach:                      params.Detach,
+		api:                         api,
+		log:                         log,
 	}
 
 	d.setReadyState(DataChannelStateConnecting)
@@ -151,7 +178,7 @@ func (d *DataChannel) open(sctpTransport *SCTPTransport) error {
 
 	cfg := &datachannel.Config{
 		ChannelType:          channelType,
-		Priority:             datachannel.ChannelPriorityNormal,
+		Priority:             d.priority.dcepPriority(),
 		ReliabilityParameter: reliabilityParameter,
 		Label:                d.label,
 		Protocol:             d.protocol,
@@ -176,9 +203,9 @@ func (d *DataChannel) open(sctpTransport *SCTPTransport) error {
 		return err
 	}
 
-	// bufferedAmountLowThreshold and onBufferedAmountLow might be set earlier
+	// bufferedAmountLowThreshold might be set earlier
 	dc.SetBufferedAmountLowThreshold(d.bufferedAmountLowThreshold)
-	dc.OnBufferedAmountLow(d.onBufferedAmountLow)
+	dc.OnBufferedAmountLow(d.handleBufferedAmountLow)
 	d.mu.Unlock()
 
 	d.onDial()
@@ -200,8 +227,8 @@ func (d *DataChannel) checkDetachAfterOpen() {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	if d.api.settingEngine.detach.DataChannels && !d.detachCalled {
-		d.log.Warn("webrtc.DetachDataChannels() enabled but didn't Detach, call Detach from OnOpen")
+	if d.detachEnabled() && !d.detachCalled {
+		d.log.Warn("webrtc.DetachDataChannels() or DetachWhenOpen() enabled but didn't Detach, call Detach from OnOpen")
 	}
 }
 
@@ -304,7 +331,7 @@ func (d *DataChannel) handleOpen(dc *datachannel.DataChannel, isRemote, isAlread
 	d.mu.Lock()
 	d.dataChannel = dc
 	bufferedAmountLowThreshold := d.bufferedAmountLowThreshold
-	onBufferedAmountLow := d.onBufferedAmountLow
+	detach := d.detachEnabled()
 	d.mu.Unlock()
 	d.setReadyState(DataChannelStateOpen)
 
@@ -312,10 +339,10 @@ func (d *DataChannel) handleOpen(dc *datachannel.DataChannel, isRemote, isAlread
 	// * detached datachannels have no read loop, the user needs to read and query themselves
 	// * remote datachannels should fire OnOpened. This isn't spec compliant, but we can't break behavior yet
 	// * already negotiated datachannels should fire OnOpened
-	if d.api.settingEngine.detach.DataChannels || isRemote || isAlreadyNegotiated {
-		// bufferedAmountLowThreshold and onBufferedAmountLow might be set earlier
+	if detach || isRemote || isAlreadyNegotiated {
+		// bufferedAmountLowThreshold might be set earlier
 		d.dataChannel.SetBufferedAmountLowThreshold(bufferedAmountLowThreshold)
-		d.dataChannel.OnBufferedAmountLow(onBufferedAmountLow)
+		d.dataChannel.OnBufferedAmountLow(d.handleBufferedAmountLow)
 		d.onOpen()
 	} else {
 		dc.OnOpen(func() {
@@ -323,10 +350,7 @@ func (d *DataChannel) handleOpen(dc *datachannel.DataChannel, isRemote, isAlread
 		})
 	}
 
-	d.mu.Lock()
-	defer d.mu.Unlock()
-
-	if !d.api.settingEngine.detach.DataChannels {
+	if !detach {
 		go d.readLoop()
 	}
 }
@@ -349,12 +373,27 @@ func (d *DataChannel) onError(err error) {
 	}
 }
 
+// readBufferSize is large enough to hold any message we accept
+func (d *DataChannel) readBufferSize() uint32 {
+	bufferSize := uint32(dataChannelBufferSize)
+	d.mu.RLock()
+	if d.sctpTransport != nil && d.sctpTransport.localMaxMessageSize() > bufferSize {
+		bufferSize = d.sctpTransport.localMaxMessageSize()
+	}
+	d.mu.RUnlock()
+
+	return bufferSize
+}
+
 func (d *DataChannel) readLoop() {
-	buffer := make([]byte, dataChannelBufferSize)
+	buffer := make([]byte, d.readBufferSize())
 	for {
 		n, isString, err := d.dataChannel.ReadDataChannel(buffer)
 		if err != nil {
 			d.setReadyState(DataChannelStateClosed)
+			d.closeMessageQueue()
+			d.dropScheduledMessages()
+			d.closeBufferedAmountLowWaiters()
 			if !errors.Is(err, io.EOF) {
 				d.onError(err)
 			}
@@ -365,8 +404,29 @@ func (d *DataChannel) readLoop() {
 		m := DataChannelMessage{Data: make([]byte, n), IsString: isString}
 		copy(m.Data, buffer[:n])
 
-		// NB: Why was DataChannelMessage not passed as a pointer value?
-		d.onMessage(m) // nolint:staticcheck
+		d.mu.RLock()
+		messageQueue := d.messageQueue
+		d.mu.RUnlock()
+
+		if messageQueue == nil {
+			// NB: Why was DataChannelMessage not passed as a pointer value?
+			d.onMessage(m) // nolint:staticcheck
+		} else if !messageQueue.deliver(m) {
+			d.onError(ErrDataChannelMessagesOverflow)
+			if err := d.Close(); err != nil {
+				d.onError(err)
+			}
+		}
+	}
+}
+
+func (d *DataChannel) closeMessageQueue() {
+	d.mu.RLock()
+	messageQueue := d.messageQueue
+	d.mu.RUnlock()
+
+	if messageQueue != nil {
+		messageQueue.close()
 	}
 }
 
@@ -376,9 +436,11 @@ func (d *DataChannel) Send(data []byte) error {
 	if err != nil {
 		return err
 	}
+	if err = d.ensureMessageSize(len(data)); err != nil {
+		return err
+	}
 
-	_, err = d.dataChannel.WriteDataChannel(data, false)
-	return err
+	return d.write(data, false)
 }
 
 // SendText sends the text message to the DataChannel peer
@@ -387,11 +449,143 @@ func (d *DataChannel) SendText(s string) error {
 	if err != nil {
 		return err
 	}
+	if err = d.ensureMessageSize(len(s)); err != nil {
+		return err
+	}
+
+	return d.write([]byte(s), true)
+}
 
-	_, err = d.dataChannel.WriteDataChannel([]byte(s), true)
+// SendContext sends the binary message to the DataChannel peer once the
+// BufferedAmount is at or below the BufferedAmountHighThreshold. It blocks
+// until then, or returns early when ctx is done or the DataChannel closes.
+func (d *DataChannel) SendContext(ctx context.Context, data []byte) error {
+	if err := d.waitBufferedAmountHigh(ctx); err != nil {
+		return err
+	}
+
+	return d.Send(data)
+}
+
+func (d *DataChannel) waitBufferedAmountHigh(ctx context.Context) error {
+	for d.BufferedAmount() > d.BufferedAmountHighThreshold() {
+		if err := d.ensureOpen(); err != nil {
+			return err
+		}
+
+		// The waiter is notified once the buffered amount drops to the
+		// high-water mark, or the DataChannel closes
+		low := make(chan struct{})
+		var once sync.Once
+		w := d.addBufferedAmountLowWaiter(d.BufferedAmountHighThreshold(), false, func() {
+			once.Do(func() { close(low) })
+		})
+
+		select {
+		case <-ctx.Done():
+			d.removeBufferedAmountLowWaiter(w)
+			return ctx.Err()
+		case <-low:
+		}
+	}
+
+	return nil
+}
+
+// Writer returns an io.Writer which sends the data written to it with
+// SendContext, blocking while the BufferedAmountHighThreshold is exceeded.
+// Writes larger than the SCTPTransport's MaxMessageSize are split into
+// several messages.
+func (d *DataChannel) Writer(ctx context.Context) io.Writer {
+	return &dataChannelWriter{ctx: ctx, dataChannel: d}
+}
+
+type dataChannelWriter struct {
+	ctx         context.Context
+	dataChannel *DataChannel
+}
+
+func (w *dataChannelWriter) Write(p []byte) (n int, err error) {
+	chunkSize := len(p)
+	if sctpTransport := w.dataChannel.Transport(); sctpTransport != nil {
+		if maxMessageSize := sctpTransport.MaxMessageSize(); maxMessageSize < float64(chunkSize) {
+			chunkSize = int(maxMessageSize)
+		}
+	}
+
+	for n < len(p) {
+		end := n + chunkSize
+		if end > len(p) {
+			end = len(p)
+		}
+
+		if err = w.dataChannel.SendContext(w.ctx, p[n:end]); err != nil {
+			return n, err
+		}
+		n = end
+	}
+
+	return n, nil
+}
+
+// write sends the message through the SettingEngine data channel scheduler if enabled
+func (d *DataChannel) write(data []byte, isString bool) error {
+	d.mu.RLock()
+	sctpTransport := d.sctpTransport
+	d.mu.RUnlock()
+
+	if sctpTransport != nil && sctpTransport.scheduler != nil {
+		return sctpTransport.scheduler.send(d, data, isString)
+	}
+	return d.writeDataChannel(data, isString)
+}
+
+func (d *DataChannel) writeDataChannel(data []byte, isString bool) error {
+	_, err := d.dataChannel.WriteDataChannel(data, isString)
+	d.checkBufferedAmountLow(false)
 	return err
 }
 
+// flushScheduledMessages writes the messages held by the scheduler before the
+// DataChannel is closed
+func (d *DataChannel) flushScheduledMessages() error {
+	d.mu.RLock()
+	sctpTransport := d.sctpTransport
+	d.mu.RUnlock()
+
+	if sctpTransport == nil || sctpTransport.scheduler == nil {
+		return nil
+	}
+	return sctpTransport.scheduler.flush(d)
+}
+
+// dropScheduledMessages reports the messages held by the scheduler that can't
+// be sent anymore, as the DataChannel is closed
+func (d *DataChannel) dropScheduledMessages() {
+	d.mu.RLock()
+	sctpTransport := d.sctpTransport
+	d.mu.RUnlock()
+
+	if sctpTransport != nil && sctpTransport.scheduler != nil {
+		sctpTransport.scheduler.drop(d)
+	}
+}
+
+// https://www.w3.org/TR/webrtc/#dom-rtcdatachannel-send (step 3)
+func (d *DataChannel) ensureMessageSize(size int) error {
+	d.mu.RLock()
+	sctpTransport := d.sctpTransport
+	d.mu.RUnlock()
+
+	if sctpTransport == nil {
+		return nil
+	}
+	if maxMessageSize := sctpTransport.MaxMessageSize(); float64(size) > maxMessageSize {
+		return &rtcerr.TypeError{Err: fmt.Errorf("%w: %d > %v", ErrDataChannelMessageTooLarge, size, maxMessageSize)}
+	}
+	return nil
+}
+
 func (d *DataChannel) ensureOpen() error {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
@@ -403,20 +597,22 @@ func (d *DataChannel) ensureOpen() error {
 
 // Detach allows you to detach the underlying datachannel. This provides
 // an idiomatic API to work with, however it disables the OnMessage callback.
-// Before calling Detach you have to enable this behavior by calling
-// webrtc.DetachDataChannels(). Combining detached and normal data channels
-// is not supported.
+// Before calling Detach you have to enable this behavior, either for every
+// DataChannel by calling webrtc.DetachDataChannels(), or for this DataChannel
+// with DataChannelInit.Detach or DetachWhenOpen.
 // Please refer to the data-channels-detach example and the
 // pion/datachannel documentation for the correct way to handle the
 // resulting DataChannel object.
 func (d *DataChannel) Detach() (datachannel.ReadWriteCloser, error) {
 	d.mu.Lock()
 
-	if !d.api.settingEngine.detach.DataChannels {
+	if !d.detachEnabled() {
+		d.mu.Unlock()
 		return nil, errDetachNotEnabled
 	}
 
 	if d.dataChannel == nil {
+		d.mu.Unlock()
 		return nil, errDetachBeforeOpened
 	}
 
@@ -446,11 +642,34 @@ func (d *DataChannel) Detach() (datachannel.ReadWriteCloser, error) {
 	return dataChannel, nil
 }
 
+// DetachWhenOpen is a Pion specific option which enables Detach for this
+// DataChannel only, so it can be combined with DataChannels using OnMessage.
+// It has to be called before the DataChannel opens, for DataChannels created
+// by the remote peer this is the OnDataChannel handler. No messages are
+// delivered to OnMessage, instead Detach has to be called from OnOpen.
+func (d *DataChannel) DetachWhenOpen() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.dataChannel != nil {
+		return &rtcerr.InvalidStateError{Err: errDetachAfterOpened}
+	}
+
+	d.detach = true
+	return nil
+}
+
+// detachEnabled must be called with d.mu held
+func (d *DataChannel) detachEnabled() bool {
+	return d.api.settingEngine.detach.DataChannels || d.detach
+}
+
 // Close Closes the DataChannel. It may be called regardless of whether
 // the DataChannel object was created by this peer or the remote peer.
 func (d *DataChannel) Close() error {
 	d.mu.Lock()
 	haveSctpTransport := d.dataChannel != nil
+	messageQueue := d.messageQueue
 	d.mu.Unlock()
 
 	if d.ReadyState() == DataChannelStateClosed {
@@ -459,10 +678,25 @@ func (d *DataChannel) Close() error {
 
 	d.setReadyState(DataChannelStateClosing)
 	if !haveSctpTransport {
+		if messageQueue != nil {
+			messageQueue.close()
+		}
 		return nil
 	}
 
-	return d.dataChannel.Close()
+	// Unblock the readLoop so it sees the closed stream
+	if messageQueue != nil {
+		messageQueue.stop()
+	}
+
+	// Messages accepted by Send are written before the stream is reset
+	flushErr := d.flushScheduledMessages()
+	err := d.dataChannel.Close()
+	d.closeBufferedAmountLowWaiters()
+	if flushErr != nil {
+		return flushErr
+	}
+	return err
 }
 
 // Label represents a label that can be used to distinguish this
@@ -511,6 +745,17 @@ func (d *DataChannel) Protocol() string {
 	return d.protocol
 }
 
+// Priority represents the relative priority of this DataChannel
+func (d *DataChannel) Priority() PriorityType {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.priority == PriorityTypeUnknown {
+		return PriorityTypeLow
+	}
+	return d.priority
+}
+
 // Negotiated represents whether this DataChannel was negotiated by the
 // application (true), or not (false).
 func (d *DataChannel) Negotiated() bool {
@@ -553,12 +798,18 @@ func (d *DataChannel) ReadyState() DataChannelState {
 // closes.
 func (d *DataChannel) BufferedAmount() uint64 {
 	d.mu.RLock()
-	defer d.mu.RUnlock()
-
 	if d.dataChannel == nil {
+		d.mu.RUnlock()
 		return 0
 	}
-	return d.dataChannel.BufferedAmount()
+	bufferedAmount := d.dataChannel.BufferedAmount()
+	sctpTransport := d.sctpTransport
+	d.mu.RUnlock()
+
+	if sctpTransport != nil && sctpTransport.scheduler != nil {
+		bufferedAmount += sctpTransport.scheduler.bufferedAmount(d)
+	}
+	return bufferedAmount
 }
 
 // BufferedAmountLowThreshold represents the threshold at which the
@@ -571,23 +822,35 @@ func (d *DataChannel) BufferedAmountLowThreshold() uint64 {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	if d.dataChannel == nil {
-		return d.bufferedAmountLowThreshold
-	}
-	return d.dataChannel.BufferedAmountLowThreshold()
+	return d.bufferedAmountLowThreshold
 }
 
 // SetBufferedAmountLowThreshold is used to update the threshold.
 // See BufferedAmountLowThreshold().
 func (d *DataChannel) SetBufferedAmountLowThreshold(th uint64) {
 	d.mu.Lock()
-	defer d.mu.Unlock()
-
 	d.bufferedAmountLowThreshold = th
+	d.mu.Unlock()
 
-	if d.dataChannel != nil {
-		d.dataChannel.SetBufferedAmountLowThreshold(th)
-	}
+	d.checkBufferedAmountLow(false)
+}
+
+// BufferedAmountHighThreshold represents the high-water mark of SendContext,
+// it blocks while the BufferedAmount is above it. The default is 1 MiB.
+func (d *DataChannel) BufferedAmountHighThreshold() uint64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.bufferedAmountHighThreshold
+}
+
+// SetBufferedAmountHighThreshold is used to update the high-water mark.
+// See BufferedAmountHighThreshold().
+func (d *DataChannel) SetBufferedAmountHighThreshold(th uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.bufferedAmountHighThreshold = th
 }
 
 // OnBufferedAmountLow sets an event handler which is invoked when
@@ -595,12 +858,10 @@ func (d *DataChannel) SetBufferedAmountLowThreshold(th uint64) {
 // BufferedAmountLowThreshold.
 func (d *DataChannel) OnBufferedAmountLow(f func()) {
 	d.mu.Lock()
-	defer d.mu.Unlock()
-
 	d.onBufferedAmountLow = f
-	if d.dataChannel != nil {
-		d.dataChannel.OnBufferedAmountLow(f)
-	}
+	d.mu.Unlock()
+
+	d.checkBufferedAmountLow(false)
 }
 
 func (d *DataChannel) getStatsID() string {
@@ -612,6 +873,8 @@ func (d *DataChannel) getStatsID() string {
 func (d *DataChannel) collectStats(collector *statsReportCollector) {
 	collector.Collecting()
 
+	bufferedAmount := d.BufferedAmount()
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
@@ -622,7 +885,8 @@ func (d *DataChannel) collectStats(collector *statsReportCollector) {
 		Label:     d.label,
 		Protocol:  d.protocol,
 		// TransportID string `json:"transportId"`
-		State: d.ReadyState(),
+		State:          d.ReadyState(),
+		BufferedAmount: bufferedAmount,
 	}
 
 	if d.id != nil {
@@ -8,12 +8,14 @@ package webrtc
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/binary"
 	"io"
 	"math/big"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -23,6 +25,7 @@ import (
 	"github.com/pion/datachannel"
 	"github.com/pion/logging"
 	"github.com/pion/transport/v3/test"
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -745,3 +748,492 @@ func TestDetachRemovesDatachannelReference(t *testing.T) {
 		}
 	}
 }
+
+func TestDataChannel_MaxMessageSize(t *testing.T) {
+	to := test.TimeOut(time.Second * 20)
+	defer to.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const maxMessageSize = 256 * 1024
+
+	offerPC, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	s := SettingEngine{}
+	s.SetSCTPMaxMessageSize(maxMessageSize)
+	answerPC, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	messageReceived := make(chan int, 1)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		d.OnMessage(func(msg DataChannelMessage) {
+			messageReceived <- len(msg.Data)
+		})
+	})
+
+	dc, err := offerPC.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+
+	opened := make(chan struct{})
+	dc.OnOpen(func() {
+		close(opened)
+	})
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	assert.Contains(t, answerPC.LocalDescription().SDP, "a=max-message-size:262144")
+	<-opened
+
+	assert.Equal(t, float64(maxMessageSize), offerPC.SCTP().MaxMessageSize())
+	assert.Equal(t, float64(sctpDefaultMaxMessageSize), answerPC.SCTP().MaxMessageSize())
+
+	assert.NoError(t, dc.Send(make([]byte, maxMessageSize)))
+	assert.Equal(t, maxMessageSize, <-messageReceived)
+
+	err = dc.Send(make([]byte, maxMessageSize+1))
+	var typeErr *rtcerr.TypeError
+	assert.ErrorAs(t, err, &typeErr)
+	assert.ErrorIs(t, err, ErrDataChannelMessageTooLarge)
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestDataChannel_Priority(t *testing.T) {
+	to := test.TimeOut(time.Second * 20)
+	defer to.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		bulkMessageSize  = 16 * 1024
+		bulkMessageCount = 128
+	)
+
+	s := SettingEngine{}
+	s.EnableDataChannelScheduler(64 * 1024)
+	offerPC, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	answerPC, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	var bulkReceived uint32
+	bulkDone := make(chan struct{})
+	controlReceived := make(chan uint64, 1)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		switch d.Label() {
+		case "bulk":
+			assert.Equal(t, PriorityTypeLow, d.Priority())
+			d.OnMessage(func(DataChannelMessage) {
+				if atomic.AddUint32(&bulkReceived, 1) == bulkMessageCount {
+					close(bulkDone)
+				}
+			})
+		case "control":
+			assert.Equal(t, PriorityTypeHigh, d.Priority())
+			d.OnMessage(func(DataChannelMessage) {
+				controlReceived <- answerPC.SCTP().association().BytesReceived()
+			})
+		}
+	})
+
+	bulk, err := offerPC.CreateDataChannel("bulk", nil)
+	assert.NoError(t, err)
+	priority := PriorityTypeHigh
+	control, err := offerPC.CreateDataChannel("control", &DataChannelInit{Priority: &priority})
+	assert.NoError(t, err)
+	assert.Equal(t, PriorityTypeLow, bulk.Priority())
+	assert.Equal(t, PriorityTypeHigh, control.Priority())
+
+	var opened sync.WaitGroup
+	opened.Add(2)
+	bulk.OnOpen(opened.Done)
+	control.OnOpen(opened.Done)
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	opened.Wait()
+
+	// The control message overtakes the bulk messages held by the scheduler
+	for i := 0; i < bulkMessageCount; i++ {
+		assert.NoError(t, bulk.Send(make([]byte, bulkMessageSize)))
+	}
+	assert.Greater(t, bulk.BufferedAmount(), uint64(0))
+	assert.NoError(t, control.SendText("control"))
+
+	// Without the scheduler the control message would only arrive after all bulk messages
+	assert.Less(t, <-controlReceived, uint64(bulkMessageSize*bulkMessageCount/2))
+	<-bulkDone
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+// TestDataChannel_SchedulerFlowControl asserts that messages held by the
+// scheduler fire OnBufferedAmountLow, and are sent when the channel closes
+func TestDataChannel_SchedulerFlowControl(t *testing.T) {
+	to := test.TimeOut(time.Second * 20)
+	defer to.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		messageSize           = 16 * 1024
+		messageCount          = 256
+		bufferedAmountHigh    = 512 * 1024
+		bufferedAmountLow     = 256 * 1024
+		schedulerBufferAmount = 64 * 1024
+	)
+
+	s := SettingEngine{}
+	s.EnableDataChannelScheduler(schedulerBufferAmount)
+	offerPC, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	answerPC, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	var received uint32
+	done := make(chan struct{})
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		d.OnMessage(func(DataChannelMessage) {
+			if atomic.AddUint32(&received, 1) == messageCount {
+				close(done)
+			}
+		})
+	})
+
+	dc, err := offerPC.CreateDataChannel("bulk", nil)
+	assert.NoError(t, err)
+	dc.OnError(func(err error) { assert.NoError(t, err) })
+
+	opened := make(chan struct{})
+	dc.OnOpen(func() { close(opened) })
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	<-opened
+
+	// The association never holds more than the scheduler allows, the low
+	// threshold is only crossed by the messages held by the scheduler
+	lowEvents := make(chan struct{}, 1)
+	dc.SetBufferedAmountLowThreshold(bufferedAmountLow)
+	dc.OnBufferedAmountLow(func() {
+		select {
+		case lowEvents <- struct{}{}:
+		default:
+		}
+	})
+
+	sent := 0
+	for ; sent < messageCount/2; sent++ {
+		if dc.BufferedAmount() > bufferedAmountHigh {
+			<-lowEvents
+		}
+		assert.NoError(t, dc.Send(make([]byte, messageSize)))
+	}
+
+	// Messages still held when the channel is closed are sent first
+	for ; sent < messageCount; sent++ {
+		assert.NoError(t, dc.Send(make([]byte, messageSize)))
+	}
+	assert.Greater(t, dc.BufferedAmount(), uint64(schedulerBufferAmount))
+	assert.NoError(t, dc.Close())
+	<-done
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestDataChannel_SendContext(t *testing.T) {
+	to := test.TimeOut(time.Second * 20)
+	defer to.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	// The answerer never reads, so data piles up in the offerer
+	s := SettingEngine{}
+	s.DetachDataChannels()
+	answerPC, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		d.OnOpen(func() {
+			_, detachErr := d.Detach()
+			assert.NoError(t, detachErr)
+		})
+	})
+
+	dc, err := offerPC.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+	dc.SetBufferedAmountHighThreshold(256 * 1024)
+	assert.Equal(t, uint64(256*1024), dc.BufferedAmountHighThreshold())
+
+	opened := make(chan struct{})
+	dc.OnOpen(func() {
+		close(opened)
+	})
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	<-opened
+
+	// Writes block once the high-water mark is exceeded
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	n, err := dc.Writer(ctx).Write(make([]byte, 8*1024*1024))
+	cancel()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, n, 8*1024*1024)
+	assert.Equal(t, 0, n%sctpDefaultMaxMessageSize)
+	assert.Greater(t, dc.BufferedAmount(), dc.BufferedAmountHighThreshold())
+
+	dcStats, ok := offerPC.GetStats().GetDataChannelStats(dc)
+	assert.True(t, ok)
+	assert.Greater(t, dcStats.BufferedAmount, dc.BufferedAmountHighThreshold())
+
+	// Closing the channel unblocks SendContext
+	sendErr := make(chan error)
+	go func() {
+		sendErr <- dc.SendContext(context.Background(), []byte("blocked"))
+	}()
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, dc.Close())
+	assert.ErrorIs(t, <-sendErr, io.ErrClosedPipe)
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestDataChannel_Writer(t *testing.T) {
+	to := test.TimeOut(time.Second * 20)
+	defer to.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const totalSize = 4 * 1024 * 1024
+
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	var received uint64
+	receivedAll := make(chan struct{})
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		d.OnMessage(func(msg DataChannelMessage) {
+			assert.LessOrEqual(t, len(msg.Data), sctpDefaultMaxMessageSize)
+			if atomic.AddUint64(&received, uint64(len(msg.Data))) == totalSize {
+				close(receivedAll)
+			}
+		})
+	})
+
+	dc, err := offerPC.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+	dc.SetBufferedAmountHighThreshold(128 * 1024)
+
+	opened := make(chan struct{})
+	dc.OnOpen(func() {
+		close(opened)
+	})
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	<-opened
+
+	n, err := io.Copy(dc.Writer(context.Background()), bytes.NewReader(make([]byte, totalSize)))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(totalSize), n)
+	<-receivedAll
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestDataChannel_DetachWhenOpen(t *testing.T) {
+	to := test.TimeOut(time.Second * 20)
+	defer to.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	chatMessage := make(chan string, 1)
+	fileData := make(chan string, 1)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		switch d.Label() {
+		case "chat":
+			d.OnMessage(func(msg DataChannelMessage) {
+				chatMessage <- string(msg.Data)
+			})
+		case "file":
+			assert.NoError(t, d.DetachWhenOpen())
+			d.OnOpen(func() {
+				rwc, detachErr := d.Detach()
+				assert.NoError(t, detachErr)
+
+				buf := make([]byte, 64)
+				n, readErr := rwc.Read(buf)
+				assert.NoError(t, readErr)
+				fileData <- string(buf[:n])
+			})
+		}
+	})
+
+	chat, err := offerPC.CreateDataChannel("chat", nil)
+	assert.NoError(t, err)
+	file, err := offerPC.CreateDataChannel("file", nil)
+	assert.NoError(t, err)
+
+	chatOpened, fileOpened := make(chan struct{}), make(chan struct{})
+	chat.OnOpen(func() { close(chatOpened) })
+	file.OnOpen(func() { close(fileOpened) })
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	<-chatOpened
+	<-fileOpened
+
+	// Neither channel was marked for detaching on the offerer
+	_, err = chat.Detach()
+	assert.ErrorIs(t, err, errDetachNotEnabled)
+
+	var rtcErr *rtcerr.InvalidStateError
+	assert.ErrorAs(t, chat.DetachWhenOpen(), &rtcErr)
+
+	assert.NoError(t, chat.SendText("hello"))
+	assert.NoError(t, file.Send([]byte("file contents")))
+	assert.Equal(t, "hello", <-chatMessage)
+	assert.Equal(t, "file contents", <-fileData)
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestDataChannel_Messages(t *testing.T) {
+	// setup opens a DataChannel whose remote end delivers through Messages,
+	// sends count messages and waits until the remote peer read all of them
+	setup := func(t *testing.T, bufferSize int, policy MessageOverflowPolicy, count int) (*PeerConnection, *PeerConnection, <-chan DataChannelMessage, chan error) {
+		offerPC, answerPC, err := newPair()
+		assert.NoError(t, err)
+
+		messages := make(chan (<-chan DataChannelMessage), 1)
+		errs := make(chan error, 1)
+		answerDC := make(chan *DataChannel, 1)
+		answerPC.OnDataChannel(func(d *DataChannel) {
+			if d.Label() != "messages" {
+				return
+			}
+			d.OnMessage(func(DataChannelMessage) {
+				assert.Fail(t, "OnMessage must not be invoked")
+			})
+			d.OnError(func(err error) {
+				errs <- err
+			})
+			messages <- d.Messages(bufferSize, policy)
+			answerDC <- d
+		})
+
+		dc, err := offerPC.CreateDataChannel("messages", nil)
+		assert.NoError(t, err)
+		opened := make(chan struct{})
+		dc.OnOpen(func() {
+			close(opened)
+		})
+
+		assert.NoError(t, signalPair(offerPC, answerPC))
+		<-opened
+
+		for i := 0; i < count; i++ {
+			assert.NoError(t, dc.SendText(strconv.Itoa(i)))
+		}
+
+		d := <-answerDC
+		for {
+			d.mu.RLock()
+			received := d.dataChannel != nil && d.dataChannel.MessagesReceived() == uint32(count)
+			d.mu.RUnlock()
+			if received {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		return offerPC, answerPC, <-messages, errs
+	}
+
+	receive := func(messages <-chan DataChannelMessage) (received []string) {
+		for msg := range messages {
+			received = append(received, string(msg.Data))
+		}
+		return received
+	}
+
+	t.Run("Block", func(t *testing.T) {
+		to := test.TimeOut(time.Second * 20)
+		defer to.Stop()
+
+		report := test.CheckRoutines(t)
+		defer report()
+
+		offerPC, answerPC, err := newPair()
+		assert.NoError(t, err)
+
+		messages := make(chan (<-chan DataChannelMessage), 1)
+		answerPC.OnDataChannel(func(d *DataChannel) {
+			if d.Label() == "messages" {
+				messages <- d.Messages(2, MessageOverflowPolicyBlock)
+			}
+		})
+
+		dc, err := offerPC.CreateDataChannel("messages", nil)
+		assert.NoError(t, err)
+		opened := make(chan struct{})
+		dc.OnOpen(func() {
+			close(opened)
+		})
+
+		assert.NoError(t, signalPair(offerPC, answerPC))
+		<-opened
+
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, dc.SendText(strconv.Itoa(i)))
+		}
+
+		// Nothing is lost while the consumer is slow
+		time.Sleep(50 * time.Millisecond)
+		received := <-messages
+		for i := 0; i < 10; i++ {
+			msg := <-received
+			assert.Equal(t, strconv.Itoa(i), string(msg.Data))
+			assert.True(t, msg.IsString)
+		}
+
+		assert.NoError(t, dc.Close())
+		assert.Empty(t, receive(received))
+
+		closePairNow(t, offerPC, answerPC)
+	})
+
+	t.Run("DropOldest", func(t *testing.T) {
+		to := test.TimeOut(time.Second * 20)
+		defer to.Stop()
+
+		report := test.CheckRoutines(t)
+		defer report()
+
+		offerPC, answerPC, messages, _ := setup(t, 2, MessageOverflowPolicyDropOldest, 10)
+
+		closePairNow(t, offerPC, answerPC)
+		assert.Equal(t, []string{"8", "9"}, receive(messages))
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		to := test.TimeOut(time.Second * 20)
+		defer to.Stop()
+
+		report := test.CheckRoutines(t)
+		defer report()
+
+		offerPC, answerPC, messages, errs := setup(t, 1, MessageOverflowPolicyClose, 2)
+
+		assert.ErrorIs(t, <-errs, ErrDataChannelMessagesOverflow)
+		assert.Equal(t, []string{"0"}, receive(messages))
+
+		closePairNow(t, offerPC, answerPC)
+	})
+}
@@ -31,6 +31,10 @@ type DataChannel struct {
 
 	// A reference to the associated api object used by this datachannel
 	api *API
+
+	// detach is set when this DataChannel is detached without
+	// SettingEngine.DetachDataChannels being enabled
+	detach bool
 }
 
 // OnOpen sets an event handler which is invoked when
@@ -112,14 +116,14 @@ func (d *DataChannel) SendText(s string) (err error) {
 
 // Detach allows you to detach the underlying datachannel. This provides
 // an idiomatic API to work with, however it disables the OnMessage callback.
-// Before calling Detach you have to enable this behavior by calling
-// webrtc.DetachDataChannels(). Combining detached and normal data channels
-// is not supported.
+// Before calling Detach you have to enable this behavior, either for every
+// DataChannel by calling webrtc.DetachDataChannels(), or for this DataChannel
+// with DataChannelInit.Detach or DetachWhenOpen.
 // Please refer to the data-channels-detach example and the
 // pion/datachannel documentation for the correct way to handle the
 // resulting DataChannel object.
 func (d *DataChannel) Detach() (datachannel.ReadWriteCloser, error) {
-	if !d.api.settingEngine.detach.DataChannels {
+	if !d.api.settingEngine.detach.DataChannels && !d.detach {
 		return nil, fmt.Errorf("enable detaching by calling webrtc.DetachDataChannels()")
 	}
 
@@ -127,6 +131,13 @@ func (d *DataChannel) Detach() (datachannel.ReadWriteCloser, error) {
 	return detached, nil
 }
 
+// DetachWhenOpen is a Pion specific option which enables Detach for this
+// DataChannel only, so it can be combined with DataChannels using OnMessage.
+func (d *DataChannel) DetachWhenOpen() error {
+	d.detach = true
+	return nil
+}
+
 // Close Closes the DataChannel. It may be called regardless of whether
 // the DataChannel object was created by this peer or the remote peer.
 func (d *DataChannel) Close() (err error) {
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+// bufferedAmountLowWaiter is notified once the buffered amount of a
+// DataChannel drops to its threshold
+type bufferedAmountLowWaiter struct {
+	threshold uint64
+
+	// stream waiters compare the threshold against the SCTP stream only,
+	// without the messages held by the data channel scheduler
+	stream bool
+	notify func()
+}
+
+// The SCTP stream of a DataChannel has a single buffered amount low threshold
+// and callback. The DataChannel owns both, and sets the threshold to the
+// highest one anybody waits for: the OnBufferedAmountLow handler, SendContext
+// and the data channel scheduler.
+
+// addBufferedAmountLowWaiter calls notify once the buffered amount drops to
+// threshold, or the DataChannel closes. notify is called right away if the
+// buffered amount already is at or below threshold.
+func (d *DataChannel) addBufferedAmountLowWaiter(threshold uint64, stream bool, notify func()) *bufferedAmountLowWaiter {
+	w := &bufferedAmountLowWaiter{threshold: threshold, stream: stream, notify: notify}
+
+	d.bufferedAmountMu.Lock()
+	closed := d.bufferedAmountLowClosed
+	if !closed {
+		d.bufferedAmountLowWaiters = append(d.bufferedAmountLowWaiters, w)
+	}
+	d.bufferedAmountMu.Unlock()
+
+	if closed {
+		notify()
+	} else {
+		d.checkBufferedAmountLow(false)
+	}
+	return w
+}
+
+func (d *DataChannel) removeBufferedAmountLowWaiter(w *bufferedAmountLowWaiter) {
+	d.bufferedAmountMu.Lock()
+	defer d.bufferedAmountMu.Unlock()
+
+	for i := range d.bufferedAmountLowWaiters {
+		if d.bufferedAmountLowWaiters[i] == w {
+			d.bufferedAmountLowWaiters = append(d.bufferedAmountLowWaiters[:i], d.bufferedAmountLowWaiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// closeBufferedAmountLowWaiters notifies all waiters when the DataChannel
+// closes, so they see the new state
+func (d *DataChannel) closeBufferedAmountLowWaiters() {
+	d.bufferedAmountMu.Lock()
+	waiters := d.bufferedAmountLowWaiters
+	d.bufferedAmountLowWaiters = nil
+	d.bufferedAmountLowClosed = true
+	d.bufferedAmountMu.Unlock()
+
+	for _, w := range waiters {
+		w.notify()
+	}
+}
+
+// handleBufferedAmountLow is the OnBufferedAmountLow callback of the SCTP stream
+func (d *DataChannel) handleBufferedAmountLow() {
+	d.checkBufferedAmountLow(true)
+}
+
+// checkBufferedAmountLow fires the OnBufferedAmountLow handler and notifies
+// the waiters whose threshold has been reached, then sets the threshold of
+// the SCTP stream to the next one. It must be called after every write, and
+// after every change to a threshold or to the messages held by the scheduler.
+func (d *DataChannel) checkBufferedAmountLow(fromStream bool) { //nolint:gocognit
+	d.mu.RLock()
+	dc := d.dataChannel
+	sctpTransport := d.sctpTransport
+	lowThreshold := d.bufferedAmountLowThreshold
+	handler := d.onBufferedAmountLow
+	d.mu.RUnlock()
+
+	if dc == nil {
+		return
+	}
+
+	calledByStream := fromStream
+	var notify []func()
+	fire := false
+
+	d.bufferedAmountMu.Lock()
+	for {
+		var held uint64
+		if sctpTransport != nil && sctpTransport.scheduler != nil {
+			held = sctpTransport.scheduler.bufferedAmount(d)
+		}
+		streamAmount := dc.BufferedAmount()
+		amount := streamAmount + held
+
+		// The stream only calls back after dropping below its threshold, so the
+		// buffered amount was above the low threshold if the stream threshold is
+		// at least as high
+		if fromStream && dc.BufferedAmountLowThreshold()+held >= lowThreshold || amount > lowThreshold {
+			d.bufferedAmountLowArmed = true
+		}
+		fromStream = false
+
+		if d.bufferedAmountLowArmed && amount <= lowThreshold {
+			d.bufferedAmountLowArmed = false
+			fire = true
+		}
+
+		// The stream threshold is the highest one still waited for
+		var streamThreshold uint64
+		if lowThreshold > held {
+			streamThreshold = lowThreshold - held
+		}
+		thresholdOfWaiter := false
+
+		waiters := d.bufferedAmountLowWaiters[:0]
+		for _, w := range d.bufferedAmountLowWaiters {
+			threshold, current := w.threshold, amount
+			if w.stream {
+				current = streamAmount
+			}
+			if current <= threshold {
+				notify = append(notify, w.notify)
+				continue
+			}
+			waiters = append(waiters, w)
+
+			if !w.stream {
+				if threshold < held {
+					continue
+				}
+				threshold -= held
+			}
+			if threshold > streamThreshold || !thresholdOfWaiter && threshold == streamThreshold {
+				streamThreshold, thresholdOfWaiter = threshold, true
+			}
+		}
+		d.bufferedAmountLowWaiters = waiters
+
+		dc.SetBufferedAmountLowThreshold(streamThreshold)
+
+		// Check again if the buffered amount dropped before the threshold was set
+		lowPending := d.bufferedAmountLowArmed && lowThreshold >= held
+		if dc.BufferedAmount() > streamThreshold || !thresholdOfWaiter && !lowPending {
+			break
+		}
+	}
+	d.bufferedAmountMu.Unlock()
+
+	for _, f := range notify {
+		f()
+	}
+	if fire && handler != nil {
+		// Writers may hold locks the handler needs to send, only the callback
+		// of the SCTP stream runs it in place
+		if calledByStream {
+			handler()
+		} else {
+			go handler()
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pion/datachannel"
+	"github.com/pion/sctp"
+	"github.com/pion/transport/v3/deadline"
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
+)
+
+// dataChannelListenerBacklog is how many incoming DataChannels are held
+// until they are returned by Accept, further ones are closed
+const dataChannelListenerBacklog = 16
+
+// DetachConn detaches the DataChannel like Detach and wraps it in a stream
+// oriented net.Conn. Reads are buffered across message boundaries, writes
+// larger than the SCTPTransport's MaxMessageSize are split into several
+// messages and block while the BufferedAmountHighThreshold is exceeded.
+// LocalAddr and RemoteAddr are the addresses of the selected ICE candidate pair.
+func (d *DataChannel) DetachConn() (net.Conn, error) {
+	rwc, err := d.Detach()
+	if err != nil {
+		return nil, err
+	}
+
+	return &dataChannelConn{
+		dataChannel:   d,
+		rwc:           rwc,
+		buffer:        make([]byte, d.readBufferSize()),
+		writeDeadline: deadline.New(),
+	}, nil
+}
+
+type dataChannelConn struct {
+	dataChannel *DataChannel
+	rwc         datachannel.ReadWriteCloser
+
+	readMu sync.Mutex
+	buffer []byte
+	// pending is the unread remainder of the last message
+	pending []byte
+
+	writeMu       sync.Mutex
+	writeDeadline *deadline.Deadline
+}
+
+func (c *dataChannelConn) Read(p []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	for len(c.pending) == 0 {
+		n, err := c.rwc.Read(c.buffer)
+		if err != nil {
+			if errors.Is(err, sctp.ErrReadDeadlineExceeded) {
+				return 0, os.ErrDeadlineExceeded
+			}
+			return 0, err
+		}
+		c.pending = c.buffer[:n]
+	}
+
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *dataChannelConn) Write(p []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.writeDeadline.Err() != nil {
+		return 0, os.ErrDeadlineExceeded
+	}
+
+	n, err := (&dataChannelWriter{ctx: c.writeDeadline, dataChannel: c.dataChannel}).Write(p)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return n, os.ErrDeadlineExceeded
+	}
+	return n, err
+}
+
+func (c *dataChannelConn) Close() error {
+	return c.dataChannel.Close()
+}
+
+func (c *dataChannelConn) LocalAddr() net.Addr {
+	return c.dataChannel.sctpTransport.candidatePairAddr(true)
+}
+
+func (c *dataChannelConn) RemoteAddr() net.Addr {
+	return c.dataChannel.sctpTransport.candidatePairAddr(false)
+}
+
+func (c *dataChannelConn) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.SetWriteDeadline(t)
+}
+
+func (c *dataChannelConn) SetReadDeadline(t time.Time) error {
+	if deadliner, ok := c.rwc.(datachannel.ReadDeadliner); ok {
+		return deadliner.SetReadDeadline(t)
+	}
+	return nil
+}
+
+func (c *dataChannelConn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline.Set(t)
+	return nil
+}
+
+// candidatePairAddr returns an address of the selected ICE candidate pair,
+// or an empty address while none is selected
+func (r *SCTPTransport) candidatePairAddr(local bool) net.Addr {
+	var pair *ICECandidatePair
+	if dtlsTransport := r.Transport(); dtlsTransport != nil {
+		if iceTransport := dtlsTransport.ICETransport(); iceTransport != nil {
+			pair, _ = iceTransport.GetSelectedCandidatePair()
+		}
+	}
+	if pair == nil {
+		return &net.UDPAddr{}
+	}
+
+	candidate := pair.Remote
+	if local {
+		candidate = pair.Local
+	}
+
+	ip := net.ParseIP(candidate.Address)
+	if candidate.Protocol == ICEProtocolTCP {
+		return &net.TCPAddr{IP: ip, Port: int(candidate.Port)}
+	}
+	return &net.UDPAddr{IP: ip, Port: int(candidate.Port)}
+}
+
+// Listen returns a net.Listener which yields a net.Conn, created by
+// DataChannel.DetachConn, for every DataChannel the remote peer opens on
+// the SCTPTransport. These DataChannels are detached even if
+// SettingEngine.DetachDataChannels isn't enabled. While the listener is open
+// incoming DataChannels are still announced by OnDataChannel, but must not
+// be used directly. Only one listener can be open at a time. At most 16
+// DataChannels wait to be accepted, the remote peer's DataChannels that
+// arrive while the backlog is full are closed.
+func (r *SCTPTransport) Listen() (net.Listener, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.state == SCTPTransportStateClosed {
+		return nil, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
+	}
+	if r.listener != nil {
+		return nil, errSCTPTransportListenerExists
+	}
+
+	r.listener = &dataChannelListener{
+		transport: r,
+		conns:     make(chan net.Conn, dataChannelListenerBacklog),
+		done:      make(chan struct{}),
+	}
+	return r.listener, nil
+}
+
+type dataChannelListener struct {
+	transport *SCTPTransport
+	conns     chan net.Conn
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// deliver hands an incoming DataChannel to Accept. It is called from the
+// goroutine accepting DataChannels of the association, so it never blocks:
+// like a TCP listen backlog overflow, the DataChannel is closed if the
+// backlog is full.
+func (l *dataChannelListener) deliver(d *DataChannel) {
+	conn, err := d.DetachConn()
+	if err != nil {
+		l.transport.log.Warnf("Failed to detach DataChannel for listener: %v", err)
+		return
+	}
+
+	select {
+	case <-l.done:
+	default:
+		select {
+		case l.conns <- conn:
+			return
+		default:
+			l.transport.log.Warnf("Listener backlog is full, closing DataChannel %s", d.Label())
+		}
+	}
+
+	if err := conn.Close(); err != nil {
+		l.transport.log.Warnf("Failed to close DataChannel: %v", err)
+	}
+}
+
+func (l *dataChannelListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *dataChannelListener) Close() error {
+	l.closeOnce.Do(func() {
+		l.transport.lock.Lock()
+		if l.transport.listener == l {
+			l.transport.listener = nil
+		}
+		l.transport.lock.Unlock()
+
+		close(l.done)
+
+		// Close the DataChannels that were never accepted
+		for {
+			select {
+			case conn := <-l.conns:
+				if err := conn.Close(); err != nil {
+					l.transport.log.Warnf("Failed to close DataChannel: %v", err)
+				}
+			default:
+				return
+			}
+		}
+	})
+	return nil
+}
+
+func (l *dataChannelListener) Addr() net.Addr {
+	return l.transport.candidatePairAddr(true)
+}
@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataChannelConn(t *testing.T) {
+	to := test.TimeOut(time.Second * 20)
+	defer to.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// Neither peer enables SettingEngine.DetachDataChannels
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	listener, err := answerPC.SCTP().Listen()
+	assert.NoError(t, err)
+
+	_, err = answerPC.SCTP().Listen()
+	assert.ErrorIs(t, err, errSCTPTransportListenerExists)
+
+	detach := true
+	dc, err := offerPC.CreateDataChannel("conn", &DataChannelInit{Detach: &detach})
+	assert.NoError(t, err)
+
+	offerConn := make(chan net.Conn, 1)
+	dc.OnOpen(func() {
+		conn, detachErr := dc.DetachConn()
+		assert.NoError(t, detachErr)
+		offerConn <- conn
+	})
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+
+	answer, err := listener.Accept()
+	assert.NoError(t, err)
+	offer := <-offerConn
+
+	// Reads are buffered across message boundaries
+	_, err = offer.Write([]byte("hello "))
+	assert.NoError(t, err)
+	_, err = offer.Write([]byte("world"))
+	assert.NoError(t, err)
+
+	buf := make([]byte, 11)
+	_, err = io.ReadFull(answer, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(buf))
+
+	_, err = offer.Write([]byte("partial read"))
+	assert.NoError(t, err)
+	buf = make([]byte, 7)
+	n, err := answer.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "partial", string(buf[:n]))
+	n, err = answer.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, " read", string(buf[:n]))
+
+	// Addresses come from the selected candidate pair
+	assert.Equal(t, offer.LocalAddr().String(), answer.RemoteAddr().String())
+	assert.Equal(t, offer.RemoteAddr().String(), answer.LocalAddr().String())
+	assert.Equal(t, "udp", answer.LocalAddr().Network())
+	assert.NotEqual(t, 0, answer.LocalAddr().(*net.UDPAddr).Port) //nolint:forcetypeassert
+
+	// Deadlines surface as net.Error timeouts
+	assert.NoError(t, answer.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+	_, err = answer.Read(buf)
+	var netErr net.Error
+	assert.True(t, errors.As(err, &netErr) && netErr.Timeout())
+	assert.NoError(t, answer.SetReadDeadline(time.Time{}))
+
+	assert.NoError(t, offer.SetWriteDeadline(time.Now().Add(-time.Second)))
+	_, err = offer.Write([]byte("late"))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	assert.NoError(t, offer.SetWriteDeadline(time.Time{}))
+
+	assert.NoError(t, offer.Close())
+	_, err = offer.Write([]byte("closed"))
+	assert.Error(t, err)
+
+	assert.NoError(t, listener.Close())
+	_, err = listener.Accept()
+	assert.ErrorIs(t, err, net.ErrClosed)
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestDataChannelConn_ListenerBacklog(t *testing.T) {
+	to := test.TimeOut(time.Second * 20)
+	defer to.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	listener, err := answerPC.SCTP().Listen()
+	assert.NoError(t, err)
+
+	// The DataChannel that doesn't fit in the backlog is closed
+	closed := make(chan string, dataChannelListenerBacklog+1)
+	for i := 0; i <= dataChannelListenerBacklog; i++ {
+		dc, dcErr := offerPC.CreateDataChannel(fmt.Sprintf("conn-%d", i), nil)
+		assert.NoError(t, dcErr)
+		dc.OnClose(func() {
+			closed <- dc.Label()
+		})
+	}
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	<-closed
+
+	accepted := map[string]bool{}
+	for i := 0; i < dataChannelListenerBacklog; i++ {
+		conn, acceptErr := listener.Accept()
+		assert.NoError(t, acceptErr)
+		accepted[conn.(*dataChannelConn).dataChannel.Label()] = true //nolint:forcetypeassert
+		assert.NoError(t, conn.Close())
+	}
+	assert.Len(t, accepted, dataChannelListenerBacklog)
+
+	assert.NoError(t, listener.Close())
+	closePairNow(t, offerPC, answerPC)
+}
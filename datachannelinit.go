@@ -33,4 +33,13 @@ type DataChannelInit struct {
 
 	// ID overrides the default selection of ID for this channel.
 	ID *uint16
+
+	// Priority is the relative priority of this channel, it defaults to
+	// PriorityTypeLow. It is announced to the remote peer and weighs the
+	// channel in the SettingEngine data channel scheduler.
+	Priority *PriorityType
+
+	// Detach is a Pion specific option which enables Detach for this channel
+	// only, regardless of SettingEngine.DetachDataChannels.
+	Detach *bool
 }
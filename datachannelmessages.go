@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+)
+
+// Messages is a Pion specific alternative to OnMessage, it returns a channel
+// which receives the messages of the DataChannel in order. Up to bufferSize
+// messages are buffered, policy decides what happens to further messages
+// until the application catches up. The channel is closed when the
+// DataChannel closes. OnMessage is not invoked while Messages is used, and
+// detached DataChannels can't use it. Calling Messages again replaces the
+// previous channel, which is closed.
+func (d *DataChannel) Messages(bufferSize int, policy MessageOverflowPolicy) <-chan DataChannelMessage {
+	if policy != MessageOverflowPolicyBlock && bufferSize < 1 {
+		// Dropping or closing needs a buffer to detect the overflow
+		bufferSize = 1
+	}
+
+	q := &dataChannelMessageQueue{
+		messages: make(chan DataChannelMessage, bufferSize),
+		policy:   policy,
+		done:     make(chan struct{}),
+	}
+
+	d.mu.Lock()
+	previous := d.messageQueue
+	d.messageQueue = q
+	d.mu.Unlock()
+
+	if previous != nil {
+		previous.close()
+	}
+	if d.ReadyState() == DataChannelStateClosed {
+		q.close()
+	}
+
+	return q.messages
+}
+
+// dataChannelMessageQueue delivers the messages read by the readLoop to the
+// channel returned by DataChannel.Messages
+type dataChannelMessageQueue struct {
+	messages chan DataChannelMessage
+	policy   MessageOverflowPolicy
+
+	// sendMu keeps close from racing a deliver in progress
+	sendMu    sync.Mutex
+	closed    bool
+	closeOnce sync.Once
+	// done is closed to abort a blocked deliver
+	done chan struct{}
+}
+
+// deliver queues msg, it returns false when msg overflowed the queue
+// with MessageOverflowPolicyClose
+func (q *dataChannelMessageQueue) deliver(msg DataChannelMessage) bool {
+	q.sendMu.Lock()
+	defer q.sendMu.Unlock()
+
+	select {
+	case <-q.done:
+		return true
+	default:
+	}
+
+	switch q.policy {
+	case MessageOverflowPolicyDropOldest:
+		for {
+			select {
+			case q.messages <- msg:
+				return true
+			default:
+			}
+
+			select {
+			case <-q.messages:
+			default:
+			}
+		}
+	case MessageOverflowPolicyClose:
+		select {
+		case q.messages <- msg:
+			return true
+		default:
+			return false
+		}
+	default:
+		select {
+		case q.messages <- msg:
+		case <-q.done:
+		}
+		return true
+	}
+}
+
+// stop aborts a blocked deliver, the channel is closed once it returned
+func (q *dataChannelMessageQueue) stop() {
+	q.closeOnce.Do(func() {
+		close(q.done)
+	})
+}
+
+// close closes the channel, buffered messages can still be received
+func (q *dataChannelMessageQueue) close() {
+	q.stop()
+
+	q.sendMu.Lock()
+	defer q.sendMu.Unlock()
+
+	if !q.closed {
+		q.closed = true
+		close(q.messages)
+	}
+}
@@ -0,0 +1,346 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// dataChannelScheduler keeps messages out of the SCTP association while more
+// than maxBufferedAmount bytes are queued in it. The SCTP association sends
+// messages of all streams in FIFO order, holding them back lets messages of
+// higher priority channels overtake bulk transfers. Held messages are released
+// with self-clocked fair queueing, weighted by the DCEP priority of the channel,
+// whenever the buffered amount of the association drops.
+type dataChannelScheduler struct {
+	sctpTransport     *SCTPTransport
+	maxBufferedAmount uint64
+
+	// writeMu serializes writes to the association so the messages of a
+	// channel are never reordered
+	writeMu sync.Mutex
+
+	mu          sync.Mutex
+	queues      map[*DataChannel]*dataChannelQueue
+	queued      int
+	virtualTime float64
+	closed      bool
+
+	// releasing is set while a goroutine releases held messages, rerun asks
+	// it to check the association again before it returns
+	releasing bool
+	rerun     bool
+
+	// waiters wake the scheduler when the buffered amount of the channels
+	// filling the association drops
+	waiters map[*DataChannel]*bufferedAmountLowWaiter
+}
+
+type dataChannelQueue struct {
+	messages       []scheduledMessage
+	lastFinish     float64
+	bufferedAmount uint64
+}
+
+type scheduledMessage struct {
+	data     []byte
+	isString bool
+	finish   float64
+}
+
+func newDataChannelScheduler(sctpTransport *SCTPTransport, maxBufferedAmount uint64) *dataChannelScheduler {
+	return &dataChannelScheduler{
+		sctpTransport:     sctpTransport,
+		maxBufferedAmount: maxBufferedAmount,
+		queues:            map[*DataChannel]*dataChannelQueue{},
+	}
+}
+
+// send writes the message to the association if it has room and nothing is
+// held, otherwise the message is held until it is scheduled. Errors of held
+// messages are reported to the OnError handler of the DataChannel.
+func (s *dataChannelScheduler) send(d *DataChannel, data []byte, isString bool) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.mu.Lock()
+	switch {
+	case s.closed:
+		s.mu.Unlock()
+		return io.ErrClosedPipe
+	case s.queued == 0 && s.sctpTransport.associationBufferedAmount() < s.maxBufferedAmount:
+		s.mu.Unlock()
+		return d.writeDataChannel(data, isString)
+	}
+
+	s.enqueue(d, data, isString)
+	s.mu.Unlock()
+
+	// The held message counts in the buffered amount of the channel
+	d.checkBufferedAmountLow(false)
+	s.wake()
+	return nil
+}
+
+// enqueue holds a copy of the message, the caller must hold s.mu
+func (s *dataChannelScheduler) enqueue(d *DataChannel, data []byte, isString bool) {
+	q, ok := s.queues[d]
+	if !ok {
+		q = &dataChannelQueue{}
+		s.queues[d] = q
+	}
+
+	start := s.virtualTime
+	if q.lastFinish > start {
+		start = q.lastFinish
+	}
+	q.lastFinish = start + float64(len(data))/float64(d.Priority().dcepPriority())
+	q.messages = append(q.messages, scheduledMessage{
+		data:     append([]byte{}, data...),
+		isString: isString,
+		finish:   q.lastFinish,
+	})
+	q.bufferedAmount += uint64(len(data))
+	s.queued++
+}
+
+// wake releases held messages from a new goroutine, or makes the running one
+// check the association again
+func (s *dataChannelScheduler) wake() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.closed || s.queued == 0:
+	case s.releasing:
+		s.rerun = true
+	default:
+		s.releasing = true
+		go s.release()
+	}
+}
+
+// release writes held messages to the association until it is full, then
+// waits for the channels filling it to drain
+func (s *dataChannelScheduler) release() {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	for {
+		if s.releaseHeld() {
+			s.watchAssociation()
+		}
+
+		s.mu.Lock()
+		if !s.rerun || s.closed {
+			s.releasing, s.rerun = false, false
+			s.mu.Unlock()
+			return
+		}
+		s.rerun = false
+		s.mu.Unlock()
+	}
+}
+
+// releaseHeld writes held messages while the association has room, it
+// returns true if messages are left once it is full. The caller must hold
+// s.writeMu.
+func (s *dataChannelScheduler) releaseHeld() bool {
+	for s.sctpTransport.associationBufferedAmount() < s.maxBufferedAmount {
+		s.mu.Lock()
+		d, message, ok := s.next()
+		dropped := s.dropClosed()
+		s.mu.Unlock()
+
+		s.reportDropped(dropped)
+		if !ok {
+			return false
+		}
+
+		if err := d.writeDataChannel(message.data, message.isString); err != nil {
+			d.onError(err)
+		}
+	}
+	return true
+}
+
+// watchAssociation wakes the scheduler once the buffered amount of the
+// association drops below maxBufferedAmount. The SCTP association has no
+// callback of its own, so the first channel to drain by the excess wakes it.
+func (s *dataChannelScheduler) watchAssociation() {
+	s.mu.Lock()
+	waiters := s.waiters
+	s.waiters = nil
+	watch := !s.closed && s.queued != 0
+	s.mu.Unlock()
+
+	for d, w := range waiters {
+		d.removeBufferedAmountLowWaiter(w)
+	}
+	if !watch {
+		return
+	}
+
+	dataChannels, bufferedAmounts, bufferedAmount := s.sctpTransport.dataChannelBufferedAmounts()
+	if bufferedAmount < s.maxBufferedAmount {
+		s.mu.Lock()
+		s.rerun = true
+		s.mu.Unlock()
+		return
+	}
+
+	excess := bufferedAmount - s.maxBufferedAmount + 1
+	waiters = map[*DataChannel]*bufferedAmountLowWaiter{}
+	for i, d := range dataChannels {
+		if bufferedAmounts[i] == 0 {
+			continue
+		}
+
+		var threshold uint64
+		if bufferedAmounts[i] > excess {
+			threshold = bufferedAmounts[i] - excess
+		}
+		waiters[d] = d.addBufferedAmountLowWaiter(threshold, true, s.wake)
+	}
+
+	s.mu.Lock()
+	s.waiters = waiters
+	s.mu.Unlock()
+}
+
+// next removes the message with the smallest virtual finish time, messages
+// of closing channels are skipped, the caller must hold s.mu
+func (s *dataChannelScheduler) next() (*DataChannel, scheduledMessage, bool) {
+	var (
+		next  *DataChannel
+		nextQ *dataChannelQueue
+	)
+	for d, q := range s.queues {
+		if d.ReadyState() != DataChannelStateOpen {
+			continue
+		}
+		if nextQ == nil || q.messages[0].finish < nextQ.messages[0].finish {
+			next, nextQ = d, q
+		}
+	}
+	if next == nil {
+		return nil, scheduledMessage{}, false
+	}
+
+	message := nextQ.messages[0]
+	nextQ.messages = nextQ.messages[1:]
+	nextQ.bufferedAmount -= uint64(len(message.data))
+	if len(nextQ.messages) == 0 {
+		delete(s.queues, next)
+	}
+	s.queued--
+	s.virtualTime = message.finish
+
+	return next, message, true
+}
+
+// dropClosed removes the messages of closed channels, the caller must hold s.mu
+func (s *dataChannelScheduler) dropClosed() map[*DataChannel]int {
+	var dropped map[*DataChannel]int
+	for d, q := range s.queues {
+		if d.ReadyState() != DataChannelStateClosed {
+			continue
+		}
+		if dropped == nil {
+			dropped = map[*DataChannel]int{}
+		}
+		dropped[d] = len(q.messages)
+		s.queued -= len(q.messages)
+		delete(s.queues, d)
+	}
+	return dropped
+}
+
+func (s *dataChannelScheduler) reportDropped(dropped map[*DataChannel]int) {
+	for d, n := range dropped {
+		if n == 0 {
+			continue
+		}
+		d.onError(fmt.Errorf("%w: %d messages of %s", ErrDataChannelScheduledMessagesDropped, n, d.Label()))
+	}
+}
+
+// flush writes the messages held for the channel regardless of the buffered
+// amount of the association, it is called when the channel is closed
+func (s *dataChannelScheduler) flush(d *DataChannel) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.mu.Lock()
+	q, ok := s.queues[d]
+	if ok {
+		s.queued -= len(q.messages)
+		delete(s.queues, d)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	for i, message := range q.messages {
+		if err := d.writeDataChannel(message.data, message.isString); err != nil {
+			s.reportDropped(map[*DataChannel]int{d: len(q.messages) - i - 1})
+			return err
+		}
+	}
+	return nil
+}
+
+// drop reports the messages held for a closed channel
+func (s *dataChannelScheduler) drop(d *DataChannel) {
+	s.mu.Lock()
+	q, ok := s.queues[d]
+	if ok {
+		s.queued -= len(q.messages)
+		delete(s.queues, d)
+	}
+	s.mu.Unlock()
+
+	if ok {
+		s.reportDropped(map[*DataChannel]int{d: len(q.messages)})
+	}
+}
+
+// bufferedAmount is the number of bytes held for the channel
+func (s *dataChannelScheduler) bufferedAmount(d *DataChannel) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if q, ok := s.queues[d]; ok {
+		return q.bufferedAmount
+	}
+	return 0
+}
+
+// close drops the held messages when the SCTPTransport stops
+func (s *dataChannelScheduler) close() {
+	s.mu.Lock()
+	s.closed = true
+	queues := s.queues
+	s.queues = map[*DataChannel]*dataChannelQueue{}
+	s.queued = 0
+	waiters := s.waiters
+	s.waiters = nil
+	s.mu.Unlock()
+
+	for d, w := range waiters {
+		d.removeBufferedAmountLowWaiter(w)
+	}
+
+	dropped := map[*DataChannel]int{}
+	for d, q := range queues {
+		dropped[d] = len(q.messages)
+	}
+	s.reportDropped(dropped)
+}
@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataChannelScheduler_WeightedFair(t *testing.T) {
+	newChannel := func(label string, priority PriorityType) *DataChannel {
+		d := &DataChannel{label: label, priority: priority}
+		d.setReadyState(DataChannelStateOpen)
+		return d
+	}
+	low, high := newChannel("low", PriorityTypeLow), newChannel("high", PriorityTypeHigh)
+	closed := newChannel("closed", PriorityTypeHigh)
+
+	s := newDataChannelScheduler(nil, 0)
+	for i := byte(0); i < 8; i++ {
+		s.enqueue(low, []byte{i, 0, 0, 0}, false)
+		s.enqueue(high, []byte{i, 0, 0, 0}, true)
+		s.enqueue(closed, []byte{i}, false)
+	}
+	closed.setReadyState(DataChannelStateClosed)
+
+	assert.Equal(t, uint64(32), s.bufferedAmount(low))
+	assert.Equal(t, uint64(32), s.bufferedAmount(high))
+
+	var order []string
+	nextIndex := map[*DataChannel]byte{}
+	for {
+		d, message, ok := s.next()
+		if !ok {
+			break
+		}
+
+		// Messages of a channel stay in order
+		assert.Equal(t, nextIndex[d], message.data[0])
+		assert.Equal(t, d == high, message.isString)
+		nextIndex[d]++
+		order = append(order, d.Label())
+	}
+
+	// The high priority channel gets four times the share of the low priority one
+	assert.Equal(t, []string{"high", "high", "high"}, order[:3])
+	assert.Equal(t, 16, len(order))
+	assert.Equal(t, byte(8), nextIndex[high])
+	assert.Equal(t, byte(8), nextIndex[low])
+	assert.Equal(t, 8, s.queued)
+	assert.Equal(t, uint64(0), s.bufferedAmount(low))
+
+	// Messages of closed channels are dropped
+	assert.Equal(t, map[*DataChannel]int{closed: 8}, s.dropClosed())
+	assert.Equal(t, 0, s.queued)
+}
+
+func TestDataChannelScheduler_Close(t *testing.T) {
+	d := &DataChannel{label: "held"}
+	d.setReadyState(DataChannelStateOpen)
+
+	errs := make(chan error, 1)
+	d.OnError(func(err error) { errs <- err })
+
+	s := newDataChannelScheduler(nil, 0)
+	s.enqueue(d, []byte{0}, false)
+	s.enqueue(d, []byte{1}, false)
+
+	// Held messages are reported when the SCTPTransport stops, and no more are accepted
+	s.close()
+	assert.ErrorIs(t, <-errs, ErrDataChannelScheduledMessagesDropped)
+	assert.Equal(t, uint64(0), s.bufferedAmount(d))
+	assert.True(t, errors.Is(s.send(d, []byte{2}, false), io.ErrClosedPipe))
+}
@@ -94,6 +94,24 @@ var (
 	// longer then 65535 bytes
 	ErrProtocolTooLarge = errors.New("protocol is larger then 65535 bytes")
 
+	// ErrDataChannelMessageTooLarge indicates that a message passed to DataChannel.Send
+	// is larger than SCTPTransport.MaxMessageSize
+	ErrDataChannelMessageTooLarge = errors.New("data channel message is larger than the max message size")
+
+	// ErrDataChannelMessagesOverflow indicates that the channel returned by
+	// DataChannel.Messages was full with MessageOverflowPolicyClose
+	ErrDataChannelMessagesOverflow = errors.New("data channel messages overflowed the buffer")
+
+	// ErrDataChannelScheduledMessagesDropped indicates that messages held by the
+	// data channel scheduler were not sent, as their DataChannel or SCTPTransport
+	// closed first. It is reported to the OnError handler of the DataChannel.
+	ErrDataChannelScheduledMessagesDropped = errors.New("data channel closed before scheduled messages were sent")
+
+	// ErrDataChannelIDReserved indicates that the remote peer opened an in-band
+	// data channel with an ID reserved by a NegotiatedDataChannelGroup. It is
+	// reported to the OnError handler of the SCTPTransport.
+	ErrDataChannelIDReserved = errors.New("data channel ID is reserved for negotiated data channels")
+
 	// ErrSenderNotCreatedByConnection indicates RemoveTrack was called with a RtpSender not created
 	// by this PeerConnection
 	ErrSenderNotCreatedByConnection = errors.New("RtpSender not created by this PeerConnection")
@@ -154,14 +172,25 @@ var (
 	// ErrNoPayloaderForCodec indicates that the requested codec does not have a payloader
 	ErrNoPayloaderForCodec = errors.New("the requested codec does not have a payloader")
 
+	// ErrH264NALTooLarge indicates that a H264 NAL doesn't fit in a packet, and can't be
+	// fragmented because packetization-mode 0 was negotiated
+	ErrH264NALTooLarge = errors.New("H264 NAL larger than the MTU can't be sent with packetization-mode 0")
+
 	// ErrRegisterHeaderExtensionInvalidDirection indicates that a extension was registered with a direction besides `sendonly` or `recvonly`
 	ErrRegisterHeaderExtensionInvalidDirection = errors.New("a header extension must be registered as 'recvonly', 'sendonly' or both")
 
 	// ErrSimulcastProbeOverflow indicates that too many Simulcast probe streams are in flight and the requested SSRC was ignored
 	ErrSimulcastProbeOverflow = errors.New("simulcast probe limit has been reached, new SSRC has been discarded")
 
+	errNegotiatedDataChannelIDOverflow     = errors.New("negotiated datachannel IDs exceed the maximum number of channels")
+	errNegotiatedDataChannelDuplicateLabel = errors.New("negotiated datachannel label declared twice")
+	errNegotiatedDataChannelIDInUse        = errors.New("negotiated datachannel ID is already in use")
+	errNegotiatedDataChannelRole           = errors.New("negotiated datachannel role must be DTLSRoleClient or DTLSRoleServer")
+
 	errDetachNotEnabled                 = errors.New("enable detaching by calling webrtc.DetachDataChannels()")
 	errDetachBeforeOpened               = errors.New("datachannel not opened yet, try calling Detach from OnOpen")
+	errDetachAfterOpened                = errors.New("datachannel already opened, call DetachWhenOpen from OnDataChannel")
+	errSCTPTransportListenerExists      = errors.New("SCTPTransport already has an open listener")
 	errDtlsTransportNotStarted          = errors.New("the DTLS transport has not started yet")
 	errDtlsKeyExtractionFailed          = errors.New("failed extracting keys from DTLS for SRTP")
 	errFailedToStartSRTP                = errors.New("failed to start SRTP")
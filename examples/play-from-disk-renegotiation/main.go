@@ -17,8 +17,7 @@ import (
 
 	"github.com/pion/randutil"
 	"github.com/pion/webrtc/v4"
-	"github.com/pion/webrtc/v4/pkg/media"
-	"github.com/pion/webrtc/v4/pkg/media/ivfreader"
+	"github.com/pion/webrtc/v4/pkg/media/player"
 )
 
 var peerConnection *webrtc.PeerConnection //nolint
@@ -166,34 +165,28 @@ func main() {
 // Read a video file from disk and write it to a webrtc.Track
 // When the video has been completely read this exits without error
 func writeVideoToTrack(t *webrtc.TrackLocalStaticSample) {
-	// Open a IVF file and start reading using our IVFReader
-	file, err := os.Open("output.ivf")
+	// Open a IVF file, the player sends its frames at the speed they should
+	// be played back as
+	reader, err := player.Open("output.ivf")
 	if err != nil {
 		panic(err)
 	}
 
-	ivf, header, err := ivfreader.NewWith(file)
-	if err != nil {
+	videoPlayer := player.New()
+	if err = videoPlayer.AddTrack(reader, t); err != nil {
+		panic(err)
+	}
+	if err = videoPlayer.Play(); err != nil {
 		panic(err)
 	}
 
-	// Send our video file frame at a time. Pace our sending so we send it at the same speed it should be played back as.
-	// This isn't required since the video is timestamped, but we will such much higher loss if we send all at once.
-	//
-	// It is important to use a time.Ticker instead of time.Sleep because
-	// * avoids accumulating skew, just calling time.Sleep didn't compensate for the time spent parsing the data
-	// * works around latency issues with Sleep (see https://github.com/golang/go/issues/44343)
-	ticker := time.NewTicker(time.Millisecond * time.Duration((float32(header.TimebaseNumerator)/float32(header.TimebaseDenominator))*1000))
-	for ; true; <-ticker.C {
-		frame, _, err := ivf.ParseNextFrame()
-		if err != nil {
-			fmt.Printf("Finish writing video track: %s ", err)
-			return
-		}
-
-		if err = t.WriteSample(media.Sample{Data: frame, Duration: time.Second}); err != nil {
-			fmt.Printf("Finish writing video track: %s ", err)
-			return
-		}
+	<-videoPlayer.Done()
+	if err = videoPlayer.Err(); err != nil {
+		fmt.Printf("Finish writing video track: %s\n", err)
+	} else {
+		fmt.Println("Finish writing video track")
+	}
+	if err = videoPlayer.Close(); err != nil {
+		fmt.Printf("cannot close player: %v\n", err)
 	}
 }
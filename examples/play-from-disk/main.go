@@ -9,23 +9,18 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"io"
 	"os"
-	"time"
 
 	"github.com/pion/webrtc/v4"
 	"github.com/pion/webrtc/v4/examples/internal/signal"
-	"github.com/pion/webrtc/v4/pkg/media"
 	"github.com/pion/webrtc/v4/pkg/media/ivfreader"
-	"github.com/pion/webrtc/v4/pkg/media/oggreader"
+	"github.com/pion/webrtc/v4/pkg/media/player"
 )
 
 const (
-	audioFileName   = "output.ogg"
-	videoFileName   = "output.ivf"
-	oggPageDuration = time.Millisecond * 20
+	audioFileName = "output.ogg"
+	videoFileName = "output.ivf"
 )
 
 // nolint:gocognit
@@ -60,6 +55,9 @@ func main() {
 
 	iceConnectedCtx, iceConnectedCtxCancel := context.WithCancel(context.Background())
 
+	// The player sends the video and audio files in sync
+	filePlayer := player.New()
+
 	if haveVideoFile {
 		file, openErr := os.Open(videoFileName)
 		if openErr != nil {
@@ -70,6 +68,9 @@ func main() {
 		if openErr != nil {
 			panic(openErr)
 		}
+		if openErr = file.Close(); openErr != nil {
+			panic(openErr)
+		}
 
 		// Determine video codec
 		var trackCodec string
@@ -107,44 +108,14 @@ func main() {
 			}
 		}()
 
-		go func() {
-			// Open a IVF file and start reading using our IVFReader
-			file, ivfErr := os.Open(videoFileName)
-			if ivfErr != nil {
-				panic(ivfErr)
-			}
-
-			ivf, header, ivfErr := ivfreader.NewWith(file)
-			if ivfErr != nil {
-				panic(ivfErr)
-			}
-
-			// Wait for connection established
-			<-iceConnectedCtx.Done()
-
-			// Send our video file frame at a time. Pace our sending so we send it at the same speed it should be played back as.
-			// This isn't required since the video is timestamped, but we will such much higher loss if we send all at once.
-			//
-			// It is important to use a time.Ticker instead of time.Sleep because
-			// * avoids accumulating skew, just calling time.Sleep didn't compensate for the time spent parsing the data
-			// * works around latency issues with Sleep (see https://github.com/golang/go/issues/44343)
-			ticker := time.NewTicker(time.Millisecond * time.Duration((float32(header.TimebaseNumerator)/float32(header.TimebaseDenominator))*1000))
-			for ; true; <-ticker.C {
-				frame, _, ivfErr := ivf.ParseNextFrame()
-				if errors.Is(ivfErr, io.EOF) {
-					fmt.Printf("All video frames parsed and sent")
-					os.Exit(0)
-				}
-
-				if ivfErr != nil {
-					panic(ivfErr)
-				}
-
-				if ivfErr = videoTrack.WriteSample(media.Sample{Data: frame, Duration: time.Second}); ivfErr != nil {
-					panic(ivfErr)
-				}
-			}
-		}()
+		// Frames are paced by the player
+		videoReader, videoReaderErr := player.Open(videoFileName)
+		if videoReaderErr != nil {
+			panic(videoReaderErr)
+		}
+		if videoReaderErr = filePlayer.AddTrack(videoReader, videoTrack); videoReaderErr != nil {
+			panic(videoReaderErr)
+		}
 	}
 
 	if haveAudioFile {
@@ -171,51 +142,31 @@ func main() {
 			}
 		}()
 
-		go func() {
-			// Open a OGG file and start reading using our OGGReader
-			file, oggErr := os.Open(audioFileName)
-			if oggErr != nil {
-				panic(oggErr)
-			}
-
-			// Open on oggfile in non-checksum mode.
-			ogg, _, oggErr := oggreader.NewWith(file)
-			if oggErr != nil {
-				panic(oggErr)
-			}
+		// Opus packets are paced by the player, along with the video frames
+		audioReader, audioReaderErr := player.Open(audioFileName)
+		if audioReaderErr != nil {
+			panic(audioReaderErr)
+		}
+		if audioReaderErr = filePlayer.AddTrack(audioReader, audioTrack); audioReaderErr != nil {
+			panic(audioReaderErr)
+		}
+	}
 
-			// Wait for connection established
-			<-iceConnectedCtx.Done()
-
-			// Keep track of last granule, the difference is the amount of samples in the buffer
-			var lastGranule uint64
-
-			// It is important to use a time.Ticker instead of time.Sleep because
-			// * avoids accumulating skew, just calling time.Sleep didn't compensate for the time spent parsing the data
-			// * works around latency issues with Sleep (see https://github.com/golang/go/issues/44343)
-			ticker := time.NewTicker(oggPageDuration)
-			for ; true; <-ticker.C {
-				pageData, pageHeader, oggErr := ogg.ParseNextPage()
-				if errors.Is(oggErr, io.EOF) {
-					fmt.Printf("All audio pages parsed and sent")
-					os.Exit(0)
-				}
+	// Start playing once the connection is established, and exit at the end of the files
+	go func() {
+		<-iceConnectedCtx.Done()
 
-				if oggErr != nil {
-					panic(oggErr)
-				}
-
-				// The amount of samples is the difference between the last and current timestamp
-				sampleCount := float64(pageHeader.GranulePosition - lastGranule)
-				lastGranule = pageHeader.GranulePosition
-				sampleDuration := time.Duration((sampleCount/48000)*1000) * time.Millisecond
+		if playErr := filePlayer.Play(); playErr != nil {
+			panic(playErr)
+		}
+		<-filePlayer.Done()
+		if playErr := filePlayer.Err(); playErr != nil {
+			panic(playErr)
+		}
 
-				if oggErr = audioTrack.WriteSample(media.Sample{Data: pageData, Duration: sampleDuration}); oggErr != nil {
-					panic(oggErr)
-				}
-			}
-		}()
-	}
+		fmt.Printf("All video frames and audio packets parsed and sent")
+		os.Exit(0)
+	}()
 
 	// Set the handler for ICE connection state
 	// This will notify you when the peer has connected/disconnected
@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/pkg/media/h264reader"
+)
+
+const h264NalUnitTypeBitmask = 0x1F
+
+var h264StartCode = []byte{0x00, 0x00, 0x00, 0x01}
+
+// splitAnnexB splits an Annex-B H264 stream into NALs. Data without a start
+// code is a single NAL.
+func splitAnnexB(data []byte) [][]byte {
+	var nals [][]byte
+	start := 0
+	for i := 0; i+2 < len(data); {
+		if data[i] != 0 || data[i+1] != 0 || data[i+2] != 1 {
+			i++
+			continue
+		}
+
+		// Trailing zeros belong to the start code of the next NAL
+		if nal := bytes.TrimRight(data[start:i], "\x00"); len(nal) != 0 {
+			nals = append(nals, nal)
+		}
+		i += 3
+		start = i
+	}
+
+	if start < len(data) {
+		nals = append(nals, data[start:])
+	}
+	return nals
+}
+
+// h264SingleNALPayloader payloads H264 with packetization-mode 0, every
+// NAL is sent in its own packet. This mode has no way to fragment NALs, the
+// ones larger than the MTU are dropped. TrackLocalStaticSample rejects them
+// with checkH264SingleNALSize before they get here.
+type h264SingleNALPayloader struct{}
+
+func (h264SingleNALPayloader) Payload(mtu uint16, payload []byte) [][]byte {
+	var payloads [][]byte
+	for _, nal := range splitAnnexB(payload) {
+		switch h264reader.NalUnitType(nal[0] & h264NalUnitTypeBitmask) {
+		case h264reader.NalUnitTypeAUD, h264reader.NalUnitTypeFiller:
+			continue
+		}
+		if len(nal) > int(mtu) {
+			continue
+		}
+
+		out := make([]byte, len(nal))
+		copy(out, nal)
+		payloads = append(payloads, out)
+	}
+	return payloads
+}
+
+// checkH264SingleNALSize returns ErrH264NALTooLarge if a NAL of the Annex-B
+// payload doesn't fit in mtu
+func checkH264SingleNALSize(mtu int, payload []byte) error {
+	for _, nal := range splitAnnexB(payload) {
+		if len(nal) > mtu {
+			return fmt.Errorf("%w: %d bytes", ErrH264NALTooLarge, len(nal))
+		}
+	}
+	return nil
+}
+
+// h264ParameterSetInjector caches the latest SPS and PPS written, and
+// prepends them to the IDR pictures that are sent without them
+type h264ParameterSetInjector struct {
+	payloader rtp.Payloader
+	sps, pps  []byte
+}
+
+func (p *h264ParameterSetInjector) Payload(mtu uint16, payload []byte) [][]byte {
+	nals := splitAnnexB(payload)
+
+	hasSPS, hasPPS, hasIDR := false, false, false
+	for _, nal := range nals {
+		switch h264reader.NalUnitType(nal[0] & h264NalUnitTypeBitmask) {
+		case h264reader.NalUnitTypeSPS:
+			p.sps = append(p.sps[:0], nal...)
+			hasSPS = true
+		case h264reader.NalUnitTypePPS:
+			p.pps = append(p.pps[:0], nal...)
+			hasPPS = true
+		case h264reader.NalUnitTypeCodedSliceIdr:
+			hasIDR = true
+		}
+	}
+
+	if !hasIDR || (hasSPS && hasPPS) || p.sps == nil || p.pps == nil {
+		return p.payloader.Payload(mtu, payload)
+	}
+
+	// Send the cached parameter sets first, in place of any written with
+	// the picture
+	injected := make([]byte, 0, len(payload)+len(p.sps)+len(p.pps)+2*len(h264StartCode))
+	injected = append(injected, h264StartCode...)
+	injected = append(injected, p.sps...)
+	injected = append(injected, h264StartCode...)
+	injected = append(injected, p.pps...)
+	for _, nal := range nals {
+		switch h264reader.NalUnitType(nal[0] & h264NalUnitTypeBitmask) {
+		case h264reader.NalUnitTypeSPS, h264reader.NalUnitTypePPS:
+			continue
+		}
+
+		injected = append(injected, h264StartCode...)
+		injected = append(injected, nal...)
+	}
+	return p.payloader.Payload(mtu, injected)
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pion/rtp/codecs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitAnnexB(t *testing.T) {
+	assert.Equal(t, [][]byte{{0x67, 0x01}, {0x68, 0x02}, {0x65, 0x03}}, splitAnnexB([]byte{
+		0x00, 0x00, 0x00, 0x01, 0x67, 0x01,
+		0x00, 0x00, 0x01, 0x68, 0x02,
+		0x00, 0x00, 0x00, 0x01, 0x65, 0x03,
+	}))
+	assert.Equal(t, [][]byte{{0x65, 0x03}}, splitAnnexB([]byte{0x65, 0x03}))
+	assert.Nil(t, splitAnnexB(nil))
+}
+
+func TestPayloaderForCodec_H264PacketizationMode(t *testing.T) {
+	for _, test := range []struct {
+		fmtpLine  string
+		singleNAL bool
+	}{
+		{"level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42001f", false},
+		{"level-asymmetry-allowed=1;packetization-mode=0;profile-level-id=42001f", true},
+		{"", false},
+	} {
+		payloader, err := payloaderForCodec(RTPCodecCapability{MimeType: MimeTypeH264, SDPFmtpLine: test.fmtpLine})
+		assert.NoError(t, err)
+		_, isSingleNAL := payloader.(h264SingleNALPayloader)
+		assert.Equal(t, test.singleNAL, isSingleNAL, test.fmtpLine)
+	}
+
+	// Mode 0 sends every NAL on its own, NALs larger than the MTU are dropped
+	annexB := []byte{
+		0x00, 0x00, 0x00, 0x01, 0x09, 0xF0,
+		0x00, 0x00, 0x00, 0x01, 0x67, 0x01,
+		0x00, 0x00, 0x00, 0x01, 0x65, 0x01, 0x02, 0x03, 0x04, 0x05,
+	}
+	assert.Equal(t, [][]byte{{0x67, 0x01}, {0x65, 0x01, 0x02, 0x03, 0x04, 0x05}}, h264SingleNALPayloader{}.Payload(6, annexB))
+	assert.Equal(t, [][]byte{{0x67, 0x01}}, h264SingleNALPayloader{}.Payload(4, annexB))
+
+	assert.NoError(t, checkH264SingleNALSize(6, annexB))
+	assert.ErrorIs(t, checkH264SingleNALSize(4, annexB), ErrH264NALTooLarge)
+}
+
+func TestH264ParameterSetInjector(t *testing.T) {
+	sps, pps := []byte{0x67, 0x42, 0xC0, 0x1F}, []byte{0x68, 0xCE, 0x3C, 0x80}
+	idr, nonIDR := []byte{0x65, 0x88, 0x84}, []byte{0x41, 0x9A, 0x02}
+	stapA := []byte{0x78, 0x00, 0x04}
+	stapA = append(stapA, sps...)
+	stapA = append(stapA, 0x00, 0x04)
+	stapA = append(stapA, pps...)
+
+	annexB := func(nals ...[]byte) []byte {
+		var data []byte
+		for _, nal := range nals {
+			data = append(data, h264StartCode...)
+			data = append(data, nal...)
+		}
+		return data
+	}
+
+	injector := &h264ParameterSetInjector{payloader: &codecs.H264Payloader{}}
+
+	// Nothing to inject before parameter sets are written
+	assert.Equal(t, [][]byte{idr}, injector.Payload(1200, annexB(idr)))
+
+	// Parameter sets written with the IDR are sent once
+	assert.Equal(t, [][]byte{stapA, idr}, injector.Payload(1200, annexB(sps, pps, idr)))
+	assert.Equal(t, [][]byte{nonIDR}, injector.Payload(1200, annexB(nonIDR)))
+
+	// The cached parameter sets are sent with the next IDR
+	assert.Equal(t, [][]byte{stapA, idr}, injector.Payload(1200, annexB(idr)))
+
+	// An IDR with only some of the parameter sets gets the cached ones
+	newPPS := []byte{0x68, 0xCE, 0x3C, 0x81}
+	injector.Payload(1200, annexB(newPPS, nonIDR))
+	newStapA := append(append([]byte{}, stapA[:len(stapA)-len(pps)]...), newPPS...)
+	assert.Equal(t, [][]byte{newStapA, idr}, injector.Payload(1200, annexB(sps, idr)))
+
+	// With packetization-mode 0 they are sent as single NALs
+	injector = &h264ParameterSetInjector{payloader: h264SingleNALPayloader{}}
+	injector.Payload(1200, annexB(sps, pps, idr))
+	assert.Equal(t, [][]byte{sps, pps, idr}, injector.Payload(1200, annexB(idr)))
+}
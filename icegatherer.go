@@ -30,6 +30,11 @@ type ICEGatherer struct {
 
 	agent *ice.Agent
 
+	// Local candidates added by the application or produced by the
+	// SettingEngine candidate rewriter, keyed by the gathered candidate ID
+	addedCandidates     []ICECandidate
+	rewrittenCandidates map[string][]ICECandidate
+
 	onLocalCandidateHandler atomic.Value // func(candidate *ICECandidate)
 	onStateChangeHandler    atomic.Value // func(state ICEGathererState)
 
@@ -175,7 +180,10 @@ func (g *ICEGatherer) Gather() error {
 				g.log.Warnf("Failed to convert ice.Candidate: %s", err)
 				return
 			}
-			onLocalCandidateHandler(&c)
+			for _, rewritten := range g.rewriteCandidate(c) {
+				rewritten := rewritten
+				onLocalCandidateHandler(&rewritten)
+			}
 		} else {
 			g.setState(ICEGathererStateComplete)
 
@@ -246,7 +254,79 @@ func (g *ICEGatherer) GetLocalCandidates() ([]ICECandidate, error) {
 		return nil, err
 	}
 
-	return newICECandidatesFromICE(iceCandidates)
+	gathered, err := newICECandidatesFromICE(iceCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []ICECandidate{}
+	for _, c := range gathered {
+		candidates = append(candidates, g.rewriteCandidate(c)...)
+	}
+
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return append(candidates, g.addedCandidates...), nil
+}
+
+// AddLocalCandidate adds a candidate the application discovered itself, for
+// example a port mapped by a load balancer or a UPnP/NAT-PMP gateway. The
+// candidate is emitted by OnLocalCandidate and included in the local
+// description, traffic sent to it must reach one of the gathered host candidates.
+// Added candidates are dropped when ICE restarts.
+func (g *ICEGatherer) AddLocalCandidate(candidate ICECandidate) error {
+	if _, err := candidate.toICE(); err != nil {
+		return err
+	}
+
+	g.lock.Lock()
+	if g.State() == ICEGathererStateClosed {
+		g.lock.Unlock()
+		return fmt.Errorf("%w: unable to add local candidate", errICEAgentNotExist)
+	}
+	g.addedCandidates = append(g.addedCandidates, candidate)
+	g.lock.Unlock()
+
+	if handler, ok := g.onLocalCandidateHandler.Load().(func(candidate *ICECandidate)); ok && handler != nil {
+		handler(&candidate)
+	}
+	return nil
+}
+
+// rewriteCandidate applies the SettingEngine candidate rewriter to a gathered
+// candidate. The result is cached so the rewriter runs once per candidate.
+func (g *ICEGatherer) rewriteCandidate(candidate ICECandidate) []ICECandidate {
+	rewriter := g.api.settingEngine.candidates.Rewriter
+	if rewriter == nil {
+		return []ICECandidate{candidate}
+	}
+
+	g.lock.RLock()
+	rewritten, ok := g.rewrittenCandidates[candidate.statsID]
+	g.lock.RUnlock()
+	if ok {
+		return rewritten
+	}
+
+	rewritten = rewriter(candidate)
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.rewrittenCandidates == nil {
+		g.rewrittenCandidates = map[string][]ICECandidate{}
+	}
+	g.rewrittenCandidates[candidate.statsID] = rewritten
+	return rewritten
+}
+
+// clearLocalCandidates drops the added and rewritten candidates of the
+// previous ICE generation
+func (g *ICEGatherer) clearLocalCandidates() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.addedCandidates = nil
+	g.rewrittenCandidates = nil
 }
 
 // OnLocalCandidate sets an event handler which fires when a new local ICE candidate is available
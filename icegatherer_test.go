@@ -156,3 +156,70 @@ func TestICEGatherer_AlreadyClosed(t *testing.T) {
 		assert.ErrorIs(t, err, errICEAgentNotExist)
 	})
 }
+
+func TestICEGatherer_RewriteAndAddLocalCandidate(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// Replace every host candidate by the address of a port mapping
+	rewriterCalls := 0
+	s := SettingEngine{}
+	s.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+	s.SetICECandidateRewriter(func(c ICECandidate) []ICECandidate {
+		rewriterCalls++
+		return []ICECandidate{{
+			Typ:            ICECandidateTypeSrflx,
+			Protocol:       c.Protocol,
+			Address:        "203.0.113.1",
+			Port:           c.Port + 10000,
+			Component:      c.Component,
+			RelatedAddress: c.Address,
+			RelatedPort:    c.Port,
+		}}
+	})
+
+	gatherer, err := NewAPI(WithSettingEngine(s)).NewICEGatherer(ICEGatherOptions{})
+	assert.NoError(t, err)
+
+	var emitted []ICECandidate
+	gatherFinished := make(chan struct{})
+	gatherer.OnLocalCandidate(func(c *ICECandidate) {
+		if c == nil {
+			close(gatherFinished)
+			return
+		}
+		emitted = append(emitted, *c)
+	})
+
+	assert.NoError(t, gatherer.Gather())
+	<-gatherFinished
+
+	assert.NotEmpty(t, emitted)
+	for _, c := range emitted {
+		assert.Equal(t, ICECandidateTypeSrflx, c.Typ)
+		assert.Equal(t, "203.0.113.1", c.Address)
+	}
+
+	added := ICECandidate{
+		Typ:      ICECandidateTypeHost,
+		Protocol: ICEProtocolUDP,
+		Address:  "198.51.100.1",
+		Port:     4000,
+	}
+	gathered := append([]ICECandidate{}, emitted...)
+	assert.NoError(t, gatherer.AddLocalCandidate(added))
+	assert.Error(t, gatherer.AddLocalCandidate(ICECandidate{Typ: ICECandidateTypeUnknown}))
+	assert.Equal(t, append(gathered, added), emitted)
+
+	// The rewriter runs once per gathered candidate
+	candidates, err := gatherer.GetLocalCandidates()
+	assert.NoError(t, err)
+	assert.Equal(t, emitted, candidates)
+	assert.Equal(t, len(gathered), rewriterCalls)
+
+	assert.NoError(t, gatherer.Close())
+	assert.Error(t, gatherer.AddLocalCandidate(added))
+}
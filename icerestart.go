@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"time"
+)
+
+// iceRestarter implements the automatic ICE restart policy configured with
+// SettingEngine.SetICERestartPolicy
+type iceRestarter struct {
+	restart func() error
+	state   func() ICEConnectionState
+
+	disconnectedDelay time.Duration
+	initialBackoff    time.Duration
+	maxBackoff        time.Duration
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	backoff time.Duration
+	closed  bool
+
+	// restarting is set while restart runs without mu held. connections
+	// counts the connected states, a restart that completes after ICE
+	// connected again doesn't back off.
+	restarting  bool
+	connections uint64
+}
+
+func newICERestarter(e *SettingEngine, restart func() error, state func() ICEConnectionState) *iceRestarter {
+	return &iceRestarter{
+		restart:           restart,
+		state:             state,
+		disconnectedDelay: e.iceRestart.disconnectedDelay,
+		initialBackoff:    e.iceRestart.initialBackoff,
+		maxBackoff:        e.iceRestart.maxBackoff,
+	}
+}
+
+// handleStateChange schedules or cancels a restart for the new ICE connection state
+func (r *iceRestarter) handleStateChange(state ICEConnectionState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+
+	switch state {
+	case ICEConnectionStateConnected, ICEConnectionStateCompleted:
+		r.stopTimer()
+		r.backoff = 0
+		r.connections++
+	case ICEConnectionStateDisconnected:
+		if r.timer == nil && r.disconnectedDelay != 0 {
+			r.timer = time.AfterFunc(r.disconnectedDelay, r.fire)
+		}
+	case ICEConnectionStateFailed:
+		// A restart already scheduled keeps its backoff
+		if r.timer == nil {
+			r.timer = time.AfterFunc(r.backoff, r.fire)
+		}
+	case ICEConnectionStateClosed:
+		r.closed = true
+		r.stopTimer()
+	default:
+	}
+}
+
+// fire restarts ICE if it is still disconnected or failed. mu is released
+// while restart runs, as it takes the PeerConnection lock and fires
+// negotiationneeded.
+func (r *iceRestarter) fire() {
+	r.mu.Lock()
+	r.timer = nil
+	if r.closed || r.restarting {
+		r.mu.Unlock()
+		return
+	}
+
+	switch r.state() {
+	case ICEConnectionStateDisconnected, ICEConnectionStateFailed:
+	default:
+		r.mu.Unlock()
+		return
+	}
+
+	r.restarting = true
+	connections := r.connections
+	r.mu.Unlock()
+
+	err := r.restart()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.restarting = false
+	if err != nil || r.closed || r.connections != connections || r.timer != nil {
+		return
+	}
+
+	// Check again after the backoff in case the restart didn't help
+	switch {
+	case r.backoff == 0:
+		r.backoff = r.initialBackoff
+	case r.backoff*2 > r.maxBackoff:
+		r.backoff = r.maxBackoff
+	default:
+		r.backoff *= 2
+	}
+	if r.backoff != 0 {
+		r.timer = time.AfterFunc(r.backoff, r.fire)
+	}
+}
+
+func (r *iceRestarter) stopTimer() {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+}
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestICERestarter(t *testing.T) {
+	var (
+		restarts uint32
+		state    atomic.Value
+	)
+	state.Store(ICEConnectionStateConnected)
+
+	s := SettingEngine{}
+	s.SetICERestartPolicy(10*time.Millisecond, 20*time.Millisecond, 40*time.Millisecond)
+	r := newICERestarter(&s, func() error {
+		atomic.AddUint32(&restarts, 1)
+		return nil
+	}, func() ICEConnectionState {
+		return state.Load().(ICEConnectionState) //nolint:forcetypeassert
+	})
+
+	t.Run("Recovered before delay", func(t *testing.T) {
+		state.Store(ICEConnectionStateDisconnected)
+		r.handleStateChange(ICEConnectionStateDisconnected)
+		state.Store(ICEConnectionStateConnected)
+		r.handleStateChange(ICEConnectionStateConnected)
+
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, uint32(0), atomic.LoadUint32(&restarts))
+	})
+
+	t.Run("Restart with backoff", func(t *testing.T) {
+		state.Store(ICEConnectionStateDisconnected)
+		r.handleStateChange(ICEConnectionStateDisconnected)
+
+		assert.Eventually(t, func() bool {
+			return atomic.LoadUint32(&restarts) >= 3
+		}, time.Second, 5*time.Millisecond)
+
+		r.mu.Lock()
+		assert.Equal(t, 40*time.Millisecond, r.backoff)
+		r.mu.Unlock()
+
+		state.Store(ICEConnectionStateConnected)
+		r.handleStateChange(ICEConnectionStateConnected)
+
+		r.mu.Lock()
+		assert.Equal(t, time.Duration(0), r.backoff)
+		assert.Nil(t, r.timer)
+		r.mu.Unlock()
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		atomic.StoreUint32(&restarts, 0)
+		state.Store(ICEConnectionStateFailed)
+		r.handleStateChange(ICEConnectionStateClosed)
+		r.handleStateChange(ICEConnectionStateFailed)
+
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, uint32(0), atomic.LoadUint32(&restarts))
+	})
+}
+
+func TestICERestarter_StateChangeDuringRestart(t *testing.T) {
+	s := SettingEngine{}
+	s.SetICERestartPolicy(time.Millisecond, 20*time.Millisecond, 40*time.Millisecond)
+
+	var r *iceRestarter
+	restarted := make(chan struct{})
+	r = newICERestarter(&s, func() error {
+		// Like RestartICE, the restart changes the ICE connection state
+		r.handleStateChange(ICEConnectionStateChecking)
+		r.handleStateChange(ICEConnectionStateConnected)
+		close(restarted)
+		return nil
+	}, func() ICEConnectionState {
+		return ICEConnectionStateDisconnected
+	})
+
+	r.handleStateChange(ICEConnectionStateDisconnected)
+	select {
+	case <-restarted:
+	case <-time.After(time.Second):
+		assert.Fail(t, "restart did not run")
+	}
+
+	assert.Eventually(t, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return !r.restarting
+	}, time.Second, time.Millisecond)
+
+	// ICE connected again during the restart, so it doesn't back off
+	r.mu.Lock()
+	assert.Equal(t, time.Duration(0), r.backoff)
+	assert.Nil(t, r.timer)
+	r.mu.Unlock()
+}
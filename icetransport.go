@@ -182,6 +182,7 @@ func (t *ICETransport) restart() error {
 	if err := agent.Restart(t.gatherer.api.settingEngine.candidates.UsernameFragment, t.gatherer.api.settingEngine.candidates.Password); err != nil {
 		return err
 	}
+	t.gatherer.clearLocalCandidates()
 	return t.gatherer.Gather()
 }
 
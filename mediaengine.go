@@ -653,6 +653,11 @@ func (m *MediaEngine) getRTPParametersByPayloadType(payloadType PayloadType) (RT
 func payloaderForCodec(codec RTPCodecCapability) (rtp.Payloader, error) {
 	switch strings.ToLower(codec.MimeType) {
 	case strings.ToLower(MimeTypeH264):
+		// Mode 1 is used unless mode 0 is negotiated explicitly
+		mode, _ := fmtp.Parse(codec.MimeType, codec.SDPFmtpLine).Parameter("packetization-mode")
+		if mode == "0" {
+			return h264SingleNALPayloader{}, nil
+		}
 		return &codecs.H264Payloader{}, nil
 	case strings.ToLower(MimeTypeOpus):
 		return &codecs.OpusPayloader{}, nil
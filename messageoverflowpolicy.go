@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+// MessageOverflowPolicy decides what DataChannel.Messages does with an
+// incoming message when the buffer of the returned channel is full.
+type MessageOverflowPolicy int
+
+const (
+	// MessageOverflowPolicyUnknown is the enum's zero-value
+	MessageOverflowPolicyUnknown MessageOverflowPolicy = iota
+
+	// MessageOverflowPolicyBlock waits until the message can be buffered,
+	// no further messages are read from the DataChannel meanwhile.
+	MessageOverflowPolicyBlock
+
+	// MessageOverflowPolicyDropOldest discards the oldest buffered message
+	// to make room for the incoming one.
+	MessageOverflowPolicyDropOldest
+
+	// MessageOverflowPolicyClose closes the DataChannel, OnError is invoked
+	// with ErrDataChannelMessagesOverflow.
+	MessageOverflowPolicyClose
+)
+
+// This is done this way because of a linter.
+const (
+	messageOverflowPolicyBlockStr      = "block"
+	messageOverflowPolicyDropOldestStr = "drop-oldest"
+	messageOverflowPolicyCloseStr      = "close"
+)
+
+func (p MessageOverflowPolicy) String() string {
+	switch p {
+	case MessageOverflowPolicyBlock:
+		return messageOverflowPolicyBlockStr
+	case MessageOverflowPolicyDropOldest:
+		return messageOverflowPolicyDropOldestStr
+	case MessageOverflowPolicyClose:
+		return messageOverflowPolicyCloseStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageOverflowPolicy_String(t *testing.T) {
+	testCases := []struct {
+		policy         MessageOverflowPolicy
+		expectedString string
+	}{
+		{MessageOverflowPolicyUnknown, ErrUnknownType.Error()},
+		{MessageOverflowPolicyBlock, "block"},
+		{MessageOverflowPolicyDropOldest, "drop-oldest"},
+		{MessageOverflowPolicyClose, "close"},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.policy.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
@@ -0,0 +1,193 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
+)
+
+// NegotiatedDataChannelInit declares one DataChannel of a
+// NegotiatedDataChannelGroup. The fields match DataChannelInit.
+type NegotiatedDataChannelInit struct {
+	Label             string
+	Protocol          string
+	Ordered           *bool
+	MaxPacketLifeTime *uint16
+	MaxRetransmits    *uint16
+	Priority          *PriorityType
+	Detach            *bool
+
+	// Role is the DTLS role whose IDs the DataChannel uses, DTLSRoleClient
+	// for even and DTLSRoleServer for odd IDs. It must be the same on both
+	// peers.
+	Role DTLSRole
+}
+
+// NegotiatedDataChannelGroup is a Pion specific helper for DataChannels that
+// are negotiated out-of-band. Both peers declare the same group, no DCEP
+// messages are exchanged to open its DataChannels.
+//
+// Like in-band DataChannels, the DataChannels of each DTLS role get the IDs
+// of its parity: sorted by label, the ones of the DTLS client are numbered
+// with the even and the ones of the DTLS server with the odd IDs from the
+// first ID on. Each label gets the same ID on both peers regardless of the
+// order of declaration. The IDs of the group are reserved on the
+// SCTPTransport. An in-band DataChannel the remote peer opens with one of them
+// while the DataChannel of the group is closed is closed as well and reported
+// to SCTPTransport.OnError, the open DataChannel of the group ignores it.
+type NegotiatedDataChannelGroup struct {
+	channels []NegotiatedDataChannelInit
+	ids      []uint16
+	ranges   []dataChannelIDRange
+}
+
+// dataChannelIDRange holds the IDs of one parity from first to last
+type dataChannelIDRange struct {
+	first, last uint16
+}
+
+func (r dataChannelIDRange) contains(id uint16) bool {
+	return id >= r.first && id <= r.last && (id-r.first)%2 == 0
+}
+
+// NewNegotiatedDataChannelGroup creates a NegotiatedDataChannelGroup whose
+// DataChannels use the IDs from firstID on. The IDs should be kept clear of
+// in-band DataChannels, which are numbered from 0.
+func NewNegotiatedDataChannelGroup(firstID uint16, channels ...NegotiatedDataChannelInit) (*NegotiatedDataChannelGroup, error) {
+	sorted := append([]NegotiatedDataChannelInit{}, channels...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Label < sorted[j].Label
+	})
+
+	// The next ID of each role, the DTLS client starts at an even ID
+	nextID := map[DTLSRole]int{DTLSRoleClient: int(firstID), DTLSRoleServer: int(firstID)}
+	if firstID%2 == 0 {
+		nextID[DTLSRoleServer]++
+	} else {
+		nextID[DTLSRoleClient]++
+	}
+
+	group := &NegotiatedDataChannelGroup{channels: sorted, ids: make([]uint16, len(sorted))}
+	roleRanges := map[DTLSRole]*dataChannelIDRange{}
+	for i, channel := range sorted {
+		if i > 0 && sorted[i-1].Label == channel.Label {
+			return nil, &rtcerr.TypeError{Err: fmt.Errorf("%w: %q", errNegotiatedDataChannelDuplicateLabel, channel.Label)}
+		}
+		if channel.Role != DTLSRoleClient && channel.Role != DTLSRoleServer {
+			return nil, &rtcerr.TypeError{Err: fmt.Errorf("%w: %q", errNegotiatedDataChannelRole, channel.Label)}
+		}
+		if channel.MaxPacketLifeTime != nil && channel.MaxRetransmits != nil {
+			return nil, &rtcerr.TypeError{Err: ErrRetransmitsOrPacketLifeTime}
+		}
+		if len(channel.Protocol) > math.MaxUint16 {
+			return nil, &rtcerr.TypeError{Err: ErrProtocolTooLarge}
+		}
+
+		id := nextID[channel.Role]
+		if id >= math.MaxUint16 {
+			return nil, &rtcerr.TypeError{Err: fmt.Errorf("%w: %d channels from %d", errNegotiatedDataChannelIDOverflow, len(channels), firstID)}
+		}
+		nextID[channel.Role] += 2
+		group.ids[i] = uint16(id)
+
+		if r, ok := roleRanges[channel.Role]; ok {
+			r.last = uint16(id)
+		} else {
+			roleRanges[channel.Role] = &dataChannelIDRange{first: uint16(id), last: uint16(id)}
+		}
+	}
+
+	for _, role := range []DTLSRole{DTLSRoleClient, DTLSRoleServer} {
+		if r, ok := roleRanges[role]; ok {
+			group.ranges = append(group.ranges, *r)
+		}
+	}
+	return group, nil
+}
+
+// ID returns the ID assigned to the DataChannel with label
+func (g *NegotiatedDataChannelGroup) ID(label string) (uint16, bool) {
+	i := sort.Search(len(g.channels), func(i int) bool {
+		return g.channels[i].Label >= label
+	})
+	if i == len(g.channels) || g.channels[i].Label != label {
+		return 0, false
+	}
+
+	return g.ids[i], true
+}
+
+// CreateNegotiatedDataChannels creates the DataChannels of group, keyed by
+// label. It fails without creating any DataChannel if one of the IDs is
+// already used by another DataChannel of the PeerConnection. If creating a
+// DataChannel fails, the ones already created are closed and the IDs of the
+// group are released. Otherwise the IDs stay reserved for the lifetime of
+// the SCTPTransport.
+func (pc *PeerConnection) CreateNegotiatedDataChannels(group *NegotiatedDataChannelGroup) (map[string]*DataChannel, error) {
+	if pc.isClosed.get() {
+		return nil, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
+	}
+
+	maxChannels := pc.sctpTransport.MaxChannels()
+
+	pc.sctpTransport.lock.Lock()
+	for _, id := range group.ids {
+		if id >= maxChannels {
+			pc.sctpTransport.lock.Unlock()
+			return nil, &rtcerr.OperationError{Err: fmt.Errorf("%w: %d", errNegotiatedDataChannelIDOverflow, id)}
+		}
+		if _, ok := pc.sctpTransport.dataChannelIDsUsed[id]; ok {
+			pc.sctpTransport.lock.Unlock()
+			return nil, &rtcerr.OperationError{Err: fmt.Errorf("%w: %d", errNegotiatedDataChannelIDInUse, id)}
+		}
+	}
+
+	// Reserve the IDs so in-band DataChannels created meanwhile skip them and
+	// the ones the remote peer opens are rejected
+	for _, id := range group.ids {
+		pc.sctpTransport.dataChannelIDsUsed[id] = struct{}{}
+	}
+	pc.sctpTransport.reservedDataChannelIDs = append(pc.sctpTransport.reservedDataChannelIDs, group.ranges...)
+	pc.sctpTransport.lock.Unlock()
+
+	dataChannels := make(map[string]*DataChannel, len(group.channels))
+	for i, channel := range group.channels {
+		id := group.ids[i]
+		protocol := channel.Protocol
+		negotiated := true
+
+		d, err := pc.CreateDataChannel(channel.Label, &DataChannelInit{
+			Ordered:           channel.Ordered,
+			MaxPacketLifeTime: channel.MaxPacketLifeTime,
+			MaxRetransmits:    channel.MaxRetransmits,
+			Protocol:          &protocol,
+			Negotiated:        &negotiated,
+			ID:                &id,
+			Priority:          channel.Priority,
+			Detach:            channel.Detach,
+		})
+		if err != nil {
+			for _, created := range dataChannels {
+				if closeErr := created.Close(); closeErr != nil {
+					pc.log.Warnf("Failed to close DataChannel: %v", closeErr)
+				}
+			}
+
+			pc.sctpTransport.releaseDataChannelIDs(group.ids, group.ranges)
+
+			return nil, err
+		}
+
+		dataChannels[channel.Label] = d
+	}
+
+	return dataChannels, nil
+}
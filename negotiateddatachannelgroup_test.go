@@ -0,0 +1,200 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewNegotiatedDataChannelGroup(t *testing.T) {
+	unordered := false
+	lifetime, retransmits := uint16(100), uint16(3)
+
+	group, err := NewNegotiatedDataChannelGroup(100,
+		NegotiatedDataChannelInit{Label: "video-control", Role: DTLSRoleClient},
+		NegotiatedDataChannelInit{Label: "chat", Protocol: "json", Role: DTLSRoleClient},
+		NegotiatedDataChannelInit{Label: "telemetry", Ordered: &unordered, MaxRetransmits: &retransmits, Role: DTLSRoleServer},
+		NegotiatedDataChannelInit{Label: "audio-control", Role: DTLSRoleServer},
+	)
+	assert.NoError(t, err)
+
+	// IDs don't depend on the order of declaration
+	reordered, err := NewNegotiatedDataChannelGroup(100,
+		NegotiatedDataChannelInit{Label: "telemetry", Ordered: &unordered, MaxRetransmits: &retransmits, Role: DTLSRoleServer},
+		NegotiatedDataChannelInit{Label: "audio-control", Role: DTLSRoleServer},
+		NegotiatedDataChannelInit{Label: "video-control", Role: DTLSRoleClient},
+		NegotiatedDataChannelInit{Label: "chat", Protocol: "json", Role: DTLSRoleClient},
+	)
+	assert.NoError(t, err)
+
+	// The DTLS client uses even and the DTLS server odd IDs
+	for label, expectedID := range map[string]uint16{"audio-control": 101, "chat": 100, "telemetry": 103, "video-control": 102} {
+		id, ok := group.ID(label)
+		assert.True(t, ok)
+		assert.Equal(t, expectedID, id)
+
+		id, ok = reordered.ID(label)
+		assert.True(t, ok)
+		assert.Equal(t, expectedID, id)
+	}
+
+	_, ok := group.ID("missing")
+	assert.False(t, ok)
+	assert.Equal(t, []dataChannelIDRange{{first: 100, last: 102}, {first: 101, last: 103}}, group.ranges)
+
+	// The DTLS client starts at the next even ID
+	oddGroup, err := NewNegotiatedDataChannelGroup(7,
+		NegotiatedDataChannelInit{Label: "a", Role: DTLSRoleServer},
+		NegotiatedDataChannelInit{Label: "b", Role: DTLSRoleClient},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{7, 8}, oddGroup.ids)
+
+	var typeErr *rtcerr.TypeError
+	_, err = NewNegotiatedDataChannelGroup(0, NegotiatedDataChannelInit{Label: "a", Role: DTLSRoleClient}, NegotiatedDataChannelInit{Label: "a", Role: DTLSRoleServer})
+	assert.ErrorAs(t, err, &typeErr)
+	assert.ErrorIs(t, err, errNegotiatedDataChannelDuplicateLabel)
+
+	_, err = NewNegotiatedDataChannelGroup(0, NegotiatedDataChannelInit{Label: "a"})
+	assert.ErrorIs(t, err, errNegotiatedDataChannelRole)
+
+	_, err = NewNegotiatedDataChannelGroup(math.MaxUint16-2, NegotiatedDataChannelInit{Label: "a", Role: DTLSRoleServer}, NegotiatedDataChannelInit{Label: "b", Role: DTLSRoleServer})
+	assert.ErrorIs(t, err, errNegotiatedDataChannelIDOverflow)
+
+	_, err = NewNegotiatedDataChannelGroup(0, NegotiatedDataChannelInit{Label: "a", MaxPacketLifeTime: &lifetime, MaxRetransmits: &retransmits, Role: DTLSRoleClient})
+	assert.ErrorIs(t, err, ErrRetransmitsOrPacketLifeTime)
+}
+
+func TestPeerConnection_CreateNegotiatedDataChannels(t *testing.T) {
+	to := test.TimeOut(time.Second * 20)
+	defer to.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		switch d.Label() {
+		case "control", "events", "bulk":
+			assert.Fail(t, "negotiated DataChannels must not be announced", d.Label())
+		case "in-band-reserved":
+			assert.Fail(t, "DataChannels on reserved IDs must be rejected")
+		}
+	})
+
+	// An in-band DataChannel occupies an ID of the group
+	inBandID := uint16(11)
+	_, err = offerPC.CreateDataChannel("in-band", &DataChannelInit{ID: &inBandID})
+	assert.NoError(t, err)
+
+	collidingGroup, err := NewNegotiatedDataChannelGroup(10,
+		NegotiatedDataChannelInit{Label: "a", Role: DTLSRoleClient},
+		NegotiatedDataChannelInit{Label: "b", Role: DTLSRoleServer},
+	)
+	assert.NoError(t, err)
+	_, err = offerPC.CreateNegotiatedDataChannels(collidingGroup)
+	assert.ErrorIs(t, err, errNegotiatedDataChannelIDInUse)
+
+	// A DataChannel that can't be created releases the IDs of the group
+	failingGroup, err := NewNegotiatedDataChannelGroup(20,
+		NegotiatedDataChannelInit{Label: "a", Role: DTLSRoleClient},
+		NegotiatedDataChannelInit{Label: strings.Repeat("b", 65536), Role: DTLSRoleClient},
+	)
+	assert.NoError(t, err)
+	_, err = offerPC.CreateNegotiatedDataChannels(failingGroup)
+	assert.ErrorIs(t, err, ErrStringSizeLimit)
+	offerPC.sctpTransport.lock.RLock()
+	assert.NotContains(t, offerPC.sctpTransport.dataChannelIDsUsed, uint16(20))
+	assert.NotContains(t, offerPC.sctpTransport.dataChannelIDsUsed, uint16(22))
+	assert.Empty(t, offerPC.sctpTransport.reservedDataChannelIDs)
+	offerPC.sctpTransport.lock.RUnlock()
+
+	newGroup := func(labels ...string) *NegotiatedDataChannelGroup {
+		channels := []NegotiatedDataChannelInit{}
+		for i, label := range labels {
+			role := DTLSRoleClient
+			if i%2 == 1 {
+				role = DTLSRoleServer
+			}
+			channels = append(channels, NegotiatedDataChannelInit{Label: label, Protocol: "proto-" + label, Role: role})
+		}
+		group, groupErr := NewNegotiatedDataChannelGroup(1000, channels...)
+		assert.NoError(t, groupErr)
+		return group
+	}
+
+	offerChannels, err := offerPC.CreateNegotiatedDataChannels(newGroup("control", "events", "bulk"))
+	assert.NoError(t, err)
+	answerChannels, err := answerPC.CreateNegotiatedDataChannels(newGroup("control", "events", "bulk"))
+	assert.NoError(t, err)
+	assert.Len(t, offerChannels, 3)
+
+	// The answerer reserves an ID without an open DataChannel, like one of a
+	// group whose DataChannel was closed
+	answerPC.sctpTransport.lock.Lock()
+	answerPC.sctpTransport.reservedDataChannelIDs = append(answerPC.sctpTransport.reservedDataChannelIDs, dataChannelIDRange{first: 2000, last: 2000})
+	answerPC.sctpTransport.lock.Unlock()
+	sctpErrs := make(chan error, 1)
+	answerPC.SCTP().OnError(func(err error) {
+		sctpErrs <- err
+	})
+
+	received := make(chan string, 3)
+	for label, d := range answerChannels {
+		label, d := label, d
+		assert.True(t, d.Negotiated())
+		assert.Equal(t, "proto-"+label, d.Protocol())
+		d.OnMessage(func(msg DataChannelMessage) {
+			assert.Equal(t, label, string(msg.Data))
+			received <- label
+		})
+	}
+
+	opened := make(chan struct{}, 3)
+	for label, d := range offerChannels {
+		label, d := label, d
+		assert.Equal(t, *answerChannels[label].ID(), *d.ID())
+		d.OnOpen(func() {
+			assert.NoError(t, d.SendText(label))
+			opened <- struct{}{}
+		})
+	}
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	for i := 0; i < 3; i++ {
+		<-opened
+	}
+	labels := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		labels[<-received] = true
+	}
+	assert.Equal(t, map[string]bool{"bulk": true, "control": true, "events": true}, labels)
+
+	// The answerer rejects an in-band DataChannel on a reserved ID
+	reservedID := uint16(2000)
+	_, err = offerPC.CreateDataChannel("in-band-reserved", &DataChannelInit{ID: &reservedID})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, <-sctpErrs, ErrDataChannelIDReserved)
+
+	// In-band DataChannels skip the IDs of the group
+	for i := 0; i < 5; i++ {
+		d, err := offerPC.CreateDataChannel("after", nil)
+		assert.NoError(t, err)
+		assert.False(t, *d.ID() >= 1000 && *d.ID() < 1003)
+	}
+
+	closePairNow(t, offerPC, answerPC)
+}
@@ -59,6 +59,10 @@ type PeerConnection struct {
 	isNegotiationNeeded    *atomicBool
 	negotiationNeededState negotiationNeededState
 
+	// iceRestartRequested is set by RestartICE and cleared by the next offer
+	iceRestartRequested *atomicBool
+	iceRestarter        *iceRestarter
+
 	lastOffer  string
 	lastAnswer string
 
@@ -118,6 +122,7 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 		isClosed:               &atomicBool{},
 		isNegotiationNeeded:    &atomicBool{},
 		negotiationNeededState: negotiationNeededStateEmpty,
+		iceRestartRequested:    &atomicBool{},
 		lastOffer:              "",
 		lastAnswer:             "",
 		greaterMid:             -1,
@@ -129,6 +134,10 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 	pc.iceConnectionState.Store(ICEConnectionStateNew)
 	pc.connectionState.Store(PeerConnectionStateNew)
 
+	if api.settingEngine.iceRestart.enabled {
+		pc.iceRestarter = newICERestarter(api.settingEngine, pc.RestartICE, pc.ICEConnectionState)
+	}
+
 	i, err := api.interceptorRegistry.Build("")
 	if err != nil {
 		return nil, err
@@ -356,6 +365,11 @@ func (pc *PeerConnection) checkNegotiationNeeded() bool { //nolint:gocognit
 		return true
 	}
 
+	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-restartice
+	if pc.iceRestartRequested.get() {
+		return true
+	}
+
 	pc.sctpTransport.lock.Lock()
 	lenDataChannel := len(pc.sctpTransport.dataChannels)
 	pc.sctpTransport.lock.Unlock()
@@ -367,15 +381,16 @@ func (pc *PeerConnection) checkNegotiationNeeded() bool { //nolint:gocognit
 	for _, t := range pc.rtpTransceivers {
 		// https://www.w3.org/TR/webrtc/#dfn-update-the-negotiation-needed-flag
 		// Step 5.1
-		// if t.stopping && !t.stopped {
-		// 	return true
-		// }
+		stopped := t.isStopped()
+		if t.isStopping() && !stopped {
+			return true
+		}
 		m := getByMid(t.Mid(), localDesc)
 		// Step 5.2
-		if !t.stopped && m == nil {
+		if !stopped && m == nil {
 			return true
 		}
-		if !t.stopped && m != nil {
+		if !stopped && m != nil {
 			// Step 5.3.1
 			if t.Direction() == RTPTransceiverDirectionSendrecv || t.Direction() == RTPTransceiverDirectionSendonly {
 				descMsid, okMsid := m.Attribute(sdp.AttrKeyMsid)
@@ -416,10 +431,15 @@ func (pc *PeerConnection) checkNegotiationNeeded() bool { //nolint:gocognit
 			}
 		}
 		// Step 5.4
-		if t.stopped && t.Mid() != "" {
-			if getByMid(t.Mid(), localDesc) != nil || getByMid(t.Mid(), remoteDesc) != nil {
+		if stopped && t.Mid() != "" {
+			if lm := getByMid(t.Mid(), localDesc); lm != nil && !isMediaSectionRejected(lm) {
 				return true
 			}
+			if remoteDesc != nil {
+				if rm := getByMid(t.Mid(), remoteDesc); rm != nil && !isMediaSectionRejected(rm) {
+					return true
+				}
+			}
 		}
 	}
 	// Step 6
@@ -436,6 +456,16 @@ func (pc *PeerConnection) OnICECandidate(f func(*ICECandidate)) {
 	pc.iceGatherer.OnLocalCandidate(f)
 }
 
+// AddLocalICECandidate adds a local candidate discovered by the application,
+// see ICEGatherer.AddLocalCandidate
+func (pc *PeerConnection) AddLocalICECandidate(candidate ICECandidate) error {
+	if pc.isClosed.get() {
+		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
+	}
+
+	return pc.iceGatherer.AddLocalCandidate(candidate)
+}
+
 // OnICEGatheringStateChange sets an event handler which is invoked when the
 // ICE candidate gathering state has changed.
 func (pc *PeerConnection) OnICEGatheringStateChange(f func(ICEGatheringState)) {
@@ -484,6 +514,9 @@ func (pc *PeerConnection) OnICEConnectionStateChange(f func(ICEConnectionState))
 func (pc *PeerConnection) onICEConnectionStateChange(cs ICEConnectionState) {
 	pc.iceConnectionState.Store(cs)
 	pc.log.Infof("ICE connection state changed: %s", cs)
+	if pc.iceRestarter != nil {
+		pc.iceRestarter.handleStateChange(cs)
+	}
 	if handler, ok := pc.onICEConnectionStateChangeHandler.Load().(func(ICEConnectionState)); ok && handler != nil {
 		handler(cs)
 	}
@@ -592,6 +625,11 @@ func (pc *PeerConnection) getStatsID() string {
 // caller of this method should hold `pc.mu` lock
 func (pc *PeerConnection) hasLocalDescriptionChanged(desc *SessionDescription) bool {
 	for _, t := range pc.rtpTransceivers {
+		// Stopping transceivers are either rejected or left out of the description
+		if t.isStopping() {
+			continue
+		}
+
 		m := getByMid(t.Mid(), desc)
 		if m == nil {
 			return true
@@ -615,7 +653,7 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 		return SessionDescription{}, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
-	if options != nil && options.ICERestart {
+	if (options != nil && options.ICERestart) || pc.iceRestartRequested.swap(false) {
 		if err := pc.iceTransport.restart(); err != nil {
 			return SessionDescription{}, err
 		}
@@ -676,6 +714,10 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 					}
 					continue
 				}
+				// A transceiver stopped before it was ever negotiated gets no media section
+				if t.isStopping() {
+					continue
+				}
 				pc.greaterMid++
 				err = t.SetMid(strconv.Itoa(pc.greaterMid))
 				if err != nil {
@@ -807,6 +849,24 @@ func (pc *PeerConnection) createICETransport() *ICETransport {
 	return t
 }
 
+// RestartICE tells the PeerConnection that ICE should be restarted. It fires
+// OnNegotiationNeeded and the next call to CreateOffer generates new ICE
+// credentials, as if OfferOptions.ICERestart was set.
+// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-restartice
+func (pc *PeerConnection) RestartICE() error {
+	if pc.isClosed.get() {
+		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
+	}
+
+	pc.iceRestartRequested.set(true)
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.onNegotiationNeeded()
+
+	return nil
+}
+
 // CreateAnswer starts the PeerConnection and generates the localDescription
 func (pc *PeerConnection) CreateAnswer(*AnswerOptions) (SessionDescription, error) {
 	useIdentity := pc.idpLoginURL != nil
@@ -971,6 +1031,46 @@ func (pc *PeerConnection) setDescription(sd *SessionDescription, op stateChangeO
 	return err
 }
 
+// rollbackDescription discards the pending offer and returns to the stable
+// state. Transceivers created by a rolled back remote offer are removed, mids
+// assigned to the others are released so they can be matched again by the
+// next offer.
+// https://www.w3.org/TR/webrtc/#set-description
+func (pc *PeerConnection) rollbackDescription(desc *SessionDescription, op stateChangeOp) error {
+	if err := pc.setDescription(desc, op); err != nil {
+		return err
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	transceivers := make([]*RTPTransceiver, 0, len(pc.rtpTransceivers))
+	for _, t := range pc.rtpTransceivers {
+		mid := t.Mid()
+		if mid == "" ||
+			pc.currentLocalDescription != nil && getByMid(mid, pc.currentLocalDescription) != nil ||
+			pc.currentRemoteDescription != nil && getByMid(mid, pc.currentRemoteDescription) != nil {
+			transceivers = append(transceivers, t)
+			continue
+		}
+
+		// Transceivers created by the rolled back offer are removed, unless
+		// a track has been added to them since
+		// https://www.rfc-editor.org/rfc/rfc8829#section-4.1.10.2
+		if t.createdByRemote && t.Sender() == nil {
+			if err := t.stopSendingAndReceiving(); err != nil {
+				pc.log.Warnf("Failed to stop RTPTransceiver: %s", err)
+			}
+			continue
+		}
+
+		t.mid.Store("")
+		transceivers = append(transceivers, t)
+	}
+	pc.rtpTransceivers = transceivers
+	return nil
+}
+
 // SetLocalDescription sets the SessionDescription of the local peer
 func (pc *PeerConnection) SetLocalDescription(desc SessionDescription) error {
 	if pc.isClosed.get() {
@@ -979,6 +1079,10 @@ func (pc *PeerConnection) SetLocalDescription(desc SessionDescription) error {
 
 	haveLocalDescription := pc.currentLocalDescription != nil
 
+	if desc.Type == SDPTypeRollback {
+		return pc.rollbackDescription(&desc, stateChangeOpSetLocal)
+	}
+
 	// JSEP 5.4
 	if desc.SDP == "" {
 		switch desc.Type {
@@ -1001,9 +1105,13 @@ func (pc *PeerConnection) SetLocalDescription(desc SessionDescription) error {
 		return err
 	}
 
+	weAnswer := desc.Type == SDPTypeAnswer
+	if weAnswer {
+		pc.removeRejectedTransceivers()
+	}
+
 	currentTransceivers := append([]*RTPTransceiver{}, pc.GetTransceivers()...)
 
-	weAnswer := desc.Type == SDPTypeAnswer
 	remoteDesc := pc.RemoteDescription()
 	if weAnswer && remoteDesc != nil {
 		_ = setRTPTransceiverCurrentDirection(&desc, currentTransceivers, false)
@@ -1041,6 +1149,10 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 
 	isRenegotiation := pc.currentRemoteDescription != nil
 
+	if desc.Type == SDPTypeRollback {
+		return pc.rollbackDescription(&desc, stateChangeOpSetRemote)
+	}
+
 	if _, err := desc.Unmarshal(); err != nil {
 		return err
 	}
@@ -1052,6 +1164,11 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 		return err
 	}
 
+	weOffer := desc.Type == SDPTypeAnswer
+	if weOffer {
+		pc.removeRejectedTransceivers()
+	}
+
 	var t *RTPTransceiver
 	localTransceivers := append([]*RTPTransceiver{}, pc.GetTransceivers()...)
 	detectedPlanB := descriptionIsPlanB(pc.RemoteDescription(), pc.log)
@@ -1059,8 +1176,6 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 		detectedPlanB = descriptionPossiblyPlanB(pc.RemoteDescription())
 	}
 
-	weOffer := desc.Type == SDPTypeAnswer
-
 	if !weOffer && !detectedPlanB {
 		for _, media := range pc.RemoteDescription().parsed.MediaDescriptions {
 			midValue := getMidValue(media)
@@ -1072,6 +1187,16 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 				continue
 			}
 
+			// A rejected media section stops its transceiver, the answer rejects it as well
+			if isMediaSectionRejected(media) {
+				if t, localTransceivers = findByMid(midValue, localTransceivers); t != nil {
+					if _, err := t.stop(); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
 			kind := NewRTPCodecType(media.MediaName.Media)
 			direction := getPeerDirection(media)
 			if kind == 0 || direction == RTPTransceiverDirectionUnknown {
@@ -1082,9 +1207,11 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 			if t == nil {
 				t, localTransceivers = satisfyTypeAndDirection(kind, direction, localTransceivers)
 			} else if direction == RTPTransceiverDirectionInactive {
-				if err := t.Stop(); err != nil {
+				if err := t.stopSendingAndReceiving(); err != nil {
 					return err
 				}
+				t.setDirection(RTPTransceiverDirectionInactive)
+				t.setCurrentDirection(RTPTransceiverDirectionInactive)
 			}
 
 			switch {
@@ -1102,6 +1229,7 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 				}
 
 				t = newRTPTransceiver(receiver, nil, localDirection, kind, pc.api)
+				t.createdByRemote = true
 				pc.mu.Lock()
 				pc.addRTPTransceiver(t)
 				pc.mu.Unlock()
@@ -1262,7 +1390,7 @@ func setRTPTransceiverCurrentDirection(answer *SessionDescription, currentTransc
 			return errPeerConnRemoteDescriptionWithoutMidValue
 		}
 
-		if media.MediaName.Media == mediaSectionApplication {
+		if media.MediaName.Media == mediaSectionApplication || isMediaSectionRejected(media) {
 			continue
 		}
 
@@ -1466,10 +1594,10 @@ func (pc *PeerConnection) startRTPSenders(currentTransceivers []*RTPTransceiver)
 }
 
 // Start SCTP subsystem
-func (pc *PeerConnection) startSCTP() {
+func (pc *PeerConnection) startSCTP(maxMessageSize uint32) {
 	// Start sctp
 	if err := pc.sctpTransport.Start(SCTPCapabilities{
-		MaxMessageSize: 0,
+		MaxMessageSize: maxMessageSize,
 	}); err != nil {
 		pc.log.Warnf("Failed to start SCTP: %s", err)
 		if err = pc.sctpTransport.Stop(); err != nil {
@@ -1808,7 +1936,7 @@ func (pc *PeerConnection) AddTrack(track TrackLocal) (*RTPSender, error) {
 		// transceiver can be reused only if it's currentDirection never be sendrecv or sendonly.
 		// But that will cause sdp inflate. So we only check currentDirection's current value,
 		// that's worked for all browsers.
-		if !t.stopped && t.kind == track.Kind() && t.Sender() == nil &&
+		if !t.isStopping() && t.kind == track.Kind() && t.Sender() == nil &&
 			!(currentDirection == RTPTransceiverDirectionSendrecv || currentDirection == RTPTransceiverDirectionSendonly) {
 			sender, err := pc.api.NewRTPSender(track, pc.dtlsTransport)
 			if err == nil {
@@ -2004,6 +2132,15 @@ func (pc *PeerConnection) CreateDataChannel(label string, options *DataChannelIn
 		if options.Negotiated != nil {
 			params.Negotiated = *options.Negotiated
 		}
+
+		// https://www.w3.org/TR/webrtc-priority/#rtcdatachannel-interface-extensions
+		if options.Priority != nil {
+			params.Priority = *options.Priority
+		}
+
+		if options.Detach != nil {
+			params.Detach = *options.Detach
+		}
 	}
 
 	d, err := pc.api.newDataChannel(params, nil, pc.log)
@@ -2065,6 +2202,10 @@ func (pc *PeerConnection) Close() error {
 	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-close (step #3)
 	pc.signalingState.Set(SignalingStateClosed)
 
+	if pc.iceRestarter != nil {
+		pc.iceRestarter.handleStateChange(ICEConnectionStateClosed)
+	}
+
 	// Try closing everything and collect the errors
 	// Shutdown strategy:
 	// 1. All Conn close by closing their underlying Conn.
@@ -2078,9 +2219,8 @@ func (pc *PeerConnection) Close() error {
 	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-close (step #4)
 	pc.mu.Lock()
 	for _, t := range pc.rtpTransceivers {
-		if !t.stopped {
-			closeErrs = append(closeErrs, t.Stop())
-		}
+		_, err := t.stop()
+		closeErrs = append(closeErrs, err)
 	}
 	pc.mu.Unlock()
 
@@ -2114,10 +2254,44 @@ func (pc *PeerConnection) Close() error {
 // and fires onNegotiationNeeded;
 // caller of this method should hold `pc.mu` lock
 func (pc *PeerConnection) addRTPTransceiver(t *RTPTransceiver) {
+	t.setOnStopping(func() {
+		pc.mu.Lock()
+		defer pc.mu.Unlock()
+		pc.onNegotiationNeeded()
+	})
 	pc.rtpTransceivers = append(pc.rtpTransceivers, t)
 	pc.onNegotiationNeeded()
 }
 
+// removeRejectedTransceivers stops every transceiver whose media section was
+// rejected by the negotiation that just completed and removes it from the set
+// of transceivers, freeing the media section to be recycled. Transceivers
+// stopped before they were ever negotiated are removed as well. Media sections
+// we answer with a zero port only because they are outside the remote BUNDLE
+// group don't stop their transceiver.
+// https://www.w3.org/TR/webrtc/#set-description
+func (pc *PeerConnection) removeRejectedTransceivers() {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	transceivers := make([]*RTPTransceiver, 0, len(pc.rtpTransceivers))
+	for _, t := range pc.rtpTransceivers {
+		mid := t.Mid()
+		stopping := t.isStopping()
+		switch {
+		case mid == "" && stopping,
+			stopping && isMidRejected(mid, pc.currentLocalDescription),
+			mid != "" && isMidRejected(mid, pc.currentRemoteDescription):
+			if err := t.setStopped(); err != nil {
+				pc.log.Warnf("Failed to stop RTPTransceiver: %s", err)
+			}
+		default:
+			transceivers = append(transceivers, t)
+		}
+	}
+	pc.rtpTransceivers = transceivers
+}
+
 // CurrentLocalDescription represents the local description that was
 // successfully negotiated the last time the PeerConnection transitioned
 // into the stable state plus any local candidates that have been generated
@@ -2307,7 +2481,7 @@ func (pc *PeerConnection) startRTP(isRenegotiation bool, remoteDesc *SessionDesc
 
 	pc.startRTPReceivers(remoteDesc, currentTransceivers)
 	if haveApplicationMediaSection(remoteDesc.parsed) {
-		pc.startSCTP()
+		pc.startSCTP(getMaxMessageSize(haveDataChannel(remoteDesc)))
 	}
 }
 
@@ -2342,6 +2516,9 @@ func (pc *PeerConnection) generateUnmatchedSDP(transceivers []*RTPTransceiver, u
 		audio := make([]*RTPTransceiver, 0)
 
 		for _, t := range transceivers {
+			if t.isStopping() {
+				continue
+			}
 			if t.kind == RTPCodecTypeVideo {
 				video = append(video, t)
 			} else if t.kind == RTPCodecTypeAudio {
@@ -2364,6 +2541,9 @@ func (pc *PeerConnection) generateUnmatchedSDP(transceivers []*RTPTransceiver, u
 		}
 	} else {
 		for _, t := range transceivers {
+			if t.isStopping() {
+				continue
+			}
 			if sender := t.Sender(); sender != nil {
 				sender.setNegotiated()
 			}
@@ -2380,7 +2560,7 @@ func (pc *PeerConnection) generateUnmatchedSDP(transceivers []*RTPTransceiver, u
 		return nil, err
 	}
 
-	return populateSDP(d, isPlanB, dtlsFingerprints, pc.api.settingEngine.sdpMediaLevelFingerprints, pc.api.settingEngine.candidates.ICELite, true, pc.api.mediaEngine, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), candidates, iceParams, mediaSections, pc.ICEGatheringState(), nil)
+	return populateSDP(d, isPlanB, dtlsFingerprints, pc.api.settingEngine.sdpMediaLevelFingerprints, pc.api.settingEngine.candidates.ICELite, true, pc.api.mediaEngine, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), candidates, iceParams, mediaSections, pc.ICEGatheringState(), pc.sctpTransport.localMaxMessageSize(), nil)
 }
 
 // generateMatchedSDP generates a SDP and takes the remote state into account
@@ -2417,6 +2597,9 @@ func (pc *PeerConnection) generateMatchedSDP(transceivers []*RTPTransceiver, use
 	}
 
 	mediaSections := []mediaSection{}
+	// indexes of media sections which no longer have a transceiver and may be
+	// recycled by an unmatched one, see JSEP 5.2.2
+	recyclableMediaSections := []int{}
 	alreadyHaveApplicationMediaSection := false
 	for _, media := range remoteDescription.parsed.MediaDescriptions {
 		midValue := getMidValue(media)
@@ -2469,7 +2652,19 @@ func (pc *PeerConnection) generateMatchedSDP(transceivers []*RTPTransceiver, use
 			}
 			t, localTransceivers = findByMid(midValue, localTransceivers)
 			if t == nil {
-				return nil, fmt.Errorf("%w: %q", errPeerConnTranscieverMidNil, midValue)
+				// Only a transceiver stopped and removed after its media section
+				// was rejected may be missing
+				if !isMediaSectionRejected(media) &&
+					!isMidRejected(midValue, pc.currentLocalDescription) &&
+					!isMidRejected(midValue, pc.currentRemoteDescription) {
+					return nil, fmt.Errorf("%w: %q", errPeerConnTranscieverMidNil, midValue)
+				}
+				t = &RTPTransceiver{kind: kind, api: pc.api, stopped: true}
+				recyclableMediaSections = append(recyclableMediaSections, len(mediaSections))
+			}
+			if t.isStopping() {
+				mediaSections = append(mediaSections, mediaSection{id: midValue, transceivers: []*RTPTransceiver{t}})
+				continue
 			}
 			if sender := t.Sender(); sender != nil {
 				sender.setNegotiated()
@@ -2484,10 +2679,21 @@ func (pc *PeerConnection) generateMatchedSDP(transceivers []*RTPTransceiver, use
 	if includeUnmatched {
 		if !detectedPlanB {
 			for _, t := range localTransceivers {
+				if t.isStopping() {
+					continue
+				}
 				if sender := t.Sender(); sender != nil {
 					sender.setNegotiated()
 				}
-				mediaSections = append(mediaSections, mediaSection{id: t.Mid(), transceivers: []*RTPTransceiver{t}})
+
+				// Recycle the first rejected media section, keeping the new mid
+				section := mediaSection{id: t.Mid(), transceivers: []*RTPTransceiver{t}}
+				if len(recyclableMediaSections) != 0 {
+					mediaSections[recyclableMediaSections[0]] = section
+					recyclableMediaSections = recyclableMediaSections[1:]
+				} else {
+					mediaSections = append(mediaSections, section)
+				}
 			}
 		}
 
@@ -2495,7 +2701,16 @@ func (pc *PeerConnection) generateMatchedSDP(transceivers []*RTPTransceiver, use
 			if detectedPlanB {
 				mediaSections = append(mediaSections, mediaSection{id: "data", data: true})
 			} else {
-				mediaSections = append(mediaSections, mediaSection{id: strconv.Itoa(len(mediaSections)), data: true})
+				dataMid := strconv.Itoa(len(mediaSections))
+				// Recycled media sections carry new mids, so the index may already be taken
+				for _, m := range mediaSections {
+					if m.id == dataMid {
+						pc.greaterMid++
+						dataMid = strconv.Itoa(pc.greaterMid)
+						break
+					}
+				}
+				mediaSections = append(mediaSections, mediaSection{id: dataMid, data: true})
 			}
 		}
 	} else if remoteDescription != nil {
@@ -2513,7 +2728,7 @@ func (pc *PeerConnection) generateMatchedSDP(transceivers []*RTPTransceiver, use
 		return nil, err
 	}
 
-	return populateSDP(d, detectedPlanB, dtlsFingerprints, pc.api.settingEngine.sdpMediaLevelFingerprints, pc.api.settingEngine.candidates.ICELite, isExtmapAllowMixed, pc.api.mediaEngine, connectionRole, candidates, iceParams, mediaSections, pc.ICEGatheringState(), bundleGroup)
+	return populateSDP(d, detectedPlanB, dtlsFingerprints, pc.api.settingEngine.sdpMediaLevelFingerprints, pc.api.settingEngine.candidates.ICELite, isExtmapAllowMixed, pc.api.mediaEngine, connectionRole, candidates, iceParams, mediaSections, pc.ICEGatheringState(), pc.sctpTransport.localMaxMessageSize(), bundleGroup)
 }
 
 func (pc *PeerConnection) setGatherCompleteHandler(handler func()) {
@@ -895,6 +895,55 @@ func TestICERestart(t *testing.T) {
 	closePairNow(t, offerPC, answerPC)
 }
 
+func TestPeerConnection_RestartICE(t *testing.T) {
+	extractUfrag := func(sdp string) string {
+		sc := bufio.NewScanner(strings.NewReader(sdp))
+		for sc.Scan() {
+			if strings.HasPrefix(sc.Text(), "a=ice-ufrag:") {
+				return sc.Text()
+			}
+		}
+
+		return ""
+	}
+
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	negotiationNeeded := make(chan struct{}, 1)
+	offerPC.OnNegotiationNeeded(func() {
+		if offerPC.iceRestartRequested.get() {
+			negotiationNeeded <- struct{}{}
+		}
+	})
+
+	_, err = offerPC.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, signalPair(offerPC, answerPC))
+
+	firstUfrag := extractUfrag(offerPC.LocalDescription().SDP)
+	assert.NotEqual(t, "", firstUfrag)
+
+	assert.NoError(t, offerPC.RestartICE())
+	<-negotiationNeeded
+
+	offer, err := offerPC.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, firstUfrag, extractUfrag(offer.SDP))
+
+	// The request is consumed by the offer
+	assert.False(t, offerPC.iceRestartRequested.get())
+
+	closePairNow(t, offerPC, answerPC)
+	assert.Error(t, offerPC.RestartICE())
+}
+
 // Assert error handling when an Agent is restart
 func TestICERestart_Error_Handling(t *testing.T) {
 	iceStates := make(chan ICEConnectionState, 100)
@@ -1606,3 +1655,35 @@ func TestPeerConnectionState(t *testing.T) {
 	assert.NoError(t, pc.Close())
 	assert.Equal(t, PeerConnectionStateClosed, pc.ConnectionState())
 }
+
+func TestPeerConnection_AddLocalICECandidate(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pc, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = pc.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+
+	offer, err := pc.CreateOffer(nil)
+	assert.NoError(t, err)
+
+	gatherComplete := GatheringCompletePromise(pc)
+	assert.NoError(t, pc.SetLocalDescription(offer))
+	<-gatherComplete
+
+	assert.NoError(t, pc.AddLocalICECandidate(ICECandidate{
+		Typ:      ICECandidateTypeHost,
+		Protocol: ICEProtocolUDP,
+		Address:  "198.51.100.1",
+		Port:     4000,
+	}))
+	assert.Contains(t, pc.LocalDescription().SDP, "198.51.100.1 4000 typ host")
+
+	assert.NoError(t, pc.Close())
+	assert.Error(t, pc.AddLocalICECandidate(ICECandidate{}))
+}
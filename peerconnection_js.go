@@ -309,6 +309,17 @@ func (pc *PeerConnection) RemoteDescription() *SessionDescription {
 	return valueToSessionDescription(pc.underlying.Get("remoteDescription"))
 }
 
+// RestartICE tells the PeerConnection that ICE should be restarted
+func (pc *PeerConnection) RestartICE() (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = recoveryToError(e)
+		}
+	}()
+	pc.underlying.Call("restartIce")
+	return nil
+}
+
 // AddICECandidate accepts an ICE candidate string and adds it
 // to the existing set of candidates
 func (pc *PeerConnection) AddICECandidate(candidate ICECandidateInit) (err error) {
@@ -376,6 +387,7 @@ func (pc *PeerConnection) CreateDataChannel(label string, options *DataChannelIn
 	return &DataChannel{
 		underlying: channel,
 		api:        pc.api,
+		detach:     options != nil && options.Detach != nil && *options.Detach,
 	}, nil
 }
 
@@ -754,6 +766,10 @@ func dataChannelInitToValue(options *DataChannelInit) js.Value {
 	}
 
 	maxPacketLifeTime := uint16PointerToValue(options.MaxPacketLifeTime)
+	priority := js.Undefined()
+	if options.Priority != nil {
+		priority = js.ValueOf(options.Priority.String())
+	}
 	return js.ValueOf(map[string]interface{}{
 		"ordered":           boolPointerToValue(options.Ordered),
 		"maxPacketLifeTime": maxPacketLifeTime,
@@ -764,6 +780,7 @@ func dataChannelInitToValue(options *DataChannelInit) js.Value {
 		"protocol":          stringPointerToValue(options.Protocol),
 		"negotiated":        boolPointerToValue(options.Negotiated),
 		"id":                uint16PointerToValue(options.ID),
+		"priority":          priority,
 	})
 }
 
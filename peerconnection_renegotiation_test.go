@@ -1007,6 +1007,100 @@ func TestPeerConnection_Renegotiation_DisableTrack(t *testing.T) {
 	closePairNow(t, pcOffer, pcAnswer)
 }
 
+// TestPeerConnection_Renegotiation_StopTransceiver asserts that a stopped transceiver
+// rejects its media section, is removed once negotiated, and that the media section is
+// recycled by the next transceiver instead of growing the SDP
+func TestPeerConnection_Renegotiation_StopTransceiver(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+
+	transceiver, err := pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	assert.NoError(t, transceiver.Stop())
+	assert.Equal(t, RTPTransceiverDirectionStopped, transceiver.Direction())
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(offer.SDP, "m=video 0 "))
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+
+	answer, err := pcAnswer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(answer.SDP, "m=video 0 "))
+	assert.NoError(t, pcAnswer.SetLocalDescription(answer))
+	assert.NoError(t, pcOffer.SetRemoteDescription(answer))
+
+	assert.Equal(t, RTPTransceiverDirectionStopped, transceiver.getCurrentDirection())
+	assert.Equal(t, 1, len(pcOffer.GetTransceivers()))
+	assert.Equal(t, 1, len(pcAnswer.GetTransceivers()))
+
+	// A new transceiver takes over the rejected media section with a new mid
+	recycled, err := pcOffer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+
+	offer, err = pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(offer.parsed.MediaDescriptions))
+	assert.Equal(t, "audio", offer.parsed.MediaDescriptions[1].MediaName.Media)
+	assert.Equal(t, recycled.Mid(), getMidValue(offer.parsed.MediaDescriptions[1]))
+	assert.NotEqual(t, transceiver.Mid(), recycled.Mid())
+
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+	answer, err = pcAnswer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, strings.Count(answer.SDP, "m=video 0 "))
+	assert.NoError(t, pcAnswer.SetLocalDescription(answer))
+	assert.NoError(t, pcOffer.SetRemoteDescription(answer))
+
+	assert.Equal(t, 2, len(pcOffer.GetTransceivers()))
+	assert.Equal(t, 2, len(pcAnswer.GetTransceivers()))
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+// TestPeerConnection_Renegotiation_MissingTransceiver asserts that a media section
+// without a transceiver is only tolerated once it has been rejected
+func TestPeerConnection_Renegotiation_MissingTransceiver(t *testing.T) {
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	// Losing track of a negotiated transceiver is a bookkeeping error
+	pcOffer.mu.Lock()
+	transceivers := pcOffer.rtpTransceivers
+	pcOffer.rtpTransceivers = nil
+	pcOffer.mu.Unlock()
+
+	_, err = pcOffer.CreateOffer(nil)
+	assert.ErrorIs(t, err, errPeerConnTranscieverMidNil)
+
+	pcOffer.mu.Lock()
+	pcOffer.rtpTransceivers = transceivers
+	pcOffer.mu.Unlock()
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
 func TestPeerConnection_Renegotiation_Simulcast(t *testing.T) {
 	lim := test.TimeOut(time.Second * 30)
 	defer lim.Stop()
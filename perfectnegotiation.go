@@ -0,0 +1,216 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+)
+
+// NegotiationSignaler is implemented by the application to deliver local
+// descriptions and candidates to the remote peer. The remote peer passes them
+// to its own PerfectNegotiator with HandleDescription and HandleICECandidate.
+type NegotiationSignaler interface {
+	SendDescription(SessionDescription) error
+	SendICECandidate(ICECandidateInit) error
+}
+
+// PerfectNegotiator is a Pion specific helper that implements the perfect
+// negotiation pattern on top of a PeerConnection. Both peers may renegotiate
+// at any time, offer collisions are resolved by the polite peer rolling back
+// its own offer while the impolite peer ignores the incoming one.
+// https://www.w3.org/TR/webrtc/#perfect-negotiation-example
+//
+// The PerfectNegotiator takes over the OnNegotiationNeeded and OnICECandidate
+// handlers of the PeerConnection.
+type PerfectNegotiator struct {
+	pc       *PeerConnection
+	polite   bool
+	signaler NegotiationSignaler
+
+	// negotiationLock serializes the offer/answer steps so a remote offer is
+	// never applied while a local offer is being created
+	negotiationLock sync.Mutex
+
+	mu                sync.Mutex
+	makingOffer       bool
+	ignoreOffer       bool
+	pendingCandidates []ICECandidateInit
+
+	onErrorHandler func(error)
+}
+
+// NewPerfectNegotiator creates a PerfectNegotiator for pc. Exactly one of the
+// two peers must be polite.
+func NewPerfectNegotiator(pc *PeerConnection, polite bool, signaler NegotiationSignaler) *PerfectNegotiator {
+	n := &PerfectNegotiator{
+		pc:       pc,
+		polite:   polite,
+		signaler: signaler,
+	}
+
+	pc.OnNegotiationNeeded(n.negotiate)
+	pc.OnICECandidate(func(c *ICECandidate) {
+		if c == nil {
+			return
+		}
+
+		if err := signaler.SendICECandidate(c.ToJSON()); err != nil {
+			n.onError(err)
+		}
+	})
+
+	return n
+}
+
+// OnError sets an event handler which is invoked when negotiating a local
+// change fails. Errors from remote descriptions and candidates are returned by
+// HandleDescription and HandleICECandidate instead.
+func (n *PerfectNegotiator) OnError(f func(error)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.onErrorHandler = f
+}
+
+func (n *PerfectNegotiator) onError(err error) {
+	n.mu.Lock()
+	handler := n.onErrorHandler
+	n.mu.Unlock()
+
+	n.pc.log.Warnf("Perfect negotiation failed: %s", err)
+	if handler != nil {
+		handler(err)
+	}
+}
+
+func (n *PerfectNegotiator) negotiate() {
+	offer, err := func() (*SessionDescription, error) {
+		n.negotiationLock.Lock()
+		defer n.negotiationLock.Unlock()
+
+		// A remote offer was applied since negotiation was requested, once it
+		// is answered negotiation-needed fires again if still required
+		if n.pc.SignalingState() != SignalingStateStable {
+			return nil, nil //nolint:nilnil
+		}
+
+		n.setMakingOffer(true)
+		offer, err := n.pc.CreateOffer(nil)
+		if err != nil {
+			return nil, err
+		}
+		if err = n.pc.SetLocalDescription(offer); err != nil {
+			return nil, err
+		}
+
+		return n.pc.LocalDescription(), nil
+	}()
+	defer n.setMakingOffer(false)
+
+	if err != nil {
+		n.onError(err)
+		return
+	} else if offer == nil {
+		return
+	}
+
+	if err := n.signaler.SendDescription(*offer); err != nil {
+		n.onError(err)
+	}
+}
+
+func (n *PerfectNegotiator) setMakingOffer(makingOffer bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.makingOffer = makingOffer
+}
+
+// HandleDescription applies a description received from the remote peer. An
+// offer colliding with a local one is ignored by the impolite peer, the
+// polite peer rolls back its own offer and answers.
+func (n *PerfectNegotiator) HandleDescription(desc SessionDescription) error {
+	answer, err := func() (*SessionDescription, error) {
+		n.negotiationLock.Lock()
+		defer n.negotiationLock.Unlock()
+
+		n.mu.Lock()
+		offerCollision := desc.Type == SDPTypeOffer &&
+			(n.makingOffer || n.pc.SignalingState() != SignalingStateStable)
+		n.ignoreOffer = !n.polite && offerCollision
+		ignoreOffer := n.ignoreOffer
+		n.mu.Unlock()
+
+		if ignoreOffer {
+			return nil, nil //nolint:nilnil
+		}
+
+		// Implicit rollback of our pending offer
+		if offerCollision {
+			if err := n.pc.SetLocalDescription(SessionDescription{Type: SDPTypeRollback}); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := n.pc.SetRemoteDescription(desc); err != nil {
+			return nil, err
+		}
+		if err := n.addPendingCandidates(); err != nil {
+			return nil, err
+		}
+
+		if desc.Type != SDPTypeOffer {
+			return nil, nil //nolint:nilnil
+		}
+
+		answer, err := n.pc.CreateAnswer(nil)
+		if err != nil {
+			return nil, err
+		}
+		if err = n.pc.SetLocalDescription(answer); err != nil {
+			return nil, err
+		}
+
+		return n.pc.LocalDescription(), nil
+	}()
+	if err != nil || answer == nil {
+		return err
+	}
+
+	return n.signaler.SendDescription(*answer)
+}
+
+// HandleICECandidate adds a candidate received from the remote peer. Candidates
+// arriving before the remote description are held until it has been applied,
+// candidates belonging to an ignored offer are dropped.
+func (n *PerfectNegotiator) HandleICECandidate(candidate ICECandidateInit) error {
+	n.mu.Lock()
+	if n.pc.RemoteDescription() == nil {
+		n.pendingCandidates = append(n.pendingCandidates, candidate)
+		n.mu.Unlock()
+		return nil
+	}
+	ignoreOffer := n.ignoreOffer
+	n.mu.Unlock()
+
+	if err := n.pc.AddICECandidate(candidate); err != nil && !ignoreOffer {
+		return err
+	}
+	return nil
+}
+
+func (n *PerfectNegotiator) addPendingCandidates() error {
+	n.mu.Lock()
+	candidates := n.pendingCandidates
+	n.pendingCandidates = nil
+	n.mu.Unlock()
+
+	for _, candidate := range candidates {
+		if err := n.pc.AddICECandidate(candidate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
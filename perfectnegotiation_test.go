@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// channelSignaler delivers descriptions and candidates to the remote
+// PerfectNegotiator asynchronously and in order, like a real signaling channel
+type channelSignaler struct {
+	messages chan func(*PerfectNegotiator) error
+}
+
+func newChannelSignaler() *channelSignaler {
+	return &channelSignaler{messages: make(chan func(*PerfectNegotiator) error, 64)}
+}
+
+func (s *channelSignaler) SendDescription(desc SessionDescription) error {
+	s.messages <- func(n *PerfectNegotiator) error { return n.HandleDescription(desc) }
+	return nil
+}
+
+func (s *channelSignaler) SendICECandidate(candidate ICECandidateInit) error {
+	s.messages <- func(n *PerfectNegotiator) error { return n.HandleICECandidate(candidate) }
+	return nil
+}
+
+func (s *channelSignaler) deliver(t *testing.T, remote *PerfectNegotiator, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for message := range s.messages {
+		if err := message(remote); !remote.pc.isClosed.get() {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestPerfectNegotiator_Glare(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcPolite, pcImpolite, err := newPair()
+	assert.NoError(t, err)
+
+	politeSignaler, impoliteSignaler := newChannelSignaler(), newChannelSignaler()
+	polite := NewPerfectNegotiator(pcPolite, true, politeSignaler)
+	impolite := NewPerfectNegotiator(pcImpolite, false, impoliteSignaler)
+	polite.OnError(func(err error) { assert.NoError(t, err) })
+	impolite.OnError(func(err error) { assert.NoError(t, err) })
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go politeSignaler.deliver(t, impolite, &wg)
+	go impoliteSignaler.deliver(t, polite, &wg)
+
+	connected := make(chan struct{})
+	var connectedOnce sync.Once
+	pcPolite.OnConnectionStateChange(func(s PeerConnectionState) {
+		if s == PeerConnectionStateConnected {
+			connectedOnce.Do(func() { close(connected) })
+		}
+	})
+
+	// Both peers add a transceiver at the same time, so both offer
+	_, err = pcPolite.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+	_, err = pcImpolite.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+
+	<-connected
+
+	negotiated := func(pc *PeerConnection) bool {
+		if pc.SignalingState() != SignalingStateStable {
+			return false
+		}
+		desc := pc.CurrentLocalDescription()
+		return desc != nil && len(desc.parsed.MediaDescriptions) == 2
+	}
+	for !negotiated(pcPolite) || !negotiated(pcImpolite) {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	for _, pc := range []*PeerConnection{pcPolite, pcImpolite} {
+		transceivers := pc.GetTransceivers()
+		assert.Equal(t, 2, len(transceivers))
+		for _, transceiver := range transceivers {
+			assert.NotEqual(t, "", transceiver.Mid())
+		}
+	}
+
+	closePairNow(t, pcPolite, pcImpolite)
+	close(politeSignaler.messages)
+	close(impoliteSignaler.messages)
+	wg.Wait()
+}
+
+func TestPeerConnection_RollbackRemoteOffer(t *testing.T) {
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+	assert.Len(t, pcAnswer.GetTransceivers(), 2)
+
+	// A transceiver the application added a track to survives the rollback
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+	_, err = pcAnswer.AddTrack(track)
+	assert.NoError(t, err)
+
+	assert.NoError(t, pcAnswer.SetRemoteDescription(SessionDescription{Type: SDPTypeRollback}))
+	assert.Equal(t, SignalingStateStable, pcAnswer.SignalingState())
+
+	transceivers := pcAnswer.GetTransceivers()
+	assert.Len(t, transceivers, 1)
+	assert.Equal(t, RTPCodecTypeVideo, transceivers[0].Kind())
+	assert.Equal(t, "", transceivers[0].Mid())
+
+	// The next offer only carries the remaining transceiver
+	offer, err = pcAnswer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Len(t, offer.parsed.MediaDescriptions, 1)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
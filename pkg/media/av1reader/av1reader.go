@@ -0,0 +1,338 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package av1reader implements a reader for raw AV1 streams, in the
+// low overhead bitstream format or the Annex B length delimited format
+package av1reader
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+// Format is the format of an AV1 stream
+type Format int
+
+const (
+	// FormatLowOverhead is the low overhead bitstream format of section 5
+	// of the AV1 specification, a sequence of OBUs with size fields. It is
+	// the format of .obu files produced by most encoders.
+	FormatLowOverhead Format = iota
+
+	// FormatAnnexB is the length delimited bitstream format of Annex B of
+	// the AV1 specification
+	FormatAnnexB
+)
+
+// OBU types, see section 6.2.2 of the AV1 specification
+const (
+	obuTypeSequenceHeader    = 1
+	obuTypeTemporalDelimiter = 2
+	obuTypeFrameHeader       = 3
+	obuTypeFrame             = 6
+	obuTypeTileList          = 8
+	obuTypePadding           = 15
+
+	obuTypeShift          = 3
+	obuTypeMask           = 0x0F
+	obuExtensionFlagMask  = 0x04
+	obuHasSizeFieldMask   = 0x02
+	leb128MaxBytes        = 8
+	leb128ContinuationBit = 0x80
+
+	// maxUnitSize bounds the allocations for sizes read from the stream
+	maxUnitSize = 1 << 26
+)
+
+var (
+	errNilStream       = errors.New("stream is nil")
+	errUnknownFormat   = errors.New("unknown AV1 stream format")
+	errMissingOBUSize  = errors.New("OBU has no size field")
+	errInvalidLEB128   = errors.New("invalid leb128 value")
+	errIncompleteOBU   = errors.New("incomplete OBU")
+	errInvalidUnitSize = errors.New("unit size exceeds the enclosing unit")
+)
+
+// TemporalUnit is the group of OBUs that share a presentation time
+type TemporalUnit struct {
+	// OBUs of the TemporalUnit, without their size field
+	OBUs [][]byte
+
+	// IsKeyFrame is set if the TemporalUnit holds a shown key frame
+	IsKeyFrame bool
+}
+
+// AV1Reader reads TemporalUnits from an AV1 stream
+type AV1Reader struct {
+	stream *bufio.Reader
+	format Format
+
+	// pendingOBU is the temporal delimiter read ahead in the low overhead
+	// format
+	pendingOBU []byte
+
+	sequenceHeader *sequenceHeader
+}
+
+// NewWith returns a new AV1Reader that reads a stream in format
+func NewWith(in io.Reader, format Format) (*AV1Reader, error) {
+	if in == nil {
+		return nil, errNilStream
+	}
+	if format != FormatLowOverhead && format != FormatAnnexB {
+		return nil, errUnknownFormat
+	}
+
+	return &AV1Reader{
+		stream: bufio.NewReader(in),
+		format: format,
+	}, nil
+}
+
+// Reset makes the AV1Reader read from in, keeping the sequence header read
+// so far. Containers that hold a TemporalUnit per frame, like IVF, use it to
+// read the frames of a stream with one AV1Reader.
+func (r *AV1Reader) Reset(in io.Reader) {
+	r.stream.Reset(in)
+	r.pendingOBU = nil
+}
+
+// NextTemporalUnit reads from stream and returns the next TemporalUnit.
+// Returns io.EOF when no more TemporalUnits are available.
+func (r *AV1Reader) NextTemporalUnit() (*TemporalUnit, error) {
+	var (
+		obus [][]byte
+		err  error
+	)
+	if r.format == FormatAnnexB {
+		obus, err = r.readAnnexBTemporalUnit()
+	} else {
+		obus, err = r.readLowOverheadTemporalUnit()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	temporalUnit := &TemporalUnit{OBUs: obus}
+	for _, obu := range obus {
+		switch obuType(obu) {
+		case obuTypeSequenceHeader:
+			if header, err := parseSequenceHeader(obuPayload(obu)); err == nil {
+				r.sequenceHeader = header
+			}
+		case obuTypeFrameHeader, obuTypeFrame:
+			if r.sequenceHeader != nil && r.sequenceHeader.isShownKeyFrame(obuPayload(obu)) {
+				temporalUnit.IsKeyFrame = true
+			}
+		}
+	}
+
+	return temporalUnit, nil
+}
+
+// FrameDuration returns the duration of a frame from the timing info of
+// the sequence header, or zero if it is unknown
+func (r *AV1Reader) FrameDuration() time.Duration {
+	if r.sequenceHeader == nil {
+		return 0
+	}
+	return r.sequenceHeader.frameDuration()
+}
+
+// Samples returns the OBUs of the TemporalUnit as Samples for a
+// TrackLocalStaticSample, one per OBU. Temporal delimiters, tile lists and
+// padding are not sent over RTP and are skipped. All Samples but the last
+// have no duration, so they share the same RTP timestamp.
+func (t *TemporalUnit) Samples(duration time.Duration) []media.Sample {
+	samples := make([]media.Sample, 0, len(t.OBUs))
+	for _, obu := range t.OBUs {
+		switch obuType(obu) {
+		case obuTypeTemporalDelimiter, obuTypeTileList, obuTypePadding:
+			continue
+		}
+
+		samples = append(samples, media.Sample{Data: obu})
+	}
+
+	if len(samples) != 0 {
+		samples[len(samples)-1].Duration = duration
+	}
+	return samples
+}
+
+func (r *AV1Reader) readLowOverheadTemporalUnit() ([][]byte, error) {
+	var obus [][]byte
+	if r.pendingOBU != nil {
+		obus = append(obus, r.pendingOBU)
+		r.pendingOBU = nil
+	}
+
+	for {
+		obu, err := readOBU(r.stream, true)
+		switch {
+		case errors.Is(err, io.EOF) && len(obus) != 0:
+			return obus, nil
+		case err != nil:
+			return nil, err
+		}
+
+		// A temporal delimiter starts the next TemporalUnit
+		if obuType(obu) == obuTypeTemporalDelimiter && len(obus) != 0 {
+			r.pendingOBU = obu
+			return obus, nil
+		}
+		obus = append(obus, obu)
+	}
+}
+
+func (r *AV1Reader) readAnnexBTemporalUnit() ([][]byte, error) {
+	temporalUnit, err := readSizedUnit(r.stream)
+	if err != nil {
+		return nil, err
+	}
+
+	var obus [][]byte
+	temporalUnitReader := bytes.NewReader(temporalUnit)
+	for temporalUnitReader.Len() != 0 {
+		frameUnit, err := readSizedUnit(temporalUnitReader)
+		if err != nil {
+			return nil, err
+		}
+
+		frameUnitReader := bytes.NewReader(frameUnit)
+		for frameUnitReader.Len() != 0 {
+			obuData, err := readSizedUnit(frameUnitReader)
+			if err != nil {
+				return nil, err
+			}
+			if len(obuData) == 0 {
+				return nil, errIncompleteOBU
+			}
+
+			obu, err := readOBU(bytes.NewReader(obuData), false)
+			if err != nil {
+				return nil, err
+			}
+			obus = append(obus, obu)
+		}
+	}
+
+	return obus, nil
+}
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// readSizedUnit reads a leb128 size followed by as many bytes
+func readSizedUnit(in byteReader) ([]byte, error) {
+	size, err := readLEB128(in)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fitsIn(in, size) {
+		return nil, errInvalidUnitSize
+	}
+
+	unit := make([]byte, size)
+	if _, err := io.ReadFull(in, unit); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return unit, nil
+}
+
+// readOBU reads an OBU and returns it without its size field. If
+// requireSize is not set, an OBU without size field spans the rest of in.
+func readOBU(in byteReader, requireSize bool) ([]byte, error) {
+	header, err := in.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	obu := []byte{header &^ obuHasSizeFieldMask}
+	if header&obuExtensionFlagMask != 0 {
+		extension, err := in.ReadByte()
+		if err != nil {
+			return nil, errIncompleteOBU
+		}
+		obu = append(obu, extension)
+	}
+
+	if header&obuHasSizeFieldMask == 0 {
+		if requireSize {
+			return nil, errMissingOBUSize
+		}
+
+		payload, err := io.ReadAll(in)
+		if err != nil {
+			return nil, err
+		}
+		return append(obu, payload...), nil
+	}
+
+	size, err := readLEB128(in)
+	if err != nil {
+		return nil, err
+	}
+	if !fitsIn(in, size) {
+		return nil, errIncompleteOBU
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(in, payload); err != nil {
+		return nil, errIncompleteOBU
+	}
+	return append(obu, payload...), nil
+}
+
+// fitsIn returns whether size bytes can be read from in
+func fitsIn(in byteReader, size uint64) bool {
+	if sized, ok := in.(interface{ Len() int }); ok {
+		return size <= uint64(sized.Len())
+	}
+	return size <= maxUnitSize
+}
+
+func readLEB128(in io.ByteReader) (uint64, error) {
+	var value uint64
+	for i := 0; i < leb128MaxBytes; i++ {
+		b, err := in.ReadByte()
+		switch {
+		case errors.Is(err, io.EOF) && i != 0:
+			return 0, io.ErrUnexpectedEOF
+		case err != nil:
+			return 0, err
+		}
+
+		value |= uint64(b&^leb128ContinuationBit) << (7 * i)
+		if b&leb128ContinuationBit == 0 {
+			return value, nil
+		}
+	}
+	return 0, errInvalidLEB128
+}
+
+func obuType(obu []byte) byte {
+	return (obu[0] >> obuTypeShift) & obuTypeMask
+}
+
+// obuPayload returns the OBU without its header
+func obuPayload(obu []byte) []byte {
+	if obu[0]&obuExtensionFlagMask != 0 {
+		if len(obu) < 2 {
+			return nil
+		}
+		return obu[2:]
+	}
+	return obu[1:]
+}
@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package av1reader
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	// Profile 0 at 30 frames per second
+	testSequenceHeader = []byte{0x04, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x7B}
+	testKeyFrame       = []byte{0x10, 0xAA}
+	testInterFrame     = []byte{0x30, 0xBB}
+)
+
+// obu returns an OBU of obuType, with a size field if sized is set
+func obu(obuType byte, sized bool, payload []byte) []byte {
+	header := obuType << obuTypeShift
+	if !sized {
+		return append([]byte{header}, payload...)
+	}
+	return append([]byte{header | obuHasSizeFieldMask, byte(len(payload))}, payload...)
+}
+
+// sized prefixes data with its leb128 size, as done in the Annex B format
+func sized(data ...[]byte) []byte {
+	unit := bytes.Join(data, nil)
+	return append([]byte{byte(len(unit))}, unit...)
+}
+
+func TestAV1Reader_LowOverhead(t *testing.T) {
+	stream := bytes.Join([][]byte{
+		obu(obuTypeTemporalDelimiter, true, nil),
+		obu(obuTypeSequenceHeader, true, testSequenceHeader),
+		obu(obuTypeFrame, true, testKeyFrame),
+		obu(obuTypeTemporalDelimiter, true, nil),
+		obu(obuTypeFrame, true, testInterFrame),
+		obu(obuTypePadding, true, []byte{0x00}),
+	}, nil)
+
+	reader, err := NewWith(bytes.NewReader(stream), FormatLowOverhead)
+	assert.NoError(t, err)
+
+	temporalUnit, err := reader.NextTemporalUnit()
+	assert.NoError(t, err)
+	assert.True(t, temporalUnit.IsKeyFrame)
+	assert.Equal(t, [][]byte{
+		obu(obuTypeTemporalDelimiter, false, nil),
+		obu(obuTypeSequenceHeader, false, testSequenceHeader),
+		obu(obuTypeFrame, false, testKeyFrame),
+	}, temporalUnit.OBUs)
+	assert.Equal(t, time.Second/30, reader.FrameDuration())
+	assert.Equal(t, []media.Sample{
+		{Data: obu(obuTypeSequenceHeader, false, testSequenceHeader)},
+		{Data: obu(obuTypeFrame, false, testKeyFrame), Duration: time.Second / 30},
+	}, temporalUnit.Samples(reader.FrameDuration()))
+
+	temporalUnit, err = reader.NextTemporalUnit()
+	assert.NoError(t, err)
+	assert.False(t, temporalUnit.IsKeyFrame)
+	assert.Len(t, temporalUnit.OBUs, 3)
+	assert.Equal(t, []media.Sample{
+		{Data: obu(obuTypeFrame, false, testInterFrame), Duration: time.Second},
+	}, temporalUnit.Samples(time.Second))
+
+	_, err = reader.NextTemporalUnit()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestAV1Reader_AnnexB(t *testing.T) {
+	stream := bytes.Join([][]byte{
+		sized(
+			sized(
+				sized(obu(obuTypeTemporalDelimiter, false, nil)),
+				sized(obu(obuTypeSequenceHeader, false, testSequenceHeader)),
+				sized(obu(obuTypeFrame, true, testKeyFrame)),
+			),
+		),
+		sized(
+			sized(
+				sized(obu(obuTypeTemporalDelimiter, false, nil)),
+				sized(obu(obuTypeFrame, false, testInterFrame)),
+			),
+		),
+	}, nil)
+
+	reader, err := NewWith(bytes.NewReader(stream), FormatAnnexB)
+	assert.NoError(t, err)
+
+	temporalUnit, err := reader.NextTemporalUnit()
+	assert.NoError(t, err)
+	assert.True(t, temporalUnit.IsKeyFrame)
+	assert.Equal(t, [][]byte{
+		obu(obuTypeTemporalDelimiter, false, nil),
+		obu(obuTypeSequenceHeader, false, testSequenceHeader),
+		obu(obuTypeFrame, false, testKeyFrame),
+	}, temporalUnit.OBUs)
+
+	temporalUnit, err = reader.NextTemporalUnit()
+	assert.NoError(t, err)
+	assert.False(t, temporalUnit.IsKeyFrame)
+	assert.Len(t, temporalUnit.OBUs, 2)
+
+	_, err = reader.NextTemporalUnit()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestAV1Reader_Reset(t *testing.T) {
+	reader, err := NewWith(bytes.NewReader(bytes.Join([][]byte{
+		obu(obuTypeSequenceHeader, true, testSequenceHeader),
+		obu(obuTypeFrame, true, testKeyFrame),
+	}, nil)), FormatLowOverhead)
+	assert.NoError(t, err)
+
+	temporalUnit, err := reader.NextTemporalUnit()
+	assert.NoError(t, err)
+	assert.True(t, temporalUnit.IsKeyFrame)
+
+	// The sequence header is kept to detect key frames of the next stream
+	reader.Reset(bytes.NewReader(obu(obuTypeFrame, true, testKeyFrame)))
+	temporalUnit, err = reader.NextTemporalUnit()
+	assert.NoError(t, err)
+	assert.True(t, temporalUnit.IsKeyFrame)
+	assert.Equal(t, time.Second/30, reader.FrameDuration())
+
+	_, err = reader.NextTemporalUnit()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestAV1Reader_Errors(t *testing.T) {
+	_, err := NewWith(nil, FormatLowOverhead)
+	assert.ErrorIs(t, err, errNilStream)
+	_, err = NewWith(&bytes.Buffer{}, Format(2))
+	assert.ErrorIs(t, err, errUnknownFormat)
+
+	for _, test := range []struct {
+		format Format
+		stream []byte
+		err    error
+	}{
+		{FormatLowOverhead, obu(obuTypeFrame, false, testKeyFrame), errMissingOBUSize},
+		{FormatLowOverhead, obu(obuTypeFrame, true, testKeyFrame)[:3], errIncompleteOBU},
+		{FormatLowOverhead, []byte{0x32, 0x80}, io.ErrUnexpectedEOF},
+		{FormatAnnexB, []byte{0x05, 0x01}, io.ErrUnexpectedEOF},
+		{FormatAnnexB, sized(sized([]byte{0x05})), errInvalidUnitSize},
+		{FormatAnnexB, sized(sized([]byte{0x00})), errIncompleteOBU},
+	} {
+		reader, err := NewWith(bytes.NewReader(test.stream), test.format)
+		assert.NoError(t, err)
+		_, err = reader.NextTemporalUnit()
+		assert.ErrorIs(t, err, test.err)
+	}
+}
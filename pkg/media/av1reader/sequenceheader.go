@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package av1reader
+
+import (
+	"errors"
+	"time"
+)
+
+const frameTypeKeyFrame = 0
+
+var errBitReaderOutOfData = errors.New("AV1 syntax element reads past the end of the OBU")
+
+// sequenceHeader holds the fields of a sequence header OBU needed to find
+// key frames and the frame rate, see section 5.5 of the AV1 specification
+type sequenceHeader struct {
+	reducedStillPictureHeader bool
+
+	timingInfoPresent        bool
+	numUnitsInDisplayTick    uint32
+	timeScale                uint32
+	equalPictureInterval     bool
+	numTicksPerPictureMinus1 uint32
+}
+
+func parseSequenceHeader(payload []byte) (*sequenceHeader, error) {
+	r := &bitReader{data: payload}
+	header := &sequenceHeader{}
+
+	// seq_profile and still_picture
+	if _, err := r.f(4); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if header.reducedStillPictureHeader, err = r.flag(); err != nil || header.reducedStillPictureHeader {
+		return header, err
+	}
+
+	if header.timingInfoPresent, err = r.flag(); err != nil || !header.timingInfoPresent {
+		return header, err
+	}
+	if header.numUnitsInDisplayTick, err = r.f(32); err != nil {
+		return nil, err
+	}
+	if header.timeScale, err = r.f(32); err != nil {
+		return nil, err
+	}
+	if header.equalPictureInterval, err = r.flag(); err != nil {
+		return nil, err
+	}
+	if header.equalPictureInterval {
+		if header.numTicksPerPictureMinus1, err = r.uvlc(); err != nil {
+			return nil, err
+		}
+	}
+
+	return header, nil
+}
+
+// isShownKeyFrame returns whether the frame header at the start of payload
+// is a key frame that is shown, see section 5.9.2
+func (s *sequenceHeader) isShownKeyFrame(payload []byte) bool {
+	if s.reducedStillPictureHeader {
+		return true
+	}
+
+	r := &bitReader{data: payload}
+	showExistingFrame, err := r.flag()
+	if err != nil || showExistingFrame {
+		return false
+	}
+
+	frameType, err := r.f(2)
+	if err != nil || frameType != frameTypeKeyFrame {
+		return false
+	}
+
+	showFrame, err := r.flag()
+	return err == nil && showFrame
+}
+
+func (s *sequenceHeader) frameDuration() time.Duration {
+	if !s.timingInfoPresent || s.timeScale == 0 {
+		return 0
+	}
+
+	ticks := uint64(s.numUnitsInDisplayTick)
+	if s.equalPictureInterval {
+		ticks *= uint64(s.numTicksPerPictureMinus1) + 1
+	}
+	return time.Duration(float64(time.Second) * float64(ticks) / float64(s.timeScale))
+}
+
+// bitReader reads the syntax elements of OBU payloads
+type bitReader struct {
+	data   []byte
+	offset int // in bits
+}
+
+// f reads an unsigned integer of n bits, f(n) in the specification
+func (r *bitReader) f(n int) (uint32, error) {
+	if n > 32 || r.offset+n > len(r.data)*8 {
+		return 0, errBitReaderOutOfData
+	}
+
+	var v uint32
+	for i := 0; i < n; i++ {
+		bit := (r.data[r.offset/8] >> (7 - uint(r.offset%8))) & 1
+		v = v<<1 | uint32(bit)
+		r.offset++
+	}
+	return v, nil
+}
+
+func (r *bitReader) flag() (bool, error) {
+	v, err := r.f(1)
+	return v == 1, err
+}
+
+// uvlc reads a variable length unsigned integer, uvlc() in the
+// specification
+func (r *bitReader) uvlc() (uint32, error) {
+	leadingZeros := 0
+	for {
+		done, err := r.flag()
+		if err != nil {
+			return 0, err
+		}
+		if done {
+			break
+		}
+		leadingZeros++
+	}
+
+	if leadingZeros >= 32 {
+		return 1<<32 - 1, nil
+	}
+	value, err := r.f(leadingZeros)
+	if err != nil {
+		return 0, err
+	}
+	return value + (1<<uint(leadingZeros) - 1), nil
+}
@@ -0,0 +1,262 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package h264reader
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+var annexBStartCode = []byte{0x00, 0x00, 0x00, 0x01}
+
+// AccessUnit is a group of NALs that make up one coded picture
+type AccessUnit struct {
+	NALs []*NAL
+
+	// IsKeyFrame is set if the AccessUnit contains an IDR picture
+	IsKeyFrame bool
+
+	// PictureOrderCount of the picture, relative to the last IDR picture
+	PictureOrderCount uint32
+
+	// SPS and PPS are the parameter sets the picture refers to, nil if they
+	// have not been read yet
+	SPS *SPS
+	PPS *PPS
+}
+
+// Data returns the NALs of the AccessUnit in Annex-B format, each prefixed
+// with a start code
+func (a *AccessUnit) Data() []byte {
+	size := 0
+	for _, nal := range a.NALs {
+		size += len(annexBStartCode) + len(nal.Data)
+	}
+
+	data := make([]byte, 0, size)
+	for _, nal := range a.NALs {
+		data = append(data, annexBStartCode...)
+		data = append(data, nal.Data...)
+	}
+	return data
+}
+
+// Duration returns the duration of a frame from the timing info of the
+// SPS, or zero if it is unknown
+func (a *AccessUnit) Duration() time.Duration {
+	if a.SPS == nil {
+		return 0
+	}
+
+	frameRate := a.SPS.FrameRate()
+	if frameRate == 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / frameRate)
+}
+
+// sliceHeader holds the start of a slice header, up to the syntax elements
+// used to compute the picture order count
+type sliceHeader struct {
+	firstMbInSlice uint32
+	ppsID          uint32
+	frameNum       uint32
+	picOrderCntLsb uint32
+}
+
+func isVCL(unitType NalUnitType) bool {
+	return unitType >= NalUnitTypeCodedSliceNonIdr && unitType <= NalUnitTypeCodedSliceIdr
+}
+
+func parseFirstMbInSlice(nal *NAL) (uint32, error) {
+	return newBitReader(nal.Data).ue()
+}
+
+// NextAccessUnit reads from stream and returns the next AccessUnit. The
+// SPS and PPS of the stream are parsed to compute the picture order count
+// of each picture. NextAccessUnit reads one NAL ahead, so it must not be
+// mixed with calls to NextNAL.
+// Returns io.EOF when no more AccessUnits are available.
+func (reader *H264Reader) NextAccessUnit() (*AccessUnit, error) {
+	accessUnit := &AccessUnit{}
+	hasVCL := false
+
+	for {
+		nal := reader.pendingNAL
+		reader.pendingNAL = nil
+		if nal == nil {
+			var err error
+			if nal, err = reader.NextNAL(); err != nil {
+				if errors.Is(err, io.EOF) && len(accessUnit.NALs) != 0 {
+					return accessUnit, nil
+				}
+				return nil, err
+			}
+		}
+
+		if hasVCL && startsAccessUnit(nal) {
+			reader.pendingNAL = nal
+			return accessUnit, nil
+		}
+
+		reader.processNAL(accessUnit, nal)
+		accessUnit.NALs = append(accessUnit.NALs, nal)
+		hasVCL = hasVCL || isVCL(nal.UnitType)
+	}
+}
+
+// startsAccessUnit returns whether nal is the first NAL of a new AccessUnit,
+// given that the current one already holds a picture. See section 7.4.1.2.3
+// of the H.264 specification.
+func startsAccessUnit(nal *NAL) bool {
+	switch {
+	case nal.UnitType == NalUnitTypeAUD, nal.UnitType == NalUnitTypeSPS,
+		nal.UnitType == NalUnitTypePPS, nal.UnitType == NalUnitTypeSEI:
+		return true
+	case nal.UnitType >= 14 && nal.UnitType <= 18:
+		return true
+	case isVCL(nal.UnitType):
+		firstMbInSlice, err := parseFirstMbInSlice(nal)
+		return err == nil && firstMbInSlice == 0
+	default:
+		return false
+	}
+}
+
+// processNAL updates the parameter sets and picture order count state of
+// the reader with nal. Parameter sets and slices that fail to parse are
+// passed through without updating the state.
+func (reader *H264Reader) processNAL(accessUnit *AccessUnit, nal *NAL) {
+	switch {
+	case nal.UnitType == NalUnitTypeSPS:
+		if sps, err := ParseSPS(nal.Data); err == nil {
+			reader.sps[sps.ID] = sps
+		}
+	case nal.UnitType == NalUnitTypePPS:
+		if pps, err := ParsePPS(nal.Data); err == nil {
+			reader.pps[pps.ID] = pps
+		}
+	case isVCL(nal.UnitType):
+		isIDR := nal.UnitType == NalUnitTypeCodedSliceIdr
+		accessUnit.IsKeyFrame = accessUnit.IsKeyFrame || isIDR
+
+		pps, sps, header, err := reader.parseSliceHeader(nal, isIDR)
+		if err != nil {
+			return
+		}
+		accessUnit.SPS, accessUnit.PPS = sps, pps
+
+		if header.firstMbInSlice == 0 {
+			reader.picOrderCount = reader.computePicOrderCount(sps, header, nal.RefIdc != 0, isIDR)
+		}
+		nal.PictureOrderCount = reader.picOrderCount
+		accessUnit.PictureOrderCount = reader.picOrderCount
+	}
+}
+
+func (reader *H264Reader) parseSliceHeader(nal *NAL, isIDR bool) (*PPS, *SPS, *sliceHeader, error) {
+	r := newBitReader(nal.Data)
+	header := &sliceHeader{}
+
+	var err error
+	if header.firstMbInSlice, err = r.ue(); err != nil {
+		return nil, nil, nil, err
+	}
+	// slice_type
+	if _, err = r.ue(); err != nil {
+		return nil, nil, nil, err
+	}
+	if header.ppsID, err = r.ue(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	pps, ok := reader.pps[header.ppsID]
+	if !ok {
+		return nil, nil, nil, errMissingParameterSet
+	}
+	sps, ok := reader.sps[pps.SPSID]
+	if !ok {
+		return nil, nil, nil, errMissingParameterSet
+	}
+
+	if sps.SeparateColourPlane {
+		// colour_plane_id
+		if _, err = r.u(2); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if header.frameNum, err = r.u(int(sps.Log2MaxFrameNum)); err != nil {
+		return nil, nil, nil, err
+	}
+	if !sps.FrameMbsOnly {
+		fieldPic, err := r.flag()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if fieldPic {
+			// bottom_field_flag
+			if _, err = r.u(1); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+	if isIDR {
+		// idr_pic_id
+		if _, err = r.ue(); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if sps.PicOrderCntType == 0 {
+		if header.picOrderCntLsb, err = r.u(int(sps.Log2MaxPicOrderCntLsb)); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	return pps, sps, header, nil
+}
+
+// computePicOrderCount implements the picture order count decoding of
+// section 8.2.1 for frames. Type 1 is approximated by the decoding order,
+// as for type 2.
+func (reader *H264Reader) computePicOrderCount(sps *SPS, header *sliceHeader, isReference, isIDR bool) uint32 {
+	if sps.PicOrderCntType == 0 {
+		if isIDR {
+			reader.prevPicOrderCntMsb, reader.prevPicOrderCntLsb = 0, 0
+		}
+
+		maxPicOrderCntLsb := int64(1) << sps.Log2MaxPicOrderCntLsb
+		lsb := int64(header.picOrderCntLsb)
+		msb := reader.prevPicOrderCntMsb
+		switch {
+		case lsb < reader.prevPicOrderCntLsb && reader.prevPicOrderCntLsb-lsb >= maxPicOrderCntLsb/2:
+			msb += maxPicOrderCntLsb
+		case lsb > reader.prevPicOrderCntLsb && lsb-reader.prevPicOrderCntLsb > maxPicOrderCntLsb/2:
+			msb -= maxPicOrderCntLsb
+		}
+
+		if isReference {
+			reader.prevPicOrderCntMsb, reader.prevPicOrderCntLsb = msb, lsb
+		}
+		return uint32(msb + lsb)
+	}
+
+	frameNum := int64(header.frameNum)
+	switch {
+	case isIDR:
+		reader.frameNumOffset = 0
+	case reader.prevFrameNum > frameNum:
+		reader.frameNumOffset += int64(1) << sps.Log2MaxFrameNum
+	}
+	reader.prevFrameNum = frameNum
+
+	switch {
+	case isIDR:
+		return 0
+	case !isReference:
+		return uint32(2*(reader.frameNumOffset+frameNum) - 1)
+	default:
+		return uint32(2 * (reader.frameNumOffset + frameNum))
+	}
+}
@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package h264reader
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testBitWriter writes H.264 syntax elements to build test NALs
+type testBitWriter struct {
+	data   []byte
+	offset int
+}
+
+func (w *testBitWriter) u(v uint32, n int) *testBitWriter {
+	for i := n - 1; i >= 0; i-- {
+		if w.offset%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[len(w.data)-1] |= byte((v>>uint(i))&1) << (7 - uint(w.offset%8))
+		w.offset++
+	}
+	return w
+}
+
+func (w *testBitWriter) ue(v uint32) *testBitWriter {
+	v++
+	n := 0
+	for x := v; x > 1; x >>= 1 {
+		n++
+	}
+	return w.u(0, n).u(v, n+1)
+}
+
+// nal returns the NAL with the header byte, rbsp trailing bits and
+// emulation prevention bytes
+func (w *testBitWriter) nal(header byte) []byte {
+	w.u(1, 1)
+	if w.offset%8 != 0 {
+		w.u(0, 8-w.offset%8)
+	}
+
+	nal := []byte{header}
+	zeros := 0
+	for _, b := range w.data {
+		if zeros >= 2 && b <= 3 {
+			nal = append(nal, 3)
+			zeros = 0
+		}
+		nal = append(nal, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return nal
+}
+
+// testSPS is a 1920x1080 Baseline Profile Level 3.1 SPS at 30 frames per
+// second, with 4 bits frame_num and 6 bits pic_order_cnt_lsb
+func testSPS() []byte {
+	return (&testBitWriter{}).
+		u(66, 8).u(0xC0, 8).u(31, 8).
+		ue(0).           // seq_parameter_set_id
+		ue(0).           // log2_max_frame_num_minus4
+		ue(0).ue(2).     // pic_order_cnt_type, log2_max_pic_order_cnt_lsb_minus4
+		ue(1).u(0, 1).   // max_num_ref_frames, gaps_in_frame_num_value_allowed_flag
+		ue(119).ue(67).  // pic_width_in_mbs_minus1, pic_height_in_map_units_minus1
+		u(1, 1).u(1, 1). // frame_mbs_only_flag, direct_8x8_inference_flag
+		u(1, 1).         // frame_cropping_flag
+		ue(0).ue(0).ue(0).ue(4).
+		u(1, 1). // vui_parameters_present_flag
+		u(0, 1).u(0, 1).u(0, 1).u(0, 1).
+		u(1, 1).u(1, 32).u(60, 32).u(1, 1). // timing_info
+		nal(0x67)
+}
+
+func testPPS() []byte {
+	return (&testBitWriter{}).ue(0).ue(0).u(0, 1).u(0, 1).nal(0x68)
+}
+
+func testSlice(header byte, firstMbInSlice, frameNum, picOrderCntLsb uint32) []byte {
+	w := (&testBitWriter{}).ue(firstMbInSlice).ue(0).ue(0).u(frameNum, 4)
+	if NalUnitType(header&0x1F) == NalUnitTypeCodedSliceIdr {
+		w.ue(0)
+	}
+	return w.u(picOrderCntLsb, 6).u(0xAB, 8).nal(header)
+}
+
+func annexB(nals ...[]byte) []byte {
+	var data []byte
+	for _, nal := range nals {
+		data = append(data, annexBStartCode...)
+		data = append(data, nal...)
+	}
+	return data
+}
+
+func TestParseSPS(t *testing.T) {
+	require := require.New(t)
+
+	sps, err := ParseSPS(testSPS())
+	require.NoError(err)
+	require.Equal(uint32(1920), sps.Width)
+	require.Equal(uint32(1080), sps.Height)
+	require.Equal(uint32(4), sps.Log2MaxFrameNum)
+	require.Equal(uint32(6), sps.Log2MaxPicOrderCntLsb)
+	require.Equal(30.0, sps.FrameRate())
+	require.Equal("42c01f", sps.ProfileLevelID())
+
+	// High Profile adds chroma format and scaling lists
+	highSPS := (&testBitWriter{}).
+		u(100, 8).u(0, 8).u(40, 8).ue(1).
+		ue(1).ue(0).ue(0).u(0, 1).u(1, 1). // chroma_format_idc to seq_scaling_matrix_present_flag
+		u(1, 1).ue(16).u(0, 7).            // first scaling list, delta_scale -8 ends it
+		ue(0).ue(2).ue(1).u(0, 1).ue(79).ue(44).u(1, 1).u(1, 1).u(0, 1).u(0, 1).
+		nal(0x67)
+	sps, err = ParseSPS(highSPS)
+	require.NoError(err)
+	require.Equal(uint32(1), sps.ID)
+	require.Equal(uint32(1280), sps.Width)
+	require.Equal(uint32(720), sps.Height)
+	require.Equal(0.0, sps.FrameRate())
+	require.Equal("640028", sps.ProfileLevelID())
+
+	_, err = ParseSPS(testPPS())
+	require.ErrorIs(err, errNotSPS)
+	_, err = ParseSPS(testSPS()[:4])
+	require.ErrorIs(err, errBitReaderOutOfData)
+}
+
+func TestParsePPS(t *testing.T) {
+	require := require.New(t)
+
+	pps, err := ParsePPS((&testBitWriter{}).ue(3).ue(1).u(1, 1).u(0, 1).nal(0x68))
+	require.NoError(err)
+	require.Equal(&PPS{ID: 3, SPSID: 1, EntropyCodingModeFlag: true}, pps)
+
+	_, err = ParsePPS(testSPS())
+	require.ErrorIs(err, errNotPPS)
+}
+
+func TestNextAccessUnit(t *testing.T) {
+	require := require.New(t)
+
+	aud := []byte{0x09, 0xF0}
+	stream := annexB(
+		testSPS(), testPPS(),
+		testSlice(0x65, 0, 0, 0), testSlice(0x65, 10, 0, 0),
+		testSlice(0x41, 0, 1, 4),
+		testSlice(0x01, 0, 2, 2),
+		aud, testSlice(0x41, 0, 2, 8),
+		// pic_order_cnt_lsb wraps around
+		testSlice(0x41, 0, 3, 40),
+		testSlice(0x41, 0, 4, 62),
+		testSlice(0x41, 0, 5, 2),
+	)
+
+	reader := CreateReader(stream, require)
+	expected := []struct {
+		nals              int
+		isKeyFrame        bool
+		pictureOrderCount uint32
+	}{
+		{4, true, 0},
+		{1, false, 4},
+		{1, false, 2},
+		{2, false, 8},
+		{1, false, 40},
+		{1, false, 62},
+		{1, false, 66},
+	}
+	for _, e := range expected {
+		accessUnit, err := reader.NextAccessUnit()
+		require.NoError(err)
+		require.Len(accessUnit.NALs, e.nals)
+		require.Equal(e.isKeyFrame, accessUnit.IsKeyFrame)
+		require.Equal(e.pictureOrderCount, accessUnit.PictureOrderCount)
+		require.Equal(time.Second/30, accessUnit.Duration())
+		require.NotNil(accessUnit.PPS)
+	}
+
+	_, err := reader.NextAccessUnit()
+	require.ErrorIs(err, io.EOF)
+
+	// Data reproduces the stream
+	reader = CreateReader(stream, require)
+	var data []byte
+	for {
+		accessUnit, err := reader.NextAccessUnit()
+		if err != nil {
+			require.ErrorIs(err, io.EOF)
+			break
+		}
+		data = append(data, accessUnit.Data()...)
+	}
+	require.True(bytes.Equal(stream, data))
+}
+
+func TestNextAccessUnit_MissingParameterSets(t *testing.T) {
+	require := require.New(t)
+
+	reader := CreateReader(annexB(testSlice(0x65, 0, 0, 0), testSlice(0x41, 0, 1, 4)), require)
+	for _, isKeyFrame := range []bool{true, false} {
+		accessUnit, err := reader.NextAccessUnit()
+		require.NoError(err)
+		require.Equal(isKeyFrame, accessUnit.IsKeyFrame)
+		require.Nil(accessUnit.SPS)
+		require.Equal(time.Duration(0), accessUnit.Duration())
+	}
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package h264reader
+
+import "errors"
+
+var errBitReaderOutOfData = errors.New("h264 syntax element reads past the end of the NAL")
+
+// bitReader reads the syntax elements of H.264 RBSP data
+type bitReader struct {
+	data   []byte
+	offset int // in bits
+}
+
+// newBitReader returns a bitReader for the payload of a NAL, the header byte
+// is skipped and emulation prevention bytes are removed
+func newBitReader(nal []byte) *bitReader {
+	rbsp := make([]byte, 0, len(nal))
+	zeros := 0
+	for i := 1; i < len(nal); i++ {
+		if zeros >= 2 && nal[i] == 3 {
+			zeros = 0
+			continue
+		}
+
+		if nal[i] == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+		rbsp = append(rbsp, nal[i])
+	}
+
+	return &bitReader{data: rbsp}
+}
+
+// u reads an unsigned integer of n bits, u(n) in the specification
+func (r *bitReader) u(n int) (uint32, error) {
+	if n > 32 || r.offset+n > len(r.data)*8 {
+		return 0, errBitReaderOutOfData
+	}
+
+	var v uint32
+	for i := 0; i < n; i++ {
+		bit := (r.data[r.offset/8] >> (7 - uint(r.offset%8))) & 1
+		v = v<<1 | uint32(bit)
+		r.offset++
+	}
+	return v, nil
+}
+
+// flag reads a single bit, u(1) in the specification
+func (r *bitReader) flag() (bool, error) {
+	v, err := r.u(1)
+	return v == 1, err
+}
+
+// ue reads an Exp-Golomb coded unsigned integer, ue(v) in the specification
+func (r *bitReader) ue() (uint32, error) {
+	leadingZeros := 0
+	for {
+		bit, err := r.u(1)
+		if err != nil {
+			return 0, err
+		}
+		if bit == 1 {
+			break
+		}
+
+		leadingZeros++
+		if leadingZeros > 31 {
+			return 0, errBitReaderOutOfData
+		}
+	}
+
+	suffix, err := r.u(leadingZeros)
+	if err != nil {
+		return 0, err
+	}
+	return (1<<uint(leadingZeros) - 1) + suffix, nil
+}
+
+// se reads an Exp-Golomb coded signed integer, se(v) in the specification
+func (r *bitReader) se() (int32, error) {
+	v, err := r.ue()
+	if err != nil {
+		return 0, err
+	}
+
+	if v%2 == 0 {
+		return -int32(v / 2), nil
+	}
+	return int32(v/2) + 1, nil
+}
@@ -18,11 +18,22 @@ type H264Reader struct {
 	nalPrefixParsed             bool
 	readBuffer                  []byte
 	tmpReadBuf                  []byte
+
+	// State of NextAccessUnit
+	pendingNAL         *NAL
+	sps                map[uint32]*SPS
+	pps                map[uint32]*PPS
+	picOrderCount      uint32
+	prevPicOrderCntMsb int64
+	prevPicOrderCntLsb int64
+	prevFrameNum       int64
+	frameNumOffset     int64
 }
 
 var (
 	errNilReader           = errors.New("stream is nil")
 	errDataIsNotH264Stream = errors.New("data is not a H264 bitstream")
+	errMissingParameterSet = errors.New("slice refers to a parameter set that has not been read")
 )
 
 // NewReader creates new H264Reader
@@ -37,6 +48,8 @@ func NewReader(in io.Reader) (*H264Reader, error) {
 		nalPrefixParsed: false,
 		readBuffer:      make([]byte, 0),
 		tmpReadBuf:      make([]byte, 4096),
+		sps:             map[uint32]*SPS{},
+		pps:             map[uint32]*PPS{},
 	}
 
 	return reader, nil
@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package h264reader
+
+import "errors"
+
+var errNotPPS = errors.New("NAL is not a picture parameter set")
+
+// PPS is a parsed picture parameter set. Only the fields needed to parse
+// slice headers are kept.
+type PPS struct {
+	ID                             uint32
+	SPSID                          uint32
+	EntropyCodingModeFlag          bool
+	BottomFieldPicOrderInFrameFlag bool
+}
+
+// ParsePPS parses a picture parameter set NAL, including its header byte
+func ParsePPS(nal []byte) (*PPS, error) {
+	if len(nal) == 0 || NalUnitType(nal[0]&0x1F) != NalUnitTypePPS {
+		return nil, errNotPPS
+	}
+
+	r := newBitReader(nal)
+	pps := &PPS{}
+
+	var err error
+	if pps.ID, err = r.ue(); err != nil {
+		return nil, err
+	}
+	if pps.SPSID, err = r.ue(); err != nil {
+		return nil, err
+	}
+	if pps.EntropyCodingModeFlag, err = r.flag(); err != nil {
+		return nil, err
+	}
+	if pps.BottomFieldPicOrderInFrameFlag, err = r.flag(); err != nil {
+		return nil, err
+	}
+	return pps, nil
+}
@@ -0,0 +1,352 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package h264reader
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errNotSPS = errors.New("NAL is not a sequence parameter set")
+
+// aspectRatioExtendedSAR signals an explicit sample aspect ratio in the VUI
+const aspectRatioExtendedSAR = 255
+
+// SPS is a parsed sequence parameter set. Only the fields needed to play
+// back and describe a stream are kept.
+type SPS struct {
+	ProfileIdc            uint8
+	ConstraintFlags       uint8
+	LevelIdc              uint8
+	ID                    uint32
+	ChromaFormatIdc       uint32
+	SeparateColourPlane   bool
+	Log2MaxFrameNum       uint32
+	PicOrderCntType       uint32
+	Log2MaxPicOrderCntLsb uint32
+	FrameMbsOnly          bool
+
+	// Width and Height are the dimensions of the decoded pictures in pixels,
+	// after cropping
+	Width  uint32
+	Height uint32
+
+	// NumUnitsInTick and TimeScale are the VUI timing info, only valid
+	// when TimingInfoPresent is set
+	TimingInfoPresent bool
+	NumUnitsInTick    uint32
+	TimeScale         uint32
+	FixedFrameRate    bool
+}
+
+// ParseSPS parses a sequence parameter set NAL, including its header byte
+func ParseSPS(nal []byte) (*SPS, error) {
+	if len(nal) == 0 || NalUnitType(nal[0]&0x1F) != NalUnitTypeSPS {
+		return nil, errNotSPS
+	}
+
+	sps := &SPS{ChromaFormatIdc: 1}
+	if err := sps.parse(newBitReader(nal)); err != nil {
+		return nil, err
+	}
+	return sps, nil
+}
+
+func (s *SPS) parse(r *bitReader) error { //nolint:gocognit,cyclop
+	header, err := r.u(24)
+	if err != nil {
+		return err
+	}
+	s.ProfileIdc = uint8(header >> 16)
+	s.ConstraintFlags = uint8(header >> 8)
+	s.LevelIdc = uint8(header)
+
+	if s.ID, err = r.ue(); err != nil {
+		return err
+	}
+
+	switch s.ProfileIdc {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		if err = s.parseHighProfile(r); err != nil {
+			return err
+		}
+	}
+
+	log2MaxFrameNumMinus4, err := r.ue()
+	if err != nil {
+		return err
+	}
+	s.Log2MaxFrameNum = log2MaxFrameNumMinus4 + 4
+
+	if s.PicOrderCntType, err = r.ue(); err != nil {
+		return err
+	}
+	switch s.PicOrderCntType {
+	case 0:
+		log2MaxPicOrderCntLsbMinus4, err := r.ue()
+		if err != nil {
+			return err
+		}
+		s.Log2MaxPicOrderCntLsb = log2MaxPicOrderCntLsbMinus4 + 4
+	case 1:
+		// delta_pic_order_always_zero_flag, offset_for_non_ref_pic and
+		// offset_for_top_to_bottom_field
+		if _, err = r.u(1); err != nil {
+			return err
+		}
+		for i := 0; i < 2; i++ {
+			if _, err = r.se(); err != nil {
+				return err
+			}
+		}
+
+		numRefFramesInPicOrderCntCycle, err := r.ue()
+		if err != nil {
+			return err
+		}
+		for i := uint32(0); i < numRefFramesInPicOrderCntCycle; i++ {
+			if _, err = r.se(); err != nil {
+				return err
+			}
+		}
+	}
+
+	// max_num_ref_frames and gaps_in_frame_num_value_allowed_flag
+	if _, err = r.ue(); err != nil {
+		return err
+	}
+	if _, err = r.u(1); err != nil {
+		return err
+	}
+
+	picWidthInMbsMinus1, err := r.ue()
+	if err != nil {
+		return err
+	}
+	picHeightInMapUnitsMinus1, err := r.ue()
+	if err != nil {
+		return err
+	}
+
+	if s.FrameMbsOnly, err = r.flag(); err != nil {
+		return err
+	}
+	if !s.FrameMbsOnly {
+		// mb_adaptive_frame_field_flag
+		if _, err = r.u(1); err != nil {
+			return err
+		}
+	}
+
+	// direct_8x8_inference_flag
+	if _, err = r.u(1); err != nil {
+		return err
+	}
+
+	frameHeightFactor := uint32(2)
+	if s.FrameMbsOnly {
+		frameHeightFactor = 1
+	}
+	s.Width = (picWidthInMbsMinus1 + 1) * 16
+	s.Height = frameHeightFactor * (picHeightInMapUnitsMinus1 + 1) * 16
+
+	frameCropping, err := r.flag()
+	if err != nil {
+		return err
+	}
+	if frameCropping {
+		var crop [4]uint32 // left, right, top, bottom
+		for i := range crop {
+			if crop[i], err = r.ue(); err != nil {
+				return err
+			}
+		}
+
+		cropUnitX, cropUnitY := s.cropUnits()
+		s.Width -= cropUnitX * (crop[0] + crop[1])
+		s.Height -= cropUnitY * frameHeightFactor * (crop[2] + crop[3])
+	}
+
+	vuiPresent, err := r.flag()
+	if err != nil {
+		return err
+	}
+	if vuiPresent {
+		return s.parseVUI(r)
+	}
+	return nil
+}
+
+func (s *SPS) parseHighProfile(r *bitReader) error {
+	var err error
+	if s.ChromaFormatIdc, err = r.ue(); err != nil {
+		return err
+	}
+	if s.ChromaFormatIdc == 3 {
+		if s.SeparateColourPlane, err = r.flag(); err != nil {
+			return err
+		}
+	}
+
+	// bit_depth_luma_minus8, bit_depth_chroma_minus8
+	for i := 0; i < 2; i++ {
+		if _, err = r.ue(); err != nil {
+			return err
+		}
+	}
+
+	// qpprime_y_zero_transform_bypass_flag
+	if _, err = r.u(1); err != nil {
+		return err
+	}
+
+	scalingMatrixPresent, err := r.flag()
+	if err != nil || !scalingMatrixPresent {
+		return err
+	}
+
+	scalingLists := 8
+	if s.ChromaFormatIdc == 3 {
+		scalingLists = 12
+	}
+	for i := 0; i < scalingLists; i++ {
+		present, err := r.flag()
+		if err != nil {
+			return err
+		}
+		if !present {
+			continue
+		}
+
+		size := 16
+		if i >= 6 {
+			size = 64
+		}
+		if err = skipScalingList(r, size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func skipScalingList(r *bitReader, size int) error {
+	lastScale, nextScale := int32(8), int32(8)
+	for j := 0; j < size; j++ {
+		if nextScale != 0 {
+			deltaScale, err := r.se()
+			if err != nil {
+				return err
+			}
+			nextScale = (lastScale + deltaScale + 256) % 256
+		}
+		if nextScale != 0 {
+			lastScale = nextScale
+		}
+	}
+	return nil
+}
+
+// cropUnits returns CropUnitX and CropUnitY for frames
+func (s *SPS) cropUnits() (uint32, uint32) {
+	if s.SeparateColourPlane || s.ChromaFormatIdc == 0 {
+		return 1, 1
+	}
+
+	subWidthC, subHeightC := uint32(2), uint32(2)
+	switch s.ChromaFormatIdc {
+	case 2:
+		subHeightC = 1
+	case 3:
+		subWidthC, subHeightC = 1, 1
+	}
+	return subWidthC, subHeightC
+}
+
+func (s *SPS) parseVUI(r *bitReader) error { //nolint:cyclop
+	aspectRatioInfoPresent, err := r.flag()
+	if err != nil {
+		return err
+	}
+	if aspectRatioInfoPresent {
+		aspectRatioIdc, err := r.u(8)
+		if err != nil {
+			return err
+		}
+		if aspectRatioIdc == aspectRatioExtendedSAR {
+			// sar_width, sar_height
+			if _, err = r.u(32); err != nil {
+				return err
+			}
+		}
+	}
+
+	overscanInfoPresent, err := r.flag()
+	if err != nil {
+		return err
+	}
+	if overscanInfoPresent {
+		if _, err = r.u(1); err != nil {
+			return err
+		}
+	}
+
+	videoSignalTypePresent, err := r.flag()
+	if err != nil {
+		return err
+	}
+	if videoSignalTypePresent {
+		// video_format, video_full_range_flag
+		if _, err = r.u(4); err != nil {
+			return err
+		}
+		colourDescriptionPresent, err := r.flag()
+		if err != nil {
+			return err
+		}
+		if colourDescriptionPresent {
+			if _, err = r.u(24); err != nil {
+				return err
+			}
+		}
+	}
+
+	chromaLocInfoPresent, err := r.flag()
+	if err != nil {
+		return err
+	}
+	if chromaLocInfoPresent {
+		for i := 0; i < 2; i++ {
+			if _, err = r.ue(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.TimingInfoPresent, err = r.flag(); err != nil || !s.TimingInfoPresent {
+		return err
+	}
+	if s.NumUnitsInTick, err = r.u(32); err != nil {
+		return err
+	}
+	if s.TimeScale, err = r.u(32); err != nil {
+		return err
+	}
+	s.FixedFrameRate, err = r.flag()
+	return err
+}
+
+// FrameRate returns the frame rate signaled by the VUI timing info, or zero
+// if the SPS has none. Two ticks make up a frame.
+func (s *SPS) FrameRate() float64 {
+	if !s.TimingInfoPresent || s.NumUnitsInTick == 0 {
+		return 0
+	}
+	return float64(s.TimeScale) / float64(2*s.NumUnitsInTick)
+}
+
+// ProfileLevelID returns the profile-level-id fmtp parameter describing
+// the stream, as defined in RFC 6184
+func (s *SPS) ProfileLevelID() string {
+	return fmt.Sprintf("%02x%02x%02x", s.ProfileIdc, s.ConstraintFlags, s.LevelIdc)
+}
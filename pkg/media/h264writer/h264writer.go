@@ -5,25 +5,74 @@
 package h264writer
 
 import (
-	"bytes"
 	"encoding/binary"
 	"io"
 	"os"
 
 	"github.com/pion/rtp"
-	"github.com/pion/rtp/codecs"
 )
 
+const (
+	typeIDR   = 5
+	typeSPS   = 7
+	typeSTAPA = 24
+	typeFUA   = 28
+
+	naluTypeBitmask   = 0x1F
+	naluHeaderBitmask = 0xE0
+	fuStartBitmask    = 0x80
+	fuEndBitmask      = 0x40
+
+	stapaHeaderSize     = 1
+	stapaNALULengthSize = 2
+	fuaHeaderSize       = 2
+
+	// reorderWindow is the number of packets held while waiting for a
+	// missing sequence number, before it is considered lost
+	reorderWindow = 64
+)
+
+var annexbNALUStartCode = []byte{0x00, 0x00, 0x00, 0x01}
+
 type (
 	// H264Writer is used to take RTP packets, parse them and
 	// write the data to an io.Writer.
 	// Currently it only supports non-interleaved mode
 	// Therefore, only 1-23, 24 (STAP-A), 28 (FU-A) NAL types are allowed.
 	// https://tools.ietf.org/html/rfc6184#section-5.2
+	//
+	// Packets are reordered by sequence number, and a frame is written once
+	// all its packets have been received, when the marker bit is set or a
+	// packet of the next frame arrives. Frames that are incomplete, and the
+	// frames that follow them until the next IDR, are dropped.
 	H264Writer struct {
-		writer       io.Writer
-		hasKeyFrame  bool
-		cachedPacket *codecs.H264Packet
+		writer      io.Writer
+		hasKeyFrame bool
+
+		started bool
+		nextSeq uint16
+		pending map[uint16]*pendingPacket
+		frame   frame
+
+		droppedFrames uint64
+		lostPackets   uint64
+	}
+
+	pendingPacket struct {
+		timestamp uint32
+		marker    bool
+		payload   []byte
+	}
+
+	frame struct {
+		started   bool
+		timestamp uint32
+		data      []byte
+		keyFrame  bool
+		broken    bool
+
+		// fragment is the NAL being reassembled from FU-A packets
+		fragment []byte
 	}
 )
 
@@ -50,59 +99,244 @@ func (h *H264Writer) WriteRTP(packet *rtp.Packet) error {
 		return nil
 	}
 
-	if !h.hasKeyFrame {
-		if h.hasKeyFrame = isKeyFrame(packet.Payload); !h.hasKeyFrame {
-			// key frame not defined yet. discarding packet
+	if h.pending == nil {
+		h.pending = map[uint16]*pendingPacket{}
+	}
+	if !h.started {
+		h.started = true
+		h.nextSeq = packet.SequenceNumber
+	}
+
+	// Late and duplicate packets are ignored
+	distance := packet.SequenceNumber - h.nextSeq
+	if distance >= 1<<15 {
+		return nil
+	}
+	if _, ok := h.pending[packet.SequenceNumber]; ok {
+		return nil
+	}
+
+	h.pending[packet.SequenceNumber] = &pendingPacket{
+		timestamp: packet.Timestamp,
+		marker:    packet.Marker,
+		payload:   append([]byte{}, packet.Payload...),
+	}
+	if err := h.drain(false); err != nil {
+		return err
+	}
+
+	// Give up on the missing packets once the window is exceeded
+	for len(h.pending) != 0 && (len(h.pending) > reorderWindow || packet.SequenceNumber-h.nextSeq >= reorderWindow) {
+		h.skipGap()
+		if err := h.drain(true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DroppedFrames returns the number of frames that were not written, because
+// they were incomplete or came after an incomplete frame
+func (h *H264Writer) DroppedFrames() uint64 {
+	return h.droppedFrames
+}
+
+// LostPackets returns the number of sequence numbers that were never received
+func (h *H264Writer) LostPackets() uint64 {
+	return h.lostPackets
+}
+
+// skipGap moves the next expected sequence number to the closest pending
+// packet, and counts the packets in between as lost
+func (h *H264Writer) skipGap() {
+	closest := uint16(1<<16 - 1)
+	for seq := range h.pending {
+		if distance := seq - h.nextSeq; distance < closest {
+			closest = distance
+		}
+	}
+
+	h.lostPackets += uint64(closest)
+	h.nextSeq += closest
+}
+
+// drain processes the pending packets that are in order. afterGap is set if
+// packets were lost before the first of them.
+func (h *H264Writer) drain(afterGap bool) error {
+	for {
+		packet, ok := h.pending[h.nextSeq]
+		if !ok {
 			return nil
 		}
+		delete(h.pending, h.nextSeq)
+		h.nextSeq++
+
+		if err := h.processPacket(packet, afterGap); err != nil {
+			return err
+		}
+		afterGap = false
 	}
+}
 
-	if h.cachedPacket == nil {
-		h.cachedPacket = &codecs.H264Packet{}
+func (h *H264Writer) processPacket(packet *pendingPacket, afterGap bool) error {
+	// The lost packets may belong to the frame in progress or the next one
+	if afterGap && h.frame.started {
+		h.frame.broken = true
 	}
 
-	data, err := h.cachedPacket.Unmarshal(packet.Payload)
-	if err != nil {
-		return err
+	if h.frame.started && packet.timestamp != h.frame.timestamp {
+		if err := h.flushFrame(); err != nil {
+			return err
+		}
+	}
+	if !h.frame.started {
+		h.frame = frame{started: true, timestamp: packet.timestamp, broken: afterGap}
 	}
 
-	_, err = h.writer.Write(data)
+	h.frame.keyFrame = h.frame.keyFrame || isKeyFrame(packet.payload)
+	h.frame.depacketize(packet.payload)
 
+	if packet.marker {
+		return h.flushFrame()
+	}
+	return nil
+}
+
+// flushFrame writes the frame in progress, unless it has to be dropped
+func (h *H264Writer) flushFrame() error {
+	f := h.frame
+	h.frame = frame{}
+
+	switch {
+	case !f.started:
+		return nil
+	case f.broken || f.fragment != nil:
+		h.droppedFrames++
+		h.hasKeyFrame = false
+		return nil
+	case len(f.data) == 0:
+		return nil
+	case !h.hasKeyFrame && !f.keyFrame:
+		// key frame not defined yet. discarding frame
+		h.droppedFrames++
+		return nil
+	}
+
+	h.hasKeyFrame = true
+	_, err := h.writer.Write(f.data)
 	return err
 }
 
-// Close closes the underlying writer
+func (f *frame) appendNALU(nalu []byte) {
+	f.data = append(f.data, annexbNALUStartCode...)
+	f.data = append(f.data, nalu...)
+}
+
+// depacketize appends the NALs of payload to the frame, and marks it
+// broken if payload cannot be parsed or a FU-A fragment is missing
+func (f *frame) depacketize(payload []byte) {
+	switch naluType := payload[0] & naluTypeBitmask; {
+	case naluType > 0 && naluType < typeSTAPA:
+		f.appendNALU(payload)
+
+	case naluType == typeSTAPA:
+		offset := stapaHeaderSize
+		for offset+stapaNALULengthSize <= len(payload) {
+			naluSize := int(binary.BigEndian.Uint16(payload[offset:]))
+			offset += stapaNALULengthSize
+			if naluSize == 0 || offset+naluSize > len(payload) {
+				f.broken = true
+				return
+			}
+
+			f.appendNALU(payload[offset : offset+naluSize])
+			offset += naluSize
+		}
+
+	case naluType == typeFUA:
+		if len(payload) < fuaHeaderSize {
+			f.broken = true
+			return
+		}
+
+		if payload[1]&fuStartBitmask != 0 {
+			if f.fragment != nil {
+				// The end of the previous NAL is missing
+				f.broken = true
+			}
+			f.fragment = []byte{payload[0]&naluHeaderBitmask | payload[1]&naluTypeBitmask}
+		} else if f.fragment == nil {
+			// The start of the NAL is missing
+			f.broken = true
+			return
+		}
+
+		f.fragment = append(f.fragment, payload[fuaHeaderSize:]...)
+		if payload[1]&fuEndBitmask != 0 {
+			f.appendNALU(f.fragment)
+			f.fragment = nil
+		}
+
+	default:
+		f.broken = true
+	}
+}
+
+// Close writes the frame in progress if it is complete, and closes the
+// underlying writer
 func (h *H264Writer) Close() error {
-	h.cachedPacket = nil
+	var err error
+	for len(h.pending) != 0 && err == nil {
+		h.skipGap()
+		err = h.drain(true)
+	}
+	if err == nil {
+		err = h.flushFrame()
+	}
+	h.pending = nil
+
 	if h.writer != nil {
 		if closer, ok := h.writer.(io.Closer); ok {
-			return closer.Close()
+			if closeErr := closer.Close(); closeErr != nil {
+				return closeErr
+			}
 		}
 	}
 
-	return nil
+	return err
 }
 
-func isKeyFrame(data []byte) bool {
-	const (
-		typeSTAPA       = 24
-		typeSPS         = 7
-		naluTypeBitmask = 0x1F
-	)
-
-	var word uint32
-
-	payload := bytes.NewReader(data)
-	if err := binary.Read(payload, binary.BigEndian, &word); err != nil {
+// isKeyFrame returns whether payload starts a key frame, it holds a SPS or
+// an IDR slice, on its own, in a STAP-A or as the start of a FU-A
+func isKeyFrame(payload []byte) bool {
+	if len(payload) == 0 {
 		return false
 	}
 
-	naluType := (word >> 24) & naluTypeBitmask
-	if naluType == typeSTAPA && word&naluTypeBitmask == typeSPS {
-		return true
-	} else if naluType == typeSPS {
-		return true
+	isKeyFrameNALU := func(naluType byte) bool {
+		return naluType == typeSPS || naluType == typeIDR
 	}
 
-	return false
+	switch naluType := payload[0] & naluTypeBitmask; naluType {
+	case typeSTAPA:
+		offset := stapaHeaderSize
+		for offset+stapaNALULengthSize < len(payload) {
+			naluSize := int(binary.BigEndian.Uint16(payload[offset:]))
+			offset += stapaNALULengthSize
+			if offset >= len(payload) {
+				return false
+			}
+			if isKeyFrameNALU(payload[offset] & naluTypeBitmask) {
+				return true
+			}
+			offset += naluSize
+		}
+		return false
+	case typeFUA:
+		return len(payload) >= fuaHeaderSize && payload[1]&fuStartBitmask != 0 &&
+			isKeyFrameNALU(payload[1]&naluTypeBitmask)
+	default:
+		return isKeyFrameNALU(naluType)
+	}
 }
@@ -36,7 +36,27 @@ func TestIsKeyFrame(t *testing.T) {
 	}{
 		{
 			"When given a non-keyframe; it should return false",
-			[]byte{0x27, 0x90, 0x90},
+			[]byte{0x21, 0x90, 0x90},
+			false,
+		},
+		{
+			"When given an IDR; it should return true",
+			[]byte{0x25, 0x90, 0x90},
+			true,
+		},
+		{
+			"When given an IDR packetized with STAP-A after a SEI; it should return true",
+			[]byte{0x38, 0x00, 0x02, 0x06, 0x90, 0x00, 0x03, 0x25, 0x90, 0x90},
+			true,
+		},
+		{
+			"When given the start of an IDR packetized with FU-A; it should return true",
+			[]byte{0x3C, 0x85, 0x90},
+			true,
+		},
+		{
+			"When given the end of an IDR packetized with FU-A; it should return false",
+			[]byte{0x3C, 0x45, 0x90},
 			false,
 		},
 		{
@@ -63,65 +83,48 @@ func TestIsKeyFrame(t *testing.T) {
 func TestWriteRTP(t *testing.T) {
 	tests := []struct {
 		name        string
-		payload     []byte
+		payloads    [][]byte
 		hasKeyFrame bool
 		wantBytes   []byte
-		wantErr     error
-		reuseWriter bool
 	}{
 		{
 			"When given an empty payload; it should return nil",
-			[]byte{},
+			[][]byte{{}},
 			false,
 			[]byte{},
-			nil,
-			false,
 		},
 		{
 			"When no keyframe is defined; it should discard the packet",
-			[]byte{0x25, 0x90, 0x90},
+			[][]byte{{0x21, 0x90, 0x90}},
 			false,
 			[]byte{},
-			nil,
-			false,
 		},
 		{
 			"When a valid Single NAL Unit packet is given; it should unpack it without error",
-			[]byte{0x27, 0x90, 0x90},
+			[][]byte{{0x27, 0x90, 0x90}},
 			true,
 			[]byte{0x00, 0x00, 0x00, 0x01, 0x27, 0x90, 0x90},
-			nil,
-			false,
 		},
 		{
 			"When a valid STAP-A packet is given; it should unpack it without error",
-			[]byte{0x38, 0x00, 0x03, 0x27, 0x90, 0x90, 0x00, 0x05, 0x28, 0x90, 0x90, 0x90, 0x90},
+			[][]byte{{0x38, 0x00, 0x03, 0x27, 0x90, 0x90, 0x00, 0x05, 0x28, 0x90, 0x90, 0x90, 0x90}},
 			true,
 			[]byte{0x00, 0x00, 0x00, 0x01, 0x27, 0x90, 0x90, 0x00, 0x00, 0x00, 0x01, 0x28, 0x90, 0x90, 0x90, 0x90},
-			nil,
-			false,
 		},
 		{
-			"When a valid FU-A start packet is given; it should unpack it without error",
-			[]byte{0x3C, 0x85, 0x90, 0x90, 0x90},
-			true,
-			[]byte{},
-			nil,
+			"When valid FU-A start and end packets are given; it should unpack them without error",
+			[][]byte{{0x3C, 0x85, 0x90, 0x90, 0x90}, {0x3C, 0x45, 0x90, 0x90, 0x90}},
 			true,
+			[]byte{0x00, 0x00, 0x00, 0x01, 0x25, 0x90, 0x90, 0x90, 0x90, 0x90, 0x90},
 		},
 		{
-			"When a valid FU-A end packet is given; it should unpack it without error",
-			[]byte{0x3C, 0x45, 0x90, 0x90, 0x90},
-			true,
-			[]byte{0x00, 0x00, 0x00, 0x01, 0x25, 0x90, 0x90, 0x90, 0x90, 0x90, 0x90},
-			nil,
+			"When an IDR is given; it should be used as the first keyframe",
+			[][]byte{{0x65, 0x90, 0x90}},
 			false,
+			[]byte{0x00, 0x00, 0x00, 0x01, 0x65, 0x90, 0x90},
 		},
 	}
 
-	var reuseWriter *bytes.Buffer
-	var reuseH264Writer *H264Writer
-
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
@@ -130,26 +133,89 @@ func TestWriteRTP(t *testing.T) {
 				hasKeyFrame: tt.hasKeyFrame,
 				writer:      writer,
 			}
-			if reuseWriter != nil {
-				writer = reuseWriter
-			}
-			if reuseH264Writer != nil {
-				h264Writer = reuseH264Writer
-			}
 
-			assert.Equal(t, tt.wantErr, h264Writer.WriteRTP(&rtp.Packet{
-				Payload: tt.payload,
-			}))
-			assert.True(t, bytes.Equal(tt.wantBytes, writer.Bytes()))
-
-			if !tt.reuseWriter {
-				assert.Nil(t, h264Writer.Close())
-				reuseWriter = nil
-				reuseH264Writer = nil
-			} else {
-				reuseWriter = writer
-				reuseH264Writer = h264Writer
+			for i, payload := range tt.payloads {
+				assert.NoError(t, h264Writer.WriteRTP(&rtp.Packet{
+					Header:  rtp.Header{SequenceNumber: uint16(i), Marker: i == len(tt.payloads)-1},
+					Payload: payload,
+				}))
 			}
+			assert.True(t, bytes.Equal(tt.wantBytes, writer.Bytes()))
+			assert.Nil(t, h264Writer.Close())
 		})
 	}
 }
+
+func TestWriteRTP_Loss(t *testing.T) {
+	idr := []byte{0x65, 0x01}
+	nonIDR := []byte{0x41, 0x02}
+	fuaStart := []byte{0x5C, 0x81, 0x03}
+	fuaEnd := []byte{0x5C, 0x41, 0x04}
+
+	annexB := func(nalus ...[]byte) []byte {
+		var data []byte
+		for _, nalu := range nalus {
+			data = append(data, annexbNALUStartCode...)
+			data = append(data, nalu...)
+		}
+		return data
+	}
+	packet := func(seq uint16, timestamp uint32, marker bool, payload []byte) *rtp.Packet {
+		return &rtp.Packet{
+			Header:  rtp.Header{SequenceNumber: seq, Timestamp: timestamp, Marker: marker},
+			Payload: payload,
+		}
+	}
+
+	t.Run("Reorder", func(t *testing.T) {
+		writer := &bytes.Buffer{}
+		h264Writer := NewWith(writer)
+
+		for _, p := range []*rtp.Packet{
+			packet(65534, 0, true, idr),
+			packet(0, 1, false, fuaStart),
+			packet(65535, 1, false, nonIDR),
+			packet(1, 1, true, fuaEnd),
+			packet(65535, 1, false, nonIDR), // Duplicate
+		} {
+			assert.NoError(t, h264Writer.WriteRTP(p))
+		}
+
+		assert.Equal(t, annexB(idr, nonIDR, []byte{0x41, 0x03, 0x04}), writer.Bytes())
+		assert.Equal(t, uint64(0), h264Writer.DroppedFrames())
+		assert.Equal(t, uint64(0), h264Writer.LostPackets())
+	})
+
+	t.Run("Gap", func(t *testing.T) {
+		writer := &bytes.Buffer{}
+		h264Writer := NewWith(writer)
+
+		assert.NoError(t, h264Writer.WriteRTP(packet(0, 0, true, idr)))
+
+		// The end of a FU-A is lost, the next frames are dropped until an IDR
+		assert.NoError(t, h264Writer.WriteRTP(packet(1, 1, false, fuaStart)))
+		assert.NoError(t, h264Writer.WriteRTP(packet(3, 2, true, nonIDR)))
+		assert.NoError(t, h264Writer.WriteRTP(packet(4, 3, true, nonIDR)))
+		for seq := uint16(5); seq < 5+reorderWindow; seq++ {
+			assert.NoError(t, h264Writer.WriteRTP(packet(seq, 4, false, nonIDR)))
+		}
+		assert.Equal(t, annexB(idr), writer.Bytes())
+		assert.Equal(t, uint64(3), h264Writer.DroppedFrames())
+		assert.Equal(t, uint64(1), h264Writer.LostPackets())
+
+		writer.Reset()
+		assert.NoError(t, h264Writer.WriteRTP(packet(5+reorderWindow, 5, true, idr)))
+		assert.Equal(t, annexB(idr), writer.Bytes())
+		assert.Equal(t, uint64(4), h264Writer.DroppedFrames())
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		writer := &bytes.Buffer{}
+		h264Writer := NewWith(writer)
+
+		// The frame in progress is written if it is complete
+		assert.NoError(t, h264Writer.WriteRTP(packet(0, 0, false, idr)))
+		assert.NoError(t, h264Writer.Close())
+		assert.Equal(t, annexB(idr), writer.Bytes())
+	})
+}
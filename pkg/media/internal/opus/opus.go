@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package opus holds Opus helpers shared by the media packages
+package opus
+
+// PacketSamples returns the number of 48 kHz samples in an Opus packet,
+// from its TOC byte, see section 3.1 of RFC 6716. The first packet of a
+// multistream packet gives the duration of all streams.
+func PacketSamples(packet []byte) uint32 {
+	if len(packet) == 0 {
+		return 0
+	}
+
+	var frameSamples uint32
+	switch config := packet[0] >> 3; {
+	case config < 12: // SILK, 10, 20, 40 or 60 ms
+		frameSamples = [...]uint32{480, 960, 1920, 2880}[config%4]
+	case config < 16: // Hybrid, 10 or 20 ms
+		frameSamples = [...]uint32{480, 960}[config%2]
+	default: // CELT, 2.5, 5, 10 or 20 ms
+		frameSamples = [...]uint32{120, 240, 480, 960}[config%4]
+	}
+
+	switch packet[0] & 0x03 {
+	case 0:
+		return frameSamples
+	case 1, 2:
+		return 2 * frameSamples
+	default:
+		if len(packet) < 2 {
+			return 0
+		}
+		return uint32(packet[1]&0x3F) * frameSamples
+	}
+}
@@ -0,0 +1,28 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPacketSamples(t *testing.T) {
+	for _, test := range []struct {
+		packet  []byte
+		samples uint32
+	}{
+		{nil, 0},
+		{[]byte{0x00}, 480},        // SILK 10 ms
+		{[]byte{0x18}, 2880},       // SILK 60 ms
+		{[]byte{0x68}, 960},        // Hybrid 20 ms
+		{[]byte{0x80}, 120},        // CELT 2.5 ms
+		{[]byte{0xF9}, 1920},       // Two CELT 20 ms frames
+		{[]byte{0xFB, 0x03}, 2880}, // Three CELT 20 ms frames
+		{[]byte{0xFB}, 0},          // Missing frame count
+	} {
+		assert.Equal(t, test.samples, PacketSamples(test.packet))
+	}
+}
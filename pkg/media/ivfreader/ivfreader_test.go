@@ -168,3 +168,21 @@ func TestIVFReader_EOFWhenNoFramesLeft(t *testing.T) {
 
 	assert.Equal(io.EOF, err)
 }
+
+func TestSplitVP9Superframe(t *testing.T) {
+	assert := assert.New(t)
+
+	// Two frames, with two bytes per size
+	superframe := []byte{0x01, 0x02, 0x03, 0xC9, 0x02, 0x00, 0x01, 0x00, 0xC9}
+	assert.Equal([][]byte{{0x01, 0x02}, {0x03}}, SplitVP9Superframe(superframe))
+
+	// Not a superframe, or an invalid index
+	for _, frame := range [][]byte{
+		{},
+		{0x01, 0x02},
+		{0x01, 0x02, 0x03, 0xC9, 0x02, 0x00, 0x01, 0x00, 0xC8},
+		{0x01, 0x02, 0x03, 0xC9, 0x02, 0x00, 0x02, 0x00, 0xC9},
+	} {
+		assert.Equal([][]byte{frame}, SplitVP9Superframe(frame))
+	}
+}
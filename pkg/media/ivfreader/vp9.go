@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package ivfreader
+
+const (
+	vp9SuperframeMarkerMask = 0xE0
+	vp9SuperframeMarker     = 0xC0
+)
+
+// SplitVP9Superframe returns the frames packed in a VP9 superframe, as
+// defined in Annex B of the VP9 bitstream specification. A frame that is
+// not a superframe, or has an invalid index, is returned as is.
+func SplitVP9Superframe(frame []byte) [][]byte {
+	if len(frame) == 0 {
+		return [][]byte{frame}
+	}
+
+	marker := frame[len(frame)-1]
+	if marker&vp9SuperframeMarkerMask != vp9SuperframeMarker {
+		return [][]byte{frame}
+	}
+
+	bytesPerSize := int(marker>>3&0x3) + 1
+	numFrames := int(marker&0x7) + 1
+	indexSize := 2 + bytesPerSize*numFrames
+	if len(frame) < indexSize || frame[len(frame)-indexSize] != marker {
+		return [][]byte{frame}
+	}
+
+	frames := make([][]byte, 0, numFrames)
+	index := frame[len(frame)-indexSize+1:]
+	offset := 0
+	for f := 0; f < numFrames; f++ {
+		size := 0
+		for b := 0; b < bytesPerSize; b++ {
+			size |= int(index[f*bytesPerSize+b]) << (8 * b)
+		}
+		if offset+size > len(frame)-indexSize {
+			return [][]byte{frame}
+		}
+
+		frames = append(frames, frame[offset:offset+size])
+		offset += size
+	}
+	return frames
+}
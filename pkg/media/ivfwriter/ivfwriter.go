@@ -24,6 +24,7 @@ var (
 
 const (
 	mimeTypeVP8 = "video/VP8"
+	mimeTypeVP9 = "video/VP9"
 	mimeTypeAV1 = "video/AV1"
 
 	ivfFileHeaderSignature = "DKIF"
@@ -35,11 +36,16 @@ type IVFWriter struct {
 	count        uint64
 	seenKeyFrame bool
 
-	isVP8, isAV1 bool
+	isVP8, isVP9, isAV1 bool
 
 	// VP8
 	currentFrame []byte
 
+	// VP9
+	vp9Picture vp9Picture
+	vp9LastSeq uint16
+	vp9HasSeq  bool
+
 	// AV1
 	av1Frame frame.AV1
 }
@@ -75,7 +81,7 @@ func NewWith(out io.Writer, opts ...Option) (*IVFWriter, error) {
 		}
 	}
 
-	if !writer.isAV1 && !writer.isVP8 {
+	if !writer.isAV1 && !writer.isVP8 && !writer.isVP9 {
 		writer.isVP8 = true
 	}
 
@@ -94,6 +100,8 @@ func (i *IVFWriter) writeHeader() error {
 	// FOURCC
 	if i.isVP8 {
 		copy(header[8:], "VP80")
+	} else if i.isVP9 {
+		copy(header[8:], "VP90")
 	} else if i.isAV1 {
 		copy(header[8:], "AV01")
 	}
@@ -157,6 +165,8 @@ func (i *IVFWriter) WriteRTP(packet *rtp.Packet) error {
 			return err
 		}
 		i.currentFrame = nil
+	} else if i.isVP9 {
+		return i.writeVP9(packet)
 	} else if i.isAV1 {
 		av1Packet := &codecs.AV1Packet{}
 		if _, err := av1Packet.Unmarshal(packet.Payload); err != nil {
@@ -190,6 +200,13 @@ func (i *IVFWriter) Close() error {
 		i.ioWriter = nil
 	}()
 
+	// The last VP9 picture may have ended without the marker bit
+	if i.isVP9 {
+		if err := i.flushVP9Picture(); err != nil {
+			return err
+		}
+	}
+
 	if ws, ok := i.ioWriter.(io.WriteSeeker); ok {
 		// Update the framecount
 		if _, err := ws.Seek(24, 0); err != nil {
@@ -212,16 +229,18 @@ func (i *IVFWriter) Close() error {
 // An Option configures a SampleBuilder.
 type Option func(i *IVFWriter) error
 
-// WithCodec configures if IVFWriter is writing AV1 or VP8 packets to disk
+// WithCodec configures if IVFWriter is writing AV1, VP8 or VP9 packets to disk
 func WithCodec(mimeType string) Option {
 	return func(i *IVFWriter) error {
-		if i.isVP8 || i.isAV1 {
+		if i.isVP8 || i.isVP9 || i.isAV1 {
 			return errCodecAlreadySet
 		}
 
 		switch mimeType {
 		case mimeTypeVP8:
 			i.isVP8 = true
+		case mimeTypeVP9:
+			i.isVP9 = true
 		case mimeTypeAV1:
 			i.isAV1 = true
 		default:
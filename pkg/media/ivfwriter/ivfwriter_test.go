@@ -5,11 +5,15 @@ package ivfwriter
 
 import (
 	"bytes"
+	"errors"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/pion/rtp"
 	"github.com/pion/rtp/codecs"
+	"github.com/pion/webrtc/v4/pkg/media/ivfreader"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -299,3 +303,111 @@ func TestIVFWriter_AV1(t *testing.T) {
 		assert.NoError(t, writer.Close())
 	})
 }
+
+func TestIVFWriter_VP9(t *testing.T) {
+	readFrames := func(t *testing.T, buffer *bytes.Buffer) [][]byte {
+		reader, header, err := ivfreader.NewWith(buffer)
+		assert.NoError(t, err)
+		assert.Equal(t, "VP90", header.FourCC)
+
+		var frames [][]byte
+		for {
+			frame, _, err := reader.ParseNextFrame()
+			if errors.Is(err, io.EOF) {
+				return frames
+			}
+			assert.NoError(t, err)
+			frames = append(frames, frame)
+		}
+	}
+
+	write := func(t *testing.T, writer *IVFWriter, packets ...*rtp.Packet) {
+		for seq, packet := range packets {
+			if packet.SequenceNumber == 0 {
+				packet.SequenceNumber = uint16(seq)
+			}
+			assert.NoError(t, writer.WriteRTP(packet))
+		}
+		assert.NoError(t, writer.Close())
+	}
+
+	packet := func(timestamp uint32, marker bool, payload ...byte) *rtp.Packet {
+		return &rtp.Packet{Header: rtp.Header{Timestamp: timestamp, Marker: marker}, Payload: payload}
+	}
+
+	t.Run("NonFlexible", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		writer, err := NewWith(buffer, WithCodec(mimeTypeVP9))
+		assert.NoError(t, err)
+
+		write(t, writer,
+			// Inter frame before the first keyframe
+			packet(0, true, 0xEC, 0x00, 0x00, 0x00, 0x01),
+			// Keyframe over two packets
+			packet(1, false, 0xA8, 0x01, 0x00, 0x00, 0x02, 0x03),
+			packet(1, true, 0xA4, 0x01, 0x00, 0x00, 0x04),
+			packet(2, true, 0xEC, 0x02, 0x00, 0x00, 0x05),
+		)
+		assert.Equal(t, [][]byte{{0x02, 0x03, 0x04}, {0x05}}, readFrames(t, buffer))
+	})
+
+	t.Run("FlexibleSpatialLayers", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		writer, err := NewWith(buffer, WithCodec(mimeTypeVP9))
+		assert.NoError(t, err)
+
+		write(t, writer,
+			packet(0, false, 0xBC, 0x01, 0x00, 0x01, 0x02),
+			packet(0, true, 0xBC, 0x01, 0x03, 0x03),
+			packet(1, false, 0xFC, 0x02, 0x00, 0x02, 0x04),
+			packet(1, true, 0xFC, 0x02, 0x03, 0x02, 0x05, 0x06),
+		)
+
+		frames := readFrames(t, buffer)
+		assert.Equal(t, 2, len(frames))
+		assert.Equal(t, [][]byte{{0x01, 0x02}, {0x03}}, ivfreader.SplitVP9Superframe(frames[0]))
+		assert.Equal(t, [][]byte{{0x04}, {0x05, 0x06}}, ivfreader.SplitVP9Superframe(frames[1]))
+	})
+
+	t.Run("Loss", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		writer, err := NewWith(buffer, WithCodec(mimeTypeVP9))
+		assert.NoError(t, err)
+
+		afterLoss, keyFrame := packet(2, true, 0xEC, 0x03, 0x00, 0x00, 0x04), packet(3, true, 0xAC, 0x04, 0x00, 0x00, 0x05)
+		afterLoss.SequenceNumber, keyFrame.SequenceNumber = 2, 3
+		write(t, writer,
+			packet(0, true, 0xAC, 0x01, 0x00, 0x00, 0x01),
+			// The packet of the picture with timestamp 1 is lost
+			afterLoss,
+			keyFrame,
+		)
+		assert.Equal(t, [][]byte{{0x01}, {0x05}}, readFrames(t, buffer))
+	})
+	t.Run("NoMarkerAtEnd", func(t *testing.T) {
+		file, err := os.Create(filepath.Join(t.TempDir(), "out.ivf"))
+		assert.NoError(t, err)
+		writer, err := NewWith(file, WithCodec(mimeTypeVP9))
+		assert.NoError(t, err)
+
+		write(t, writer,
+			packet(0, true, 0xAC, 0x01, 0x00, 0x00, 0x01),
+			// The last picture is complete but has no marker bit
+			packet(1, false, 0xEC, 0x02, 0x00, 0x00, 0x02),
+		)
+
+		data, err := os.ReadFile(file.Name())
+		assert.NoError(t, err)
+		reader, header, err := ivfreader.NewWith(bytes.NewReader(data))
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(2), header.NumFrames)
+
+		for _, expected := range [][]byte{{0x01}, {0x02}} {
+			frame, _, err := reader.ParseNextFrame()
+			assert.NoError(t, err)
+			assert.Equal(t, expected, frame)
+		}
+		_, _, err = reader.ParseNextFrame()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+}
@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package ivfwriter
+
+import (
+	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
+)
+
+const (
+	vp9SuperframeMarker    = 0xC0
+	vp9SuperframeMaxFrames = 8
+)
+
+// vp9Picture is the VP9 picture being reassembled, it holds a frame for
+// each spatial layer
+type vp9Picture struct {
+	started   bool
+	timestamp uint32
+	keyFrame  bool
+	broken    bool
+
+	// frame is the layer frame being reassembled, frames the completed ones
+	frame  []byte
+	frames [][]byte
+}
+
+func (i *IVFWriter) writeVP9(packet *rtp.Packet) error {
+	vp9Packet := codecs.VP9Packet{}
+	if _, err := vp9Packet.Unmarshal(packet.Payload); err != nil {
+		return err
+	}
+
+	// A lost packet may hold a whole picture the next ones depend on, wait
+	// for the next keyframe
+	lost := i.vp9HasSeq && packet.SequenceNumber != i.vp9LastSeq+1
+	i.vp9LastSeq, i.vp9HasSeq = packet.SequenceNumber, true
+	if lost {
+		i.vp9Picture.broken = true
+	}
+
+	// The previous picture ended without the marker bit
+	if i.vp9Picture.started && packet.Timestamp != i.vp9Picture.timestamp {
+		if err := i.flushVP9Picture(); err != nil {
+			return err
+		}
+	}
+
+	picture := &i.vp9Picture
+	if !picture.started {
+		*picture = vp9Picture{started: true, timestamp: packet.Timestamp, broken: lost}
+	}
+
+	switch {
+	case vp9Packet.B:
+		if picture.frame != nil {
+			// The end of the previous layer frame is missing
+			picture.broken = true
+		}
+		if len(picture.frames) == 0 && picture.frame == nil && !vp9Packet.P {
+			picture.keyFrame = true
+		}
+		picture.frame = []byte{}
+	case picture.frame == nil:
+		// The start of the layer frame is missing
+		picture.broken = true
+	}
+
+	if picture.frame != nil {
+		picture.frame = append(picture.frame, vp9Packet.Payload...)
+		if vp9Packet.E {
+			picture.frames = append(picture.frames, picture.frame)
+			picture.frame = nil
+		}
+	}
+
+	if packet.Marker {
+		return i.flushVP9Picture()
+	}
+	return nil
+}
+
+// flushVP9Picture writes the picture being reassembled if it is complete
+// and decodable. Pictures with more than one layer frame are written as a
+// superframe.
+func (i *IVFWriter) flushVP9Picture() error {
+	picture := i.vp9Picture
+	i.vp9Picture = vp9Picture{}
+
+	switch {
+	case !picture.started:
+		return nil
+	case picture.broken || picture.frame != nil || len(picture.frames) == 0 ||
+		len(picture.frames) > vp9SuperframeMaxFrames:
+		i.seenKeyFrame = false
+		return nil
+	case !i.seenKeyFrame && !picture.keyFrame:
+		return nil
+	}
+
+	i.seenKeyFrame = true
+	if len(picture.frames) == 1 {
+		return i.writeFrame(picture.frames[0])
+	}
+	return i.writeFrame(vp9Superframe(picture.frames))
+}
+
+// vp9Superframe packs frames into a superframe, as defined in Annex B of
+// the VP9 bitstream specification
+func vp9Superframe(frames [][]byte) []byte {
+	maxSize := 0
+	totalSize := 0
+	for _, frame := range frames {
+		if len(frame) > maxSize {
+			maxSize = len(frame)
+		}
+		totalSize += len(frame)
+	}
+
+	bytesPerSize := 1
+	for bytesPerSize < 4 && maxSize >= 1<<(8*bytesPerSize) {
+		bytesPerSize++
+	}
+
+	marker := byte(vp9SuperframeMarker | (bytesPerSize-1)<<3 | (len(frames) - 1))
+	superframe := make([]byte, 0, totalSize+2+bytesPerSize*len(frames))
+	for _, frame := range frames {
+		superframe = append(superframe, frame...)
+	}
+
+	superframe = append(superframe, marker)
+	for _, frame := range frames {
+		for b := 0; b < bytesPerSize; b++ {
+			superframe = append(superframe, byte(len(frame)>>(8*b)))
+		}
+	}
+	return append(superframe, marker)
+}
@@ -18,13 +18,17 @@ const (
 
 	pageHeaderLen       = 27
 	idPagePayloadLength = 19
+
+	// The ID header of channel mapping families other than 0 also holds
+	// the stream counts and the channel mapping table
+	idPageChannelMappingLength = 21
 )
 
 var (
 	errNilStream                 = errors.New("stream is nil")
 	errBadIDPageSignature        = errors.New("bad header signature")
 	errBadIDPageType             = errors.New("wrong header, expected beginning of stream")
-	errBadIDPageLength           = errors.New("payload for id page must be 19 bytes")
+	errBadIDPageLength           = errors.New("payload for id page must be 19 bytes, or 21 bytes and the channel mapping table")
 	errBadIDPagePayloadSignature = errors.New("bad payload signature")
 	errShortPageHeader           = errors.New("not enough data for payload header")
 	errChecksumMismatch          = errors.New("expected and actual checksum do not match")
@@ -36,6 +40,19 @@ type OggReader struct {
 	bytesReadSuccesfully int64
 	checksumTable        *[256]uint32
 	doChecksum           bool
+
+	// State of ParseNextPacket. Offsets are relative to streamStart, the
+	// position of the stream when it was passed to NewWith. pageOffset is
+	// kept apart from bytesReadSuccesfully, which ResetReader reports.
+	streamStart     int64
+	pageOffset      int64
+	preSkip         uint16
+	packets         []*OggPacket
+	partialPacket   []byte
+	granule         uint64
+	granuleKnown    bool
+	dataOffset      int64
+	dataOffsetKnown bool
 }
 
 // OggHeader is the metadata from the first two pages
@@ -49,6 +66,11 @@ type OggHeader struct {
 	PreSkip    uint16
 	SampleRate uint32
 	Version    uint8
+
+	// Set for channel mapping families other than 0
+	StreamCount        uint8
+	CoupledStreamCount uint8
+	ChannelMapping     []uint8
 }
 
 // OggPageHeader is the metadata for a Page
@@ -83,6 +105,14 @@ func newWith(in io.Reader, doChecksum bool) (*OggReader, *OggHeader, error) {
 		doChecksum:    doChecksum,
 	}
 
+	// SeekToGranule needs the offset the stream starts at, the error is
+	// returned by SeekToGranule if the stream can't seek
+	if seeker, ok := in.(io.Seeker); ok {
+		if start, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			reader.streamStart = start
+		}
+	}
+
 	header, err := reader.readHeaders()
 	if err != nil {
 		return nil, nil, err
@@ -106,7 +136,7 @@ func (o *OggReader) readHeaders() (*OggHeader, error) {
 		return nil, errBadIDPageType
 	}
 
-	if len(payload) != idPagePayloadLength {
+	if len(payload) < idPagePayloadLength {
 		return nil, errBadIDPageLength
 	}
 
@@ -114,12 +144,23 @@ func (o *OggReader) readHeaders() (*OggHeader, error) {
 		return nil, errBadIDPagePayloadSignature
 	}
 
+	if payload[18] == 0 && len(payload) != idPagePayloadLength ||
+		payload[18] != 0 && len(payload) != idPageChannelMappingLength+int(payload[9]) {
+		return nil, errBadIDPageLength
+	}
+
 	header.Version = payload[8]
 	header.Channels = payload[9]
 	header.PreSkip = binary.LittleEndian.Uint16(payload[10:12])
 	header.SampleRate = binary.LittleEndian.Uint32(payload[12:16])
 	header.OutputGain = binary.LittleEndian.Uint16(payload[16:18])
 	header.ChannelMap = payload[18]
+	if header.ChannelMap != 0 {
+		header.StreamCount = payload[19]
+		header.CoupledStreamCount = payload[20]
+		header.ChannelMapping = payload[idPageChannelMappingLength:]
+	}
+	o.preSkip = header.PreSkip
 
 	return header, nil
 }
@@ -127,13 +168,19 @@ func (o *OggReader) readHeaders() (*OggHeader, error) {
 // ParseNextPage reads from stream and returns Ogg page payload, header,
 // and an error if there is incomplete page data.
 func (o *OggReader) ParseNextPage() ([]byte, *OggPageHeader, error) {
+	payload, pageHeader, _, err := o.parsePage()
+	return payload, pageHeader, err
+}
+
+// parsePage reads the next page and also returns its segment table
+func (o *OggReader) parsePage() ([]byte, *OggPageHeader, []byte, error) {
 	h := make([]byte, pageHeaderLen)
 
 	n, err := io.ReadFull(o.stream, h)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	} else if n < len(h) {
-		return nil, nil, errShortPageHeader
+		return nil, nil, nil, errShortPageHeader
 	}
 
 	pageHeader := &OggPageHeader{
@@ -149,7 +196,7 @@ func (o *OggReader) ParseNextPage() ([]byte, *OggPageHeader, error) {
 
 	sizeBuffer := make([]byte, pageHeader.segmentsCount)
 	if _, err = io.ReadFull(o.stream, sizeBuffer); err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	payloadSize := 0
@@ -159,7 +206,7 @@ func (o *OggReader) ParseNextPage() ([]byte, *OggPageHeader, error) {
 
 	payload := make([]byte, payloadSize)
 	if _, err = io.ReadFull(o.stream, payload); err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	if o.doChecksum {
@@ -185,11 +232,12 @@ func (o *OggReader) ParseNextPage() ([]byte, *OggPageHeader, error) {
 		}
 
 		if binary.LittleEndian.Uint32(h[22:22+4]) != checksum {
-			return nil, nil, errChecksumMismatch
+			return nil, nil, nil, errChecksumMismatch
 		}
 	}
 
-	return payload, pageHeader, nil
+	o.pageOffset += int64(len(h) + len(sizeBuffer) + len(payload))
+	return payload, pageHeader, sizeBuffer, nil
 }
 
 // ResetReader resets the internal stream of OggReader. This is useful
@@ -0,0 +1,242 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package oggreader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/media/internal/opus"
+)
+
+const (
+	pageHeaderTypeContinuedPacket = 0x01
+	pageHeaderTypeEndOfStream     = 0x04
+
+	commentPageSignature = "OpusTags"
+
+	// noGranulePosition is set on pages where no packet ends
+	noGranulePosition = ^uint64(0)
+
+	// opusSampleRate is the rate of granule positions, whatever the input
+	// sample rate of the stream
+	opusSampleRate = 48000
+
+	maxLacingValue = 255
+)
+
+var errStreamNotSeekable = errors.New("stream does not implement io.Seeker")
+
+// OggPacket is an Opus packet reassembled from the pages of a stream
+type OggPacket struct {
+	Data []byte
+
+	// GranulePosition of the first sample of the packet, in 48 kHz samples
+	// including the pre-skip
+	GranulePosition uint64
+
+	// Samples is the number of 48 kHz samples in the packet, from its TOC
+	// byte. It is trimmed for the last packet of the stream if the final
+	// granule position ends it early.
+	Samples uint32
+
+	// Duration of the Samples
+	Duration time.Duration
+
+	// Timestamp is the presentation time of the first sample. It is
+	// negative for the samples discarded as pre-skip.
+	Timestamp time.Duration
+}
+
+// ParseNextPacket reads from stream and returns the next Opus packet, the
+// comment header is skipped. Packets are reassembled from the lacing values
+// of the pages, so a page may hold several packets and a packet may span
+// several pages. ParseNextPacket must not be mixed with calls to
+// ParseNextPage.
+func (o *OggReader) ParseNextPacket() (*OggPacket, error) {
+	for len(o.packets) == 0 {
+		if err := o.readPackets(); err != nil {
+			return nil, err
+		}
+	}
+
+	packet := o.packets[0]
+	o.packets = o.packets[1:]
+	return packet, nil
+}
+
+// readPackets reads a page and queues the packets that end in it
+func (o *OggReader) readPackets() error {
+	pageOffset := o.pageOffset
+	payload, pageHeader, segments, err := o.parsePage()
+	if err != nil {
+		return err
+	}
+
+	// A continuation is dropped if the start of the packet was not read,
+	// after seeking
+	if pageHeader.headerType&pageHeaderTypeContinuedPacket == 0 {
+		o.partialPacket = nil
+	} else if o.partialPacket == nil {
+		for len(segments) != 0 {
+			segment := segments[0]
+			payload, segments = payload[segment:], segments[1:]
+			if segment < maxLacingValue {
+				break
+			}
+		}
+	}
+
+	var packets [][]byte
+	for _, segment := range segments {
+		o.partialPacket = append(o.partialPacket, payload[:segment]...)
+		payload = payload[segment:]
+
+		// A lacing value below 255 ends the packet
+		if segment < maxLacingValue {
+			packets = append(packets, o.partialPacket)
+			o.partialPacket = nil
+		}
+	}
+
+	if !o.dataOffsetKnown && len(packets) != 0 {
+		o.dataOffsetKnown = true
+		o.dataOffset = pageOffset
+		if bytes.HasPrefix(packets[0], []byte(commentPageSignature)) {
+			packets = packets[1:]
+			o.dataOffset = o.pageOffset
+		}
+	}
+	if len(packets) == 0 {
+		return nil
+	}
+
+	o.queuePackets(packets, pageHeader)
+	return nil
+}
+
+// queuePackets computes the granule position of packets from the one of
+// their page, and queues them
+func (o *OggReader) queuePackets(packets [][]byte, pageHeader *OggPageHeader) {
+	samples := make([]uint32, len(packets))
+	total := uint64(0)
+	for i, packet := range packets {
+		samples[i] = opus.PacketSamples(packet)
+		total += uint64(samples[i])
+	}
+
+	switch {
+	case pageHeader.GranulePosition == noGranulePosition:
+	case !o.granuleKnown:
+		// The first page gives the granule position the stream starts at
+		o.granule = 0
+		if pageHeader.GranulePosition > total {
+			o.granule = pageHeader.GranulePosition - total
+		}
+		o.granuleKnown = true
+	case pageHeader.headerType&pageHeaderTypeEndOfStream != 0 && o.granule+total > pageHeader.GranulePosition:
+		// The last page may end before its last packet, trim it
+		trim := o.granule + total - pageHeader.GranulePosition
+		last := len(samples) - 1
+		if trim > uint64(samples[last]) {
+			trim = uint64(samples[last])
+		}
+		samples[last] -= uint32(trim)
+	}
+
+	for i, packet := range packets {
+		o.packets = append(o.packets, &OggPacket{
+			Data:            packet,
+			GranulePosition: o.granule,
+			Samples:         samples[i],
+			Duration:        samplesToDuration(int64(samples[i])),
+			Timestamp:       samplesToDuration(int64(o.granule) - int64(o.preSkip)),
+		})
+		o.granule += uint64(samples[i])
+	}
+}
+
+// SeekToGranule moves the stream so that the next packet returned by
+// ParseNextPacket is the one holding the sample at granule, a position in
+// 48 kHz samples including the pre-skip. The stream must implement
+// io.Seeker, and may start after other data: positions are relative to the
+// offset it had when it was passed to NewWith. Seeking past the end of the
+// stream returns io.EOF.
+func (o *OggReader) SeekToGranule(granule uint64) error {
+	seeker, ok := o.stream.(io.Seeker)
+	if !ok {
+		return errStreamNotSeekable
+	}
+
+	// The comment header has to be read to know where the audio starts
+	for !o.dataOffsetKnown {
+		if err := o.readPackets(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := seeker.Seek(o.streamStart+o.dataOffset, io.SeekStart); err != nil {
+		return err
+	}
+
+	// Find the last page before granule where no packet is continued
+	offset := o.dataOffset
+	startOffset, startGranule, startGranuleKnown := o.dataOffset, uint64(0), false
+	lastGranule, lastGranuleKnown := uint64(0), false
+	for {
+		h := make([]byte, pageHeaderLen)
+		if _, err := io.ReadFull(o.stream, h); err != nil {
+			break
+		}
+		segments := make([]byte, h[26])
+		if _, err := io.ReadFull(o.stream, segments); err != nil {
+			break
+		}
+		payloadSize := int64(0)
+		for _, segment := range segments {
+			payloadSize += int64(segment)
+		}
+
+		if h[5]&pageHeaderTypeContinuedPacket == 0 {
+			startOffset, startGranule, startGranuleKnown = offset, lastGranule, lastGranuleKnown
+		}
+		if pageGranule := binary.LittleEndian.Uint64(h[6:14]); pageGranule != noGranulePosition {
+			if pageGranule > granule {
+				break
+			}
+			lastGranule, lastGranuleKnown = pageGranule, true
+		}
+
+		offset += int64(len(h)+len(segments)) + payloadSize
+		if _, err := seeker.Seek(payloadSize, io.SeekCurrent); err != nil {
+			return err
+		}
+	}
+
+	if _, err := seeker.Seek(o.streamStart+startOffset, io.SeekStart); err != nil {
+		return err
+	}
+	o.pageOffset = startOffset
+	o.granule, o.granuleKnown = startGranule, startGranuleKnown
+	o.packets, o.partialPacket = nil, nil
+
+	// Drop the packets that end before granule
+	for {
+		packet, err := o.ParseNextPacket()
+		if err != nil {
+			return err
+		}
+		if packet.GranulePosition+uint64(packet.Samples) > granule {
+			o.packets = append([]*OggPacket{packet}, o.packets...)
+			return nil
+		}
+	}
+}
+
+func samplesToDuration(samples int64) time.Duration {
+	return time.Duration(samples) * time.Second / opusSampleRate
+}
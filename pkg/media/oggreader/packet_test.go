@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package oggreader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// buildPage returns an Ogg page with a valid checksum
+func buildPage(headerType uint8, granulePosition uint64, index uint32, segments []byte, payload []byte) []byte {
+	page := make([]byte, pageHeaderLen, pageHeaderLen+len(segments)+len(payload))
+	copy(page, pageHeaderSignature)
+	page[5] = headerType
+	binary.LittleEndian.PutUint64(page[6:], granulePosition)
+	binary.LittleEndian.PutUint32(page[14:], 0x1234)
+	binary.LittleEndian.PutUint32(page[18:], index)
+	page[26] = uint8(len(segments))
+	page = append(page, segments...)
+	page = append(page, payload...)
+
+	table := generateChecksumTable()
+	var checksum uint32
+	for _, v := range page {
+		checksum = (checksum << 8) ^ table[byte(checksum>>24)^v]
+	}
+	binary.LittleEndian.PutUint32(page[22:], checksum)
+	return page
+}
+
+func opusPacket(toc byte, size int) []byte {
+	packet := make([]byte, size)
+	packet[0] = toc
+	return packet
+}
+
+var (
+	// 20 ms CELT, two 20 ms CELT frames, 60 ms SILK spanning two pages and
+	// 20 ms CELT trimmed to 5 ms by the last granule position
+	testPackets = [][]byte{
+		opusPacket(0xF8, 10),
+		opusPacket(0xF9, 20),
+		opusPacket(0x18, 300),
+		opusPacket(0xF8, 10),
+	}
+	testPreSkip = uint16(312)
+)
+
+func buildOpusStream() []byte {
+	idHeader := []byte("OpusHead")
+	idHeader = append(idHeader, 1, 2)
+	idHeader = binary.LittleEndian.AppendUint16(idHeader, testPreSkip)
+	idHeader = binary.LittleEndian.AppendUint32(idHeader, 48000)
+	idHeader = append(idHeader, 0, 0, 0)
+	commentHeader := []byte("OpusTags\x00\x00\x00\x00\x00\x00\x00\x00")
+
+	return bytes.Join([][]byte{
+		buildPage(pageHeaderTypeBeginningOfStream, 0, 0, []byte{19}, idHeader),
+		buildPage(0, 0, 1, []byte{16}, commentHeader),
+		buildPage(0, 2880, 2, []byte{10, 20, 255}, bytes.Join(testPackets[:3], nil)[:285]),
+		buildPage(pageHeaderTypeContinuedPacket|pageHeaderTypeEndOfStream, 6000, 3, []byte{45, 10},
+			bytes.Join(testPackets[2:], nil)[255:]),
+	}, nil)
+}
+
+func TestOggReader_ParseNextPacket(t *testing.T) {
+	reader, header, err := NewWith(bytes.NewReader(buildOpusStream()))
+	assert.NoError(t, err)
+	assert.Equal(t, testPreSkip, header.PreSkip)
+
+	for i, expected := range []struct {
+		granulePosition uint64
+		samples         uint32
+	}{
+		{0, 960},
+		{960, 1920},
+		{2880, 2880},
+		{5760, 240},
+	} {
+		packet, err := reader.ParseNextPacket()
+		assert.NoError(t, err)
+		assert.Equal(t, testPackets[i], packet.Data)
+		assert.Equal(t, expected.granulePosition, packet.GranulePosition)
+		assert.Equal(t, expected.samples, packet.Samples)
+		assert.Equal(t, time.Duration(expected.samples)*time.Second/48000, packet.Duration)
+		assert.Equal(t, (time.Duration(expected.granulePosition)-time.Duration(testPreSkip))*time.Second/48000, packet.Timestamp)
+	}
+
+	_, err = reader.ParseNextPacket()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestOggReader_SeekToGranule(t *testing.T) {
+	reader, _, err := NewWith(bytes.NewReader(buildOpusStream()))
+	assert.NoError(t, err)
+
+	for _, test := range []struct {
+		granule uint64
+		packet  int
+	}{
+		{3000, 2},
+		{100, 0},
+		{5760, 3},
+		{960, 1},
+	} {
+		assert.NoError(t, reader.SeekToGranule(test.granule))
+		packet, err := reader.ParseNextPacket()
+		assert.NoError(t, err)
+		assert.Equal(t, testPackets[test.packet], packet.Data, test.granule)
+	}
+
+	assert.ErrorIs(t, reader.SeekToGranule(6000), io.EOF)
+
+	// The stream may start after other data
+	prefixed := bytes.NewReader(append([]byte("prefix"), buildOpusStream()...))
+	_, err = prefixed.Seek(int64(len("prefix")), io.SeekStart)
+	assert.NoError(t, err)
+	reader, _, err = NewWith(prefixed)
+	assert.NoError(t, err)
+	assert.NoError(t, reader.SeekToGranule(3000))
+	packet, err := reader.ParseNextPacket()
+	assert.NoError(t, err)
+	assert.Equal(t, testPackets[2], packet.Data)
+
+	reader, _, err = NewWith(struct{ io.Reader }{bytes.NewReader(buildOpusStream())})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, reader.SeekToGranule(0), errStreamNotSeekable)
+}
@@ -9,10 +9,13 @@ import (
 	"errors"
 	"io"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/pion/randutil"
 	"github.com/pion/rtp"
 	"github.com/pion/rtp/codecs"
+	"github.com/pion/webrtc/v4/pkg/media/internal/opus"
 )
 
 const (
@@ -23,34 +26,66 @@ const (
 	idPageSignature                    = "OpusHead"
 	commentPageSignature               = "OpusTags"
 	pageHeaderSignature                = "OggS"
+
+	// channelMappingFamilyRTP is one stream, mono or stereo
+	channelMappingFamilyRTP = 0
+	// channelMappingFamilyVorbis describes up to 8 channels in several
+	// streams, as negotiated for multiopus
+	channelMappingFamilyVorbis = 1
+
+	maxLacingValue  = 255
+	maxPageSegments = 255
+
+	// maxTimestampGap is the largest gap filled, 10 seconds at 48 kHz.
+	// Packets further ahead or behind are a discontinuity of the sender.
+	maxTimestampGap = 10 * 48000
 )
 
+// Frame sizes of the silence frames inserted in gaps, in 48 kHz samples
+var silenceFrameSizes = [...]uint32{960, 480, 240, 120} //nolint:gochecknoglobals
+
 var (
-	errFileNotOpened    = errors.New("file not opened")
-	errInvalidNilPacket = errors.New("invalid nil packet")
+	errFileNotOpened          = errors.New("file not opened")
+	errInvalidNilPacket       = errors.New("invalid nil packet")
+	errInvalidChannelMapping  = errors.New("invalid channel mapping for the channel count")
+	errChannelMappingRequired = errors.New("more than two channels require a channel mapping")
+	errInvalidFmtpLine        = errors.New("invalid multiopus fmtp line")
 )
 
 // OggWriter is used to take RTP packets and write them to an OGG on disk
 type OggWriter struct {
-	stream                  io.Writer
-	fd                      *os.File
-	sampleRate              uint32
-	channelCount            uint16
-	serial                  uint32
-	pageIndex               uint32
-	checksumTable           *[256]uint32
-	previousGranulePosition uint64
-	previousTimestamp       uint32
-	lastPayloadSize         int
+	stream        io.Writer
+	fd            *os.File
+	sampleRate    uint32
+	channelCount  uint16
+	serial        uint32
+	pageIndex     uint32
+	checksumTable *[256]uint32
+	preSkip       uint16
+	fillGaps      bool
+
+	channelMappingFamily uint8
+	streamCount          uint8
+	coupledStreamCount   uint8
+	channelMapping       []uint8
+
+	// granulePosition is the number of samples written, at 48 kHz
+	granulePosition uint64
+	// nextTimestamp is the RTP timestamp expected for the next packet
+	nextTimestamp    uint32
+	nextTimestampSet bool
+	lastPagePackets  [][]byte
+	lastPageGranule  uint64
+	lastPageSize     int
 }
 
 // New builds a new OGG Opus writer
-func New(fileName string, sampleRate uint32, channelCount uint16) (*OggWriter, error) {
+func New(fileName string, sampleRate uint32, channelCount uint16, opts ...Option) (*OggWriter, error) {
 	f, err := os.Create(fileName) //nolint:gosec
 	if err != nil {
 		return nil, err
 	}
-	writer, err := NewWith(f, sampleRate, channelCount)
+	writer, err := NewWith(f, sampleRate, channelCount, opts...)
 	if err != nil {
 		return nil, f.Close()
 	}
@@ -59,7 +94,7 @@ func New(fileName string, sampleRate uint32, channelCount uint16) (*OggWriter, e
 }
 
 // NewWith initialize a new OGG Opus writer with an io.Writer output
-func NewWith(out io.Writer, sampleRate uint32, channelCount uint16) (*OggWriter, error) {
+func NewWith(out io.Writer, sampleRate uint32, channelCount uint16, opts ...Option) (*OggWriter, error) {
 	if out == nil {
 		return nil, errFileNotOpened
 	}
@@ -70,12 +105,28 @@ func NewWith(out io.Writer, sampleRate uint32, channelCount uint16) (*OggWriter,
 		channelCount:  channelCount,
 		serial:        randutil.NewMathRandomGenerator().Uint32(),
 		checksumTable: generateChecksumTable(),
+		preSkip:       defaultPreSkip,
+		fillGaps:      true,
+	}
 
-		// Timestamp and Granule MUST start from 1
-		// Only headers can have 0 values
-		previousTimestamp:       1,
-		previousGranulePosition: 1,
+	for _, o := range opts {
+		if err := o(writer); err != nil {
+			return nil, err
+		}
 	}
+
+	if writer.channelMappingFamily == channelMappingFamilyRTP {
+		if channelCount > 2 {
+			return nil, errChannelMappingRequired
+		}
+		writer.streamCount = 1
+		if channelCount == 2 {
+			writer.coupledStreamCount = 1
+		}
+	} else if len(writer.channelMapping) != int(channelCount) {
+		return nil, errInvalidChannelMapping
+	}
+
 	if err := writer.writeHeaders(); err != nil {
 		return nil, err
 	}
@@ -110,21 +161,26 @@ func (i *OggWriter) writeHeaders() error {
 	// ID Header
 	oggIDHeader := make([]byte, 19)
 
-	copy(oggIDHeader[0:], idPageSignature)                          // Magic Signature 'OpusHead'
-	oggIDHeader[8] = 1                                              // Version
-	oggIDHeader[9] = uint8(i.channelCount)                          // Channel count
-	binary.LittleEndian.PutUint16(oggIDHeader[10:], defaultPreSkip) // pre-skip
-	binary.LittleEndian.PutUint32(oggIDHeader[12:], i.sampleRate)   // original sample rate, any valid sample e.g 48000
-	binary.LittleEndian.PutUint16(oggIDHeader[16:], 0)              // output gain
-	oggIDHeader[18] = 0                                             // channel map 0 = one stream: mono or stereo
+	copy(oggIDHeader[0:], idPageSignature)                        // Magic Signature 'OpusHead'
+	oggIDHeader[8] = 1                                            // Version
+	oggIDHeader[9] = uint8(i.channelCount)                        // Channel count
+	binary.LittleEndian.PutUint16(oggIDHeader[10:], i.preSkip)    // pre-skip
+	binary.LittleEndian.PutUint32(oggIDHeader[12:], i.sampleRate) // original sample rate, any valid sample e.g 48000
+	binary.LittleEndian.PutUint16(oggIDHeader[16:], 0)            // output gain
+	oggIDHeader[18] = i.channelMappingFamily                      // channel map 0 = one stream: mono or stereo
+
+	// Channel mapping family 1 is followed by the stream counts and the
+	// channel mapping table
+	if i.channelMappingFamily != channelMappingFamilyRTP {
+		oggIDHeader = append(oggIDHeader, i.streamCount, i.coupledStreamCount)
+		oggIDHeader = append(oggIDHeader, i.channelMapping...)
+	}
 
 	// Reference: https://tools.ietf.org/html/rfc7845.html#page-6
 	// RFC specifies that the ID Header page should have a granule position of 0 and a Header Type set to 2 (StartOfStream)
-	data := i.createPage(oggIDHeader, pageHeaderTypeBeginningOfStream, 0, i.pageIndex)
-	if err := i.writeToStream(data); err != nil {
+	if err := i.writePage([][]byte{oggIDHeader}, pageHeaderTypeBeginningOfStream, 0); err != nil {
 		return err
 	}
-	i.pageIndex++
 
 	// Comment Header
 	oggCommentHeader := make([]byte, 21)
@@ -134,13 +190,7 @@ func (i *OggWriter) writeHeaders() error {
 	binary.LittleEndian.PutUint32(oggCommentHeader[17:], 0) // User Comment List Length
 
 	// RFC specifies that the page where the CommentHeader completes should have a granule position of 0
-	data = i.createPage(oggCommentHeader, pageHeaderTypeContinuationOfStream, 0, i.pageIndex)
-	if err := i.writeToStream(data); err != nil {
-		return err
-	}
-	i.pageIndex++
-
-	return nil
+	return i.writePage([][]byte{oggCommentHeader}, pageHeaderTypeContinuationOfStream, 0)
 }
 
 const (
@@ -148,10 +198,17 @@ const (
 )
 
 func (i *OggWriter) createPage(payload []uint8, headerType uint8, granulePos uint64, pageIndex uint32) []byte {
-	i.lastPayloadSize = len(payload)
-	nSegments := (len(payload) / 255) + 1 // A segment can be at most 255 bytes long.
+	return i.createPageWithPackets([][]byte{payload}, headerType, granulePos, pageIndex)
+}
 
-	page := make([]byte, pageHeaderSize+i.lastPayloadSize+nSegments)
+func (i *OggWriter) createPageWithPackets(packets [][]byte, headerType uint8, granulePos uint64, pageIndex uint32) []byte {
+	nSegments, payloadSize := 0, 0
+	for _, packet := range packets {
+		nSegments += (len(packet) / maxLacingValue) + 1 // A segment can be at most 255 bytes long.
+		payloadSize += len(packet)
+	}
+
+	page := make([]byte, pageHeaderSize, pageHeaderSize+nSegments+payloadSize)
 
 	copy(page[0:], pageHeaderSignature)                 // page headers starts with 'OggS'
 	page[4] = 0                                         // Version
@@ -162,14 +219,19 @@ func (i *OggWriter) createPage(payload []uint8, headerType uint8, granulePos uin
 	page[26] = uint8(nSegments)                         // Number of segments in page.
 
 	// Filling segment table with the lacing values.
-	// First (nSegments - 1) values will always be 255.
-	for i := 0; i < nSegments-1; i++ {
-		page[pageHeaderSize+i] = 255
+	// For each packet, all values but the last will be 255, and the last
+	// value will be the remainder.
+	for _, packet := range packets {
+		for j := 0; j < len(packet)/maxLacingValue; j++ {
+			page = append(page, maxLacingValue)
+		}
+		page = append(page, uint8(len(packet)%maxLacingValue))
 	}
-	// The last value will be the remainder.
-	page[pageHeaderSize+nSegments-1] = uint8(len(payload) % 255)
 
-	copy(page[pageHeaderSize+nSegments:], payload) // Payload goes after the segment table, so at pageHeaderSize+nSegments.
+	// Payload goes after the segment table
+	for _, packet := range packets {
+		page = append(page, packet...)
+	}
 
 	var checksum uint32
 	for index := range page {
@@ -181,6 +243,21 @@ func (i *OggWriter) createPage(payload []uint8, headerType uint8, granulePos uin
 	return page
 }
 
+// writePage writes packets in a new page, and keeps them to mark the page
+// as the EOS on Close
+func (i *OggWriter) writePage(packets [][]byte, headerType uint8, granulePos uint64) error {
+	data := i.createPageWithPackets(packets, headerType, granulePos, i.pageIndex)
+	if err := i.writeToStream(data); err != nil {
+		return err
+	}
+
+	i.pageIndex++
+	i.lastPagePackets = packets
+	i.lastPageGranule = granulePos
+	i.lastPageSize = len(data)
+	return nil
+}
+
 // WriteRTP adds a new packet and writes the appropriate headers for it
 func (i *OggWriter) WriteRTP(packet *rtp.Packet) error {
 	if packet == nil {
@@ -197,17 +274,88 @@ func (i *OggWriter) WriteRTP(packet *rtp.Packet) error {
 	}
 
 	payload := opusPacket.Payload[0:]
+	samples := opus.PacketSamples(payload)
 
-	// Should be equivalent to sampleRate * duration
-	if i.previousTimestamp != 1 {
-		increment := packet.Timestamp - i.previousTimestamp
-		i.previousGranulePosition += uint64(increment)
+	// The RTP clock rate of Opus is always 48 kHz, like granule positions.
+	// A small gap in timestamps comes from DTX or lost packets, a packet
+	// behind the expected timestamp arrived late and is dropped.
+	if !i.nextTimestampSet {
+		i.nextTimestamp, i.nextTimestampSet = packet.Timestamp, true
 	}
-	i.previousTimestamp = packet.Timestamp
+	switch gap := int32(packet.Timestamp - i.nextTimestamp); {
+	case gap < 0 && gap >= -maxTimestampGap:
+		return nil
+	case gap >= 0 && gap <= maxTimestampGap:
+		if err := i.skipGap(uint32(gap)); err != nil {
+			return err
+		}
+	}
+	// Larger jumps continue the granule position from the new timestamp
+	i.nextTimestamp = packet.Timestamp + samples
 
-	data := i.createPage(payload, pageHeaderTypeContinuationOfStream, i.previousGranulePosition, i.pageIndex)
-	i.pageIndex++
-	return i.writeToStream(data)
+	// The granule position of a page is the one of the last sample of its
+	// last packet
+	i.granulePosition += uint64(samples)
+	return i.writePage([][]byte{payload}, pageHeaderTypeContinuationOfStream, i.granulePosition)
+}
+
+// skipGap fills a gap of samples with silence frames, or advances the
+// granule position over it if fillGaps is unset
+func (i *OggWriter) skipGap(samples uint32) error {
+	if !i.fillGaps {
+		i.granulePosition += uint64(samples)
+		return nil
+	}
+
+	var packets [][]byte
+	for _, frameSize := range silenceFrameSizes {
+		for ; samples >= frameSize; samples -= frameSize {
+			packets = append(packets, i.silenceFrame(frameSize))
+			i.granulePosition += uint64(frameSize)
+
+			if len(packets) == maxPageSegments {
+				if err := i.writePage(packets, pageHeaderTypeContinuationOfStream, i.granulePosition); err != nil {
+					return err
+				}
+				packets = nil
+			}
+		}
+	}
+
+	// The remainder is shorter than any Opus frame, the next packet starts
+	// slightly early
+	if len(packets) == 0 {
+		return nil
+	}
+	return i.writePage(packets, pageHeaderTypeContinuationOfStream, i.granulePosition)
+}
+
+// silenceFrame returns an Opus packet of frameSize samples made of zero
+// length CELT frames, that decoders conceal as silence. A multistream
+// packet holds one frame per stream, all but the last self-delimited, see
+// appendix B of RFC 6716.
+func (i *OggWriter) silenceFrame(frameSize uint32) []byte {
+	config := byte(31)
+	for _, size := range silenceFrameSizes {
+		if size == frameSize {
+			break
+		}
+		config--
+	}
+
+	var packet []byte
+	for stream := uint8(0); stream < i.streamCount; stream++ {
+		toc := config << 3
+		if stream < i.coupledStreamCount {
+			toc |= 0x04 // stereo
+		}
+		packet = append(packet, toc)
+
+		if stream != i.streamCount-1 {
+			packet = append(packet, 0) // frame length
+		}
+	}
+	return packet
 }
 
 // Close stops the recording
@@ -228,17 +376,11 @@ func (i *OggWriter) Close() error {
 	}
 
 	// Seek back one page, we need to update the header and generate new CRC
-	pageOffset, err := i.fd.Seek(-1*int64(i.lastPayloadSize+pageHeaderSize+1), 2)
-	if err != nil {
-		return err
-	}
-
-	payload := make([]byte, i.lastPayloadSize)
-	if _, err := i.fd.ReadAt(payload, pageOffset+pageHeaderSize+1); err != nil {
+	if _, err := i.fd.Seek(-1*int64(i.lastPageSize), io.SeekEnd); err != nil {
 		return err
 	}
 
-	data := i.createPage(payload, pageHeaderTypeEndOfStream, i.previousGranulePosition, i.pageIndex-1)
+	data := i.createPageWithPackets(i.lastPagePackets, pageHeaderTypeEndOfStream, i.lastPageGranule, i.pageIndex-1)
 	if err := i.writeToStream(data); err != nil {
 		return err
 	}
@@ -276,3 +418,84 @@ func generateChecksumTable() *[256]uint32 {
 	}
 	return &table
 }
+
+// An Option configures an OggWriter.
+type Option func(i *OggWriter) error
+
+// WithPreSkip sets the number of samples at 48 kHz that decoders discard
+// at the start of the stream, 3840 by default
+func WithPreSkip(preSkip uint16) Option {
+	return func(i *OggWriter) error {
+		i.preSkip = preSkip
+		return nil
+	}
+}
+
+// WithGapFilling sets whether gaps in RTP timestamps, from DTX or lost
+// packets, are filled with silence frames. If not, only the granule
+// position is advanced over them. Gaps longer than 10 seconds are never
+// filled. Enabled by default.
+func WithGapFilling(enabled bool) Option {
+	return func(i *OggWriter) error {
+		i.fillGaps = enabled
+		return nil
+	}
+}
+
+// WithChannelMapping writes a multichannel stream with channel mapping
+// family 1, for multiopus tracks. channelMapping gives the decoded channel
+// of each output channel, see section 5.1.1 of RFC 7845.
+func WithChannelMapping(streamCount, coupledStreamCount uint8, channelMapping []uint8) Option {
+	return func(i *OggWriter) error {
+		if streamCount == 0 || coupledStreamCount > streamCount ||
+			int(streamCount)+int(coupledStreamCount) > maxLacingValue || len(channelMapping) > 8 {
+			return errInvalidChannelMapping
+		}
+		for _, channel := range channelMapping {
+			if channel != 255 && channel >= streamCount+coupledStreamCount {
+				return errInvalidChannelMapping
+			}
+		}
+
+		i.channelMappingFamily = channelMappingFamilyVorbis
+		i.streamCount = streamCount
+		i.coupledStreamCount = coupledStreamCount
+		i.channelMapping = channelMapping
+		return nil
+	}
+}
+
+// WithMultiopusFmtpLine writes a multichannel stream with the channel
+// mapping of the fmtp line of a multiopus track, e.g.
+// "channel_mapping=0,4,1,2,3,5;num_streams=4;coupled_streams=2"
+func WithMultiopusFmtpLine(fmtpLine string) Option {
+	return func(i *OggWriter) error {
+		var (
+			streamCount, coupledStreamCount uint64
+			channelMapping                  []uint8
+			err                             error
+		)
+		for _, parameter := range strings.Split(fmtpLine, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(parameter), "=")
+			switch strings.ToLower(key) {
+			case "num_streams":
+				streamCount, err = strconv.ParseUint(value, 10, 8)
+			case "coupled_streams":
+				coupledStreamCount, err = strconv.ParseUint(value, 10, 8)
+			case "channel_mapping":
+				for _, channel := range strings.Split(value, ",") {
+					var c uint64
+					if c, err = strconv.ParseUint(channel, 10, 8); err != nil {
+						break
+					}
+					channelMapping = append(channelMapping, uint8(c))
+				}
+			}
+			if err != nil {
+				return errInvalidFmtpLine
+			}
+		}
+
+		return WithChannelMapping(uint8(streamCount), uint8(coupledStreamCount), channelMapping)(i)
+	}
+}
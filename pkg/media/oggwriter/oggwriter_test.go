@@ -5,10 +5,14 @@ package oggwriter
 
 import (
 	"bytes"
+	"errors"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/pkg/media/oggreader"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -163,3 +167,138 @@ func TestOggWriter_LargePayload(t *testing.T) {
 	data := writer.createPage(rawPkt, pageHeaderTypeContinuationOfStream, 0, 1)
 	assert.Equal(t, uint8(4), data[26])
 }
+
+func opusRTPPacket(timestamp uint32, payload ...byte) *rtp.Packet {
+	return &rtp.Packet{Header: rtp.Header{Timestamp: timestamp}, Payload: payload}
+}
+
+func TestOggWriter_Gaps(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		fillGaps bool
+		packets  [][]byte
+		granules []uint64
+	}{
+		{
+			name:     "FillGaps",
+			fillGaps: true,
+			packets:  [][]byte{{0xF8, 0x01}, {0xF8, 0x02}, {0xF8}, {0xF8}, {0xF0}, {0xF8, 0x03}, {0xF8, 0x05}, {0xF8, 0x06}},
+			granules: []uint64{0, 960, 1920, 4320, 5280, 6240, 7200},
+		},
+		{
+			name:     "AdvanceGranule",
+			fillGaps: false,
+			packets:  [][]byte{{0xF8, 0x01}, {0xF8, 0x02}, {0xF8, 0x03}, {0xF8, 0x05}, {0xF8, 0x06}},
+			granules: []uint64{0, 960, 1920, 5280, 6240, 7200},
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			writer, err := NewWith(buffer, 48000, 1, WithGapFilling(test.fillGaps), WithPreSkip(312))
+			assert.NoError(t, err)
+
+			// A DTX gap of 2400 samples, a packet that arrives late and is
+			// dropped, then a jump of a minute that isn't filled
+			timestamp := uint32(1<<32 - 960)
+			for n, offset := range []uint32{0, 960, 4320, 3360, 5280 + 60*48000, 6240 + 60*48000} {
+				assert.NoError(t, writer.WriteRTP(opusRTPPacket(timestamp+offset, 0xF8, byte(n+1))))
+			}
+
+			reader, header, err := oggreader.NewWith(bytes.NewReader(buffer.Bytes()))
+			assert.NoError(t, err)
+			assert.Equal(t, uint16(312), header.PreSkip)
+
+			var granules []uint64
+			for {
+				_, pageHeader, err := reader.ParseNextPage()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				assert.NoError(t, err)
+				granules = append(granules, pageHeader.GranulePosition)
+			}
+			assert.Equal(t, test.granules, granules)
+
+			reader, _, err = oggreader.NewWith(bytes.NewReader(buffer.Bytes()))
+			assert.NoError(t, err)
+			for _, expected := range test.packets {
+				packet, err := reader.ParseNextPacket()
+				assert.NoError(t, err)
+				assert.Equal(t, expected, packet.Data)
+			}
+		})
+	}
+}
+
+func TestOggWriter_Multichannel(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer, err := NewWith(buffer, 48000, 6,
+		WithMultiopusFmtpLine("minptime=10;useinbandfec=1; channel_mapping=0,4,1,2,3,5;num_streams=4;coupled_streams=2"))
+	assert.NoError(t, err)
+
+	// The ID header page has the channel mapping table
+	idHeader := buffer.Bytes()[pageHeaderSize+1:]
+	assert.Equal(t, []byte{6}, idHeader[9:10])
+	assert.Equal(t, []byte{1, 4, 2, 0, 4, 1, 2, 3, 5}, idHeader[18:27])
+
+	assert.Equal(t, []byte{0xFC, 0x00, 0xFC, 0x00, 0xF8, 0x00, 0xF8}, writer.silenceFrame(960))
+	assert.Equal(t, []byte{0xE4, 0x00, 0xE4, 0x00, 0xE0, 0x00, 0xE0}, writer.silenceFrame(120))
+
+	reader, header, err := oggreader.NewWith(bytes.NewReader(buffer.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(6), header.Channels)
+	assert.Equal(t, uint8(1), header.ChannelMap)
+	assert.Equal(t, uint8(4), header.StreamCount)
+	assert.Equal(t, uint8(2), header.CoupledStreamCount)
+	assert.Equal(t, []uint8{0, 4, 1, 2, 3, 5}, header.ChannelMapping)
+	_, _, err = reader.ParseNextPage()
+	assert.NoError(t, err)
+}
+
+func TestOggWriter_ChannelMappingErrors(t *testing.T) {
+	for _, test := range []struct {
+		channelCount uint16
+		opts         []Option
+		err          error
+	}{
+		{6, nil, errChannelMappingRequired},
+		{2, []Option{WithChannelMapping(2, 0, []uint8{0, 1})}, nil},
+		{3, []Option{WithChannelMapping(2, 0, []uint8{0, 1})}, errInvalidChannelMapping},
+		{2, []Option{WithChannelMapping(1, 0, []uint8{0, 1})}, errInvalidChannelMapping},
+		{2, []Option{WithChannelMapping(1, 2, []uint8{0, 1})}, errInvalidChannelMapping},
+		{2, []Option{WithMultiopusFmtpLine("num_streams=x")}, errInvalidFmtpLine},
+		{2, []Option{WithMultiopusFmtpLine("num_streams=1;coupled_streams=1;channel_mapping=0,1")}, nil},
+	} {
+		_, err := NewWith(&bytes.Buffer{}, 48000, test.channelCount, test.opts...)
+		assert.ErrorIs(t, err, test.err)
+	}
+}
+
+func TestOggWriter_CloseFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test.ogg")
+	writer, err := New(fileName, 48000, 2)
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.WriteRTP(opusRTPPacket(0, 0xFC, 0x01)))
+	assert.NoError(t, writer.WriteRTP(opusRTPPacket(2880, 0xFC, 0x02)))
+	assert.NoError(t, writer.Close())
+
+	data, err := os.ReadFile(fileName) //nolint:gosec
+	assert.NoError(t, err)
+
+	// The last page holds the last packet, with the EOS flag
+	reader, _, err := oggreader.NewWith(bytes.NewReader(data))
+	assert.NoError(t, err)
+	var packets [][]byte
+	for {
+		packet, err := reader.ParseNextPacket()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err)
+		packets = append(packets, packet.Data)
+	}
+	assert.Equal(t, [][]byte{{0xFC, 0x01}, {0xFC}, {0xFC}, {0xFC, 0x02}}, packets)
+	assert.Equal(t, byte(pageHeaderTypeEndOfStream), data[len(data)-pageHeaderSize-1-2+5])
+}
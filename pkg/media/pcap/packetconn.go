@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package pcap
+
+import (
+	"net"
+)
+
+// packetConn writes the STUN and DTLS datagrams of a net.PacketConn to a
+// Writer
+type packetConn struct {
+	net.PacketConn
+	writer *Writer
+}
+
+// NewPacketConn returns a net.PacketConn that writes the STUN and DTLS
+// datagrams sent and received on conn to w. SRTP and SRTCP datagrams are
+// not written, as they are encrypted; use a Tap to capture them after
+// decryption. To capture a PeerConnection, pass the returned conn to
+// webrtc.NewICEUDPMux and use it with SettingEngine.SetICEUDPMux.
+func NewPacketConn(conn net.PacketConn, w *Writer) (net.PacketConn, error) {
+	if conn == nil || w == nil {
+		return nil, errNilStream
+	}
+	return &packetConn{PacketConn: conn, writer: w}, nil
+}
+
+func (c *packetConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	n, addr, err := c.PacketConn.ReadFrom(p)
+	if err == nil {
+		c.write(p[:n], addr, c.LocalAddr())
+	}
+	return n, addr, err
+}
+
+func (c *packetConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	n, err := c.PacketConn.WriteTo(p, addr)
+	if err == nil {
+		c.write(p[:n], c.LocalAddr(), addr)
+	}
+	return n, err
+}
+
+func (c *packetConn) write(payload []byte, src, dst net.Addr) {
+	srcUDP, srcOK := src.(*net.UDPAddr)
+	dstUDP, dstOK := dst.(*net.UDPAddr)
+	if !srcOK || !dstOK || !isSTUNOrDTLS(payload) {
+		return
+	}
+
+	// An unspecified local address is written as loopback
+	if srcUDP.IP.IsUnspecified() {
+		srcUDP = &net.UDPAddr{IP: loopbackOf(srcUDP.IP), Port: srcUDP.Port}
+	}
+	if dstUDP.IP.IsUnspecified() {
+		dstUDP = &net.UDPAddr{IP: loopbackOf(dstUDP.IP), Port: dstUDP.Port}
+	}
+
+	// The capture must not disturb the session, write errors are dropped
+	_ = c.writer.WritePacket(Packet{Source: srcUDP, Destination: dstUDP, Payload: append([]byte{}, payload...)})
+}
+
+// isSTUNOrDTLS demultiplexes datagrams by their first byte, see RFC 7983
+func isSTUNOrDTLS(payload []byte) bool {
+	return len(payload) != 0 && (payload[0] <= 3 || (payload[0] >= 20 && payload[0] <= 63))
+}
+
+func loopbackOf(ip net.IP) net.IP {
+	if ip.To4() != nil {
+		return net.IPv4(127, 0, 0, 1)
+	}
+	return net.IPv6loopback
+}
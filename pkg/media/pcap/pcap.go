@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package pcap implements a pcapng writer for decrypted RTP and RTCP, with
+// synthetic IP and UDP headers, and a pcap and pcapng reader that extracts
+// RTP streams for replay.
+//
+// The Tap captures a PeerConnection through its interceptors. Received
+// packets are captured as the application reads them: streams that are
+// never read are missing, and the capture times are the read times, not the
+// arrival times.
+package pcap
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// Link types, see https://www.tcpdump.org/linktypes.html
+const (
+	linkTypeNull     = 0
+	linkTypeEthernet = 1
+	linkTypeRaw      = 101
+	linkTypeLinuxSLL = 113
+	linkTypeIPv4     = 228
+	linkTypeIPv6     = 229
+)
+
+const (
+	ipv4HeaderLen = 20
+	ipv6HeaderLen = 40
+	udpHeaderLen  = 8
+
+	ipProtocolUDP = 17
+	defaultTTL    = 64
+)
+
+var (
+	errNilStream           = errors.New("stream is nil")
+	errUnknownFormat       = errors.New("not a pcap or pcapng file")
+	errMalformed           = errors.New("malformed pcap")
+	errUnknownInterface    = errors.New("packet refers to an unknown interface")
+	errPacketTooLarge      = errors.New("packet too large for a UDP datagram")
+	errNotUDP              = errors.New("packet is not a UDP datagram")
+	errUnsupportedLinkType = errors.New("unsupported link type")
+	errMissingAddress      = errors.New("packet has no source or destination address")
+)
+
+// Packet is a UDP datagram read from or written to a capture
+type Packet struct {
+	// Timestamp of the capture
+	Timestamp time.Time
+
+	// Source and Destination of the datagram
+	Source      *net.UDPAddr
+	Destination *net.UDPAddr
+
+	// Payload of the datagram
+	Payload []byte
+}
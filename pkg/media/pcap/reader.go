@@ -0,0 +1,355 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package pcap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// Magic numbers of pcap files, with microsecond or nanosecond timestamps
+const (
+	pcapMagicMicroseconds = 0xA1B2C3D4
+	pcapMagicNanoseconds  = 0xA1B23C4D
+
+	pcapHeaderLen       = 24
+	pcapRecordHeaderLen = 16
+	blockHeaderLen      = 8
+
+	// maxBlockLen bounds the allocations for lengths read from the file
+	maxBlockLen = 1 << 24
+
+	ethernetHeaderLen = 14
+	linuxSLLHeaderLen = 16
+	etherTypeVLAN     = 0x8100
+	etherTypeIPv4     = 0x0800
+	etherTypeIPv6     = 0x86DD
+)
+
+// captureInterface is the link type and timestamp resolution of packets
+type captureInterface struct {
+	linkType uint16
+	// units of timestamps per second
+	resolution uint64
+}
+
+// Reader reads the UDP datagrams of a pcap or pcapng file. Packets that are
+// not UDP over IPv4 or IPv6 are skipped.
+type Reader struct {
+	reader    *bufio.Reader
+	byteOrder binary.ByteOrder
+	isPCAPNG  bool
+
+	// interfaces of the current pcapng section, or the only one of a pcap
+	// file
+	interfaces []captureInterface
+}
+
+// NewReader returns a new Reader, the format of the file is detected from
+// its first bytes
+func NewReader(r io.Reader) (*Reader, error) {
+	if r == nil {
+		return nil, errNilStream
+	}
+
+	reader := &Reader{reader: bufio.NewReader(r)}
+	magic, err := reader.reader.Peek(4)
+	if err != nil {
+		return nil, errUnknownFormat
+	}
+
+	if binary.LittleEndian.Uint32(magic) == blockTypeSectionHeader {
+		reader.isPCAPNG = true
+		return reader, nil
+	}
+	if err := reader.readPCAPHeader(); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// Next returns the next UDP datagram of the file, or io.EOF at its end
+func (r *Reader) Next() (*Packet, error) {
+	for {
+		var (
+			data      []byte
+			timestamp time.Time
+			linkType  uint16
+			err       error
+		)
+		if r.isPCAPNG {
+			data, timestamp, linkType, err = r.readPCAPNGPacket()
+		} else {
+			data, timestamp, linkType, err = r.readPCAPPacket()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ipPacket, err := stripLinkLayer(data, linkType)
+		if err != nil {
+			continue
+		}
+		packet, err := unmarshalIPUDP(ipPacket)
+		if err != nil {
+			continue
+		}
+
+		packet.Timestamp = timestamp
+		return packet, nil
+	}
+}
+
+// NextRTP returns the next RTP packet of the file with the given SSRC, and
+// its capture time. RTCP packets multiplexed on the same port, and packets
+// that do not parse as RTP, are skipped.
+func (r *Reader) NextRTP(ssrc uint32) (*rtp.Packet, time.Time, error) {
+	for {
+		packet, err := r.Next()
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		if !isRTP(packet.Payload) {
+			continue
+		}
+
+		rtpPacket := &rtp.Packet{}
+		if err := rtpPacket.Unmarshal(packet.Payload); err != nil || rtpPacket.SSRC != ssrc {
+			continue
+		}
+		return rtpPacket, packet.Timestamp, nil
+	}
+}
+
+// isRTP returns whether payload looks like an RTP packet, and not RTCP or
+// STUN and DTLS, see RFC 5761 and RFC 7983
+func isRTP(payload []byte) bool {
+	if len(payload) < 12 || payload[0]>>6 != 2 {
+		return false
+	}
+	payloadType := payload[1] & 0x7F
+	return payloadType < 64 || payloadType > 95
+}
+
+func (r *Reader) readPCAPHeader() error {
+	header := make([]byte, pcapHeaderLen)
+	if _, err := io.ReadFull(r.reader, header); err != nil {
+		return errUnknownFormat
+	}
+
+	var resolution uint64
+	for _, byteOrder := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		switch byteOrder.Uint32(header) {
+		case pcapMagicMicroseconds:
+			resolution = uint64(time.Second / time.Microsecond)
+		case pcapMagicNanoseconds:
+			resolution = uint64(time.Second)
+		default:
+			continue
+		}
+
+		r.byteOrder = byteOrder
+		r.interfaces = []captureInterface{{
+			linkType:   uint16(byteOrder.Uint32(header[20:])),
+			resolution: resolution,
+		}}
+		return nil
+	}
+	return errUnknownFormat
+}
+
+func (r *Reader) readPCAPPacket() ([]byte, time.Time, uint16, error) {
+	header := make([]byte, pcapRecordHeaderLen)
+	if _, err := io.ReadFull(r.reader, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, time.Time{}, 0, errMalformed
+		}
+		return nil, time.Time{}, 0, err
+	}
+
+	capturedLen := r.byteOrder.Uint32(header[8:])
+	if capturedLen > maxBlockLen {
+		return nil, time.Time{}, 0, errMalformed
+	}
+	data := make([]byte, capturedLen)
+	if _, err := io.ReadFull(r.reader, data); err != nil {
+		return nil, time.Time{}, 0, errMalformed
+	}
+
+	captureInterface := r.interfaces[0]
+	seconds, fraction := uint64(r.byteOrder.Uint32(header[0:])), uint64(r.byteOrder.Uint32(header[4:]))
+	timestamp := time.Unix(int64(seconds), int64(fraction*uint64(time.Second)/captureInterface.resolution))
+	return data, timestamp, captureInterface.linkType, nil
+}
+
+// readPCAPNGPacket reads blocks until a packet block, and returns its data
+func (r *Reader) readPCAPNGPacket() ([]byte, time.Time, uint16, error) {
+	for {
+		blockType, body, err := r.readBlock()
+		if err != nil {
+			return nil, time.Time{}, 0, err
+		}
+
+		switch blockType {
+		case blockTypeSectionHeader:
+			r.interfaces = nil
+		case blockTypeInterfaceDescription:
+			if err := r.readInterfaceDescription(body); err != nil {
+				return nil, time.Time{}, 0, err
+			}
+		case blockTypeEnhancedPacket:
+			if len(body) < 20 {
+				return nil, time.Time{}, 0, errMalformed
+			}
+			interfaceID := r.byteOrder.Uint32(body[0:])
+			if interfaceID >= uint32(len(r.interfaces)) {
+				return nil, time.Time{}, 0, errUnknownInterface
+			}
+			capturedLen := r.byteOrder.Uint32(body[12:])
+			if capturedLen > uint32(len(body)-20) {
+				return nil, time.Time{}, 0, errMalformed
+			}
+
+			captureInterface := r.interfaces[interfaceID]
+			units := uint64(r.byteOrder.Uint32(body[4:]))<<32 | uint64(r.byteOrder.Uint32(body[8:]))
+			return body[20 : 20+capturedLen], captureInterface.timestamp(units), captureInterface.linkType, nil
+		case blockTypeSimplePacket:
+			// Simple packets have no timestamp, and come from the first
+			// interface
+			if len(body) < 4 || len(r.interfaces) == 0 {
+				return nil, time.Time{}, 0, errMalformed
+			}
+			capturedLen := r.byteOrder.Uint32(body[0:])
+			if capturedLen > uint32(len(body)-4) {
+				capturedLen = uint32(len(body) - 4)
+			}
+			return body[4 : 4+capturedLen], time.Time{}, r.interfaces[0].linkType, nil
+		}
+	}
+}
+
+// readBlock reads a pcapng block and returns its type and body. The byte
+// order is read from section header blocks.
+func (r *Reader) readBlock() (uint32, []byte, error) {
+	header := make([]byte, blockHeaderLen)
+	if _, err := io.ReadFull(r.reader, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil, errMalformed
+		}
+		return 0, nil, err
+	}
+
+	// The block type of a section header is a palindrome, its byte order
+	// magic gives the byte order of the section
+	blockType := binary.LittleEndian.Uint32(header)
+	if blockType == blockTypeSectionHeader {
+		magic, err := r.reader.Peek(4)
+		if err != nil {
+			return 0, nil, errMalformed
+		}
+		switch {
+		case binary.LittleEndian.Uint32(magic) == byteOrderMagic:
+			r.byteOrder = binary.LittleEndian
+		case binary.BigEndian.Uint32(magic) == byteOrderMagic:
+			r.byteOrder = binary.BigEndian
+		default:
+			return 0, nil, errMalformed
+		}
+	} else if r.byteOrder == nil {
+		return 0, nil, errMalformed
+	}
+
+	blockType = r.byteOrder.Uint32(header)
+	length := r.byteOrder.Uint32(header[4:])
+	if length < blockHeaderLen+4 || length%4 != 0 || length > maxBlockLen {
+		return 0, nil, errMalformed
+	}
+
+	// The body is followed by the repeated length
+	body := make([]byte, length-blockHeaderLen)
+	if _, err := io.ReadFull(r.reader, body); err != nil {
+		return 0, nil, errMalformed
+	}
+	return blockType, body[:len(body)-4], nil
+}
+
+func (r *Reader) readInterfaceDescription(body []byte) error {
+	if len(body) < 8 {
+		return errMalformed
+	}
+
+	captureInterface := captureInterface{
+		linkType:   r.byteOrder.Uint16(body[0:]),
+		resolution: uint64(time.Second / time.Microsecond),
+	}
+
+	// Options are a code, a length and a value padded to 32 bits
+	for options := body[8:]; len(options) >= 4; {
+		code, length := r.byteOrder.Uint16(options[0:]), int(r.byteOrder.Uint16(options[2:]))
+		if code == optionEndOfOpt || len(options) < 4+length {
+			break
+		}
+
+		if code == optionIfTSResol && length == 1 {
+			tsResol := options[4]
+			switch {
+			case tsResol&0x80 != 0 && tsResol&0x7F < 64:
+				captureInterface.resolution = 1 << (tsResol & 0x7F)
+			case tsResol < 20:
+				captureInterface.resolution = uint64(math.Pow10(int(tsResol)))
+			}
+		}
+		options = options[4+(length+3)/4*4:]
+	}
+
+	r.interfaces = append(r.interfaces, captureInterface)
+	return nil
+}
+
+// timestamp converts a timestamp in units of the interface resolution
+func (i captureInterface) timestamp(units uint64) time.Time {
+	if i.resolution == 0 {
+		return time.Time{}
+	}
+	seconds, fraction := units/i.resolution, units%i.resolution
+	return time.Unix(int64(seconds), int64(float64(fraction)*float64(time.Second)/float64(i.resolution)))
+}
+
+// stripLinkLayer returns the IP packet of a link layer frame
+func stripLinkLayer(data []byte, linkType uint16) ([]byte, error) {
+	switch linkType {
+	case linkTypeRaw, linkTypeIPv4, linkTypeIPv6:
+		return data, nil
+	case linkTypeNull:
+		if len(data) < 4 {
+			return nil, errMalformed
+		}
+		return data[4:], nil
+	case linkTypeEthernet:
+		if len(data) < ethernetHeaderLen {
+			return nil, errMalformed
+		}
+		etherType, offset := binary.BigEndian.Uint16(data[12:]), ethernetHeaderLen
+		if etherType == etherTypeVLAN && len(data) >= offset+4 {
+			etherType, offset = binary.BigEndian.Uint16(data[16:]), offset+4
+		}
+		if etherType != etherTypeIPv4 && etherType != etherTypeIPv6 {
+			return nil, errNotUDP
+		}
+		return data[offset:], nil
+	case linkTypeLinuxSLL:
+		if len(data) < linuxSLLHeaderLen {
+			return nil, errMalformed
+		}
+		return data[linuxSLLHeaderLen:], nil
+	default:
+		return nil, errUnsupportedLinkType
+	}
+}
@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package pcap
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testSource      = &net.UDPAddr{IP: net.IPv4(192, 168, 1, 2), Port: 50000} //nolint:gochecknoglobals
+	testDestination = &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 40000}    //nolint:gochecknoglobals
+)
+
+// buildPCAP returns a classic pcap file of Ethernet frames, with
+// nanosecond timestamps one millisecond apart
+func buildPCAP(t *testing.T, byteOrder binary.ByteOrder, frames ...[]byte) []byte {
+	t.Helper()
+
+	file := make([]byte, pcapHeaderLen)
+	byteOrder.PutUint32(file[0:], pcapMagicNanoseconds)
+	byteOrder.PutUint16(file[4:], 2)
+	byteOrder.PutUint16(file[6:], 4)
+	byteOrder.PutUint32(file[16:], 0xFFFF)
+	byteOrder.PutUint32(file[20:], linkTypeEthernet)
+
+	for i, frame := range frames {
+		record := make([]byte, pcapRecordHeaderLen)
+		byteOrder.PutUint32(record[0:], 1700000000)
+		byteOrder.PutUint32(record[4:], uint32(i)*uint32(time.Millisecond))
+		byteOrder.PutUint32(record[8:], uint32(len(frame)))
+		byteOrder.PutUint32(record[12:], uint32(len(frame)))
+		file = append(file, record...)
+		file = append(file, frame...)
+	}
+	return file
+}
+
+// ethernetFrame returns payload in a UDP datagram, in an Ethernet frame
+func ethernetFrame(t *testing.T, payload []byte) []byte {
+	t.Helper()
+
+	packet, err := marshalIPUDP(testSource, testDestination, payload)
+	assert.NoError(t, err)
+
+	frame := make([]byte, ethernetHeaderLen)
+	binary.BigEndian.PutUint16(frame[12:], etherTypeIPv4)
+	return append(frame, packet...)
+}
+
+func rtpPayload(t *testing.T, ssrc uint32, sequenceNumber uint16) []byte {
+	t.Helper()
+
+	payload, err := (&rtp.Packet{
+		Header:  rtp.Header{Version: 2, PayloadType: 96, SequenceNumber: sequenceNumber, SSRC: ssrc},
+		Payload: []byte{0xAA},
+	}).Marshal()
+	assert.NoError(t, err)
+	return payload
+}
+
+func buildRTPCapture(t *testing.T, byteOrder binary.ByteOrder) []byte {
+	t.Helper()
+
+	rtcpPayload, err := rtcp.Marshal([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: 1}})
+	assert.NoError(t, err)
+
+	arp := make([]byte, ethernetHeaderLen+28)
+	binary.BigEndian.PutUint16(arp[12:], 0x0806)
+
+	return buildPCAP(t, byteOrder,
+		ethernetFrame(t, rtpPayload(t, 1, 10)),
+		arp,
+		ethernetFrame(t, rtpPayload(t, 2, 20)),
+		ethernetFrame(t, rtcpPayload),
+		ethernetFrame(t, []byte{0x00, 0x01, 0x00, 0x00}),
+		ethernetFrame(t, rtpPayload(t, 1, 11)),
+	)
+}
+
+func TestReader_PCAP(t *testing.T) {
+	for _, byteOrder := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		reader, err := NewReader(bytes.NewReader(buildRTPCapture(t, byteOrder)))
+		assert.NoError(t, err)
+
+		// The ARP frame is skipped
+		var packets []*Packet
+		for {
+			packet, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			packets = append(packets, packet)
+		}
+		assert.Len(t, packets, 5)
+		assert.True(t, testSource.IP.Equal(packets[0].Source.IP))
+		assert.Equal(t, testDestination.Port, packets[0].Destination.Port)
+		assert.Equal(t, time.Unix(1700000000, int64(2*time.Millisecond)), packets[1].Timestamp)
+
+		reader, err = NewReader(bytes.NewReader(buildRTPCapture(t, byteOrder)))
+		assert.NoError(t, err)
+		for _, expected := range []struct {
+			sequenceNumber uint16
+			timestamp      time.Duration
+		}{
+			{10, 0},
+			{11, 5 * time.Millisecond},
+		} {
+			packet, timestamp, err := reader.NextRTP(1)
+			assert.NoError(t, err)
+			assert.Equal(t, expected.sequenceNumber, packet.SequenceNumber)
+			assert.Equal(t, time.Unix(1700000000, int64(expected.timestamp)), timestamp)
+		}
+		_, _, err = reader.NextRTP(1)
+		assert.ErrorIs(t, err, io.EOF)
+	}
+}
+
+func TestReader_Errors(t *testing.T) {
+	_, err := NewReader(nil)
+	assert.ErrorIs(t, err, errNilStream)
+	_, err = NewReader(bytes.NewReader([]byte("not a capture file at all")))
+	assert.ErrorIs(t, err, errUnknownFormat)
+
+	// A truncated record
+	file := buildPCAP(t, binary.LittleEndian, ethernetFrame(t, []byte{0x01}))
+	reader, err := NewReader(bytes.NewReader(file[:len(file)-1]))
+	assert.NoError(t, err)
+	_, err = reader.Next()
+	assert.ErrorIs(t, err, errMalformed)
+
+	// A pcapng packet before any interface description
+	buffer := &bytes.Buffer{}
+	_, err = NewWriter(buffer)
+	assert.NoError(t, err)
+	block := marshalBlock(blockTypeEnhancedPacket, make([]byte, 20))
+	reader, err = NewReader(bytes.NewReader(append(buffer.Bytes()[:28], block...)))
+	assert.NoError(t, err)
+	_, err = reader.Next()
+	assert.ErrorIs(t, err, errUnknownInterface)
+}
+
+type testRTPWriter struct {
+	packets []*rtp.Packet
+	times   []time.Time
+}
+
+func (w *testRTPWriter) WriteRTP(packet *rtp.Packet) error {
+	w.packets = append(w.packets, packet)
+	w.times = append(w.times, time.Now())
+	return nil
+}
+
+func TestReplay(t *testing.T) {
+	reader, err := NewReader(bytes.NewReader(buildRTPCapture(t, binary.LittleEndian)))
+	assert.NoError(t, err)
+
+	writer := &testRTPWriter{}
+	assert.NoError(t, Replay(context.Background(), reader, 1, writer))
+	assert.Len(t, writer.packets, 2)
+	assert.GreaterOrEqual(t, writer.times[1].Sub(writer.times[0]), 5*time.Millisecond)
+
+	reader, err = NewReader(bytes.NewReader(buildRTPCapture(t, binary.LittleEndian)))
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, Replay(ctx, reader, 2, &testRTPWriter{}), context.Canceled)
+}
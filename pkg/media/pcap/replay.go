@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package pcap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// RTPWriter writes RTP packets, it is implemented by
+// webrtc.TrackLocalStaticRTP
+type RTPWriter interface {
+	WriteRTP(packet *rtp.Packet) error
+}
+
+// Replay writes the RTP packets of reader with the given SSRC to writer,
+// for instance a webrtc.TrackLocalStaticRTP, with the timing of their
+// capture. Replay returns at the end of the file, or when ctx is done.
+func Replay(ctx context.Context, reader *Reader, ssrc uint32, writer RTPWriter) error {
+	var start, firstTimestamp time.Time
+	for {
+		packet, timestamp, err := reader.NextRTP(ssrc)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		// Timestamps are relative to the first packet
+		if start.IsZero() {
+			start, firstTimestamp = time.Now(), timestamp
+		}
+		if err := sleepUntil(ctx, start.Add(timestamp.Sub(firstTimestamp))); err != nil {
+			return err
+		}
+
+		if err := writer.WriteRTP(packet); err != nil {
+			return err
+		}
+	}
+}
+
+// sleepUntil waits until t, or returns the error of ctx
+func sleepUntil(ctx context.Context, t time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
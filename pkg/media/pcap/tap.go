@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package pcap
+
+import (
+	"net"
+	"sync"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+// AddressFunc returns the local and remote addresses of the session, for
+// instance from the selected candidate pair of its ICE transport. It
+// returns nil addresses while they are unknown. It is called for every
+// packet, so the addresses follow the changes of the selected pair:
+//
+//	tap.SetAddressFunc(func() (*net.UDPAddr, *net.UDPAddr) {
+//		pair, err := iceTransport.GetSelectedCandidatePair()
+//		if err != nil || pair == nil {
+//			return nil, nil
+//		}
+//		return &net.UDPAddr{IP: net.ParseIP(pair.Local.Address), Port: int(pair.Local.Port)},
+//			&net.UDPAddr{IP: net.ParseIP(pair.Remote.Address), Port: int(pair.Remote.Port)}
+//	})
+type AddressFunc func() (local, remote *net.UDPAddr)
+
+// Placeholder addresses used until the AddressFunc returns the ones of the
+// session
+var (
+	placeholderLocal  = &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5004} //nolint:gochecknoglobals
+	placeholderRemote = &net.UDPAddr{IP: net.IPv4(127, 0, 0, 2), Port: 5004} //nolint:gochecknoglobals
+)
+
+// Tap is an interceptor.Factory that writes the RTP and RTCP packets of a
+// PeerConnection to a Writer, as seen by the interceptors: after SRTP
+// decryption for received packets, and before SRTP encryption for sent
+// ones. The UDP headers are built from the addresses of the AddressFunc.
+//
+// The interceptors see received packets only when the application reads
+// them, with TrackRemote.ReadRTP or RTPReceiver.ReadRTCP, so the packets of
+// a stream that is never read are missing from the capture. Received packets
+// are timestamped when they are read, not when they arrive, and buffering in
+// the application shifts their timing.
+type Tap struct {
+	writer *Writer
+
+	mu          sync.Mutex
+	addressFunc AddressFunc
+}
+
+// NewTap returns a Tap that writes to w
+func NewTap(w *Writer) (*Tap, error) {
+	if w == nil {
+		return nil, errNilStream
+	}
+	return &Tap{writer: w}, nil
+}
+
+// SetAddressFunc sets the function that returns the addresses of the
+// session, usually from the ICE transport once the PeerConnection is made
+func (t *Tap) SetAddressFunc(addressFunc AddressFunc) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.addressFunc = addressFunc
+}
+
+// NewInterceptor returns a new interceptor that writes to the Writer of
+// the Tap
+func (t *Tap) NewInterceptor(string) (interceptor.Interceptor, error) {
+	return &tapInterceptor{tap: t}, nil
+}
+
+// addresses returns the current local and remote addresses, or
+// placeholders while they are unknown
+func (t *Tap) addresses() (*net.UDPAddr, *net.UDPAddr) {
+	t.mu.Lock()
+	addressFunc := t.addressFunc
+	t.mu.Unlock()
+
+	if addressFunc != nil {
+		if local, remote := addressFunc(); local != nil && remote != nil {
+			return local, remote
+		}
+	}
+	return placeholderLocal, placeholderRemote
+}
+
+// write writes a packet sent to the remote address, or received from it
+func (t *Tap) write(payload []byte, received bool) {
+	local, remote := t.addresses()
+	packet := Packet{Source: local, Destination: remote, Payload: payload}
+	if received {
+		packet.Source, packet.Destination = remote, local
+	}
+
+	// The capture must not disturb the session, write errors are dropped
+	_ = t.writer.WritePacket(packet)
+}
+
+type tapInterceptor struct {
+	interceptor.NoOp
+	tap *Tap
+}
+
+func (i *tapInterceptor) BindRTCPReader(reader interceptor.RTCPReader) interceptor.RTCPReader {
+	return interceptor.RTCPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		n, attributes, err := reader.Read(b, a)
+		if err == nil {
+			i.tap.write(append([]byte{}, b[:n]...), true)
+		}
+		return n, attributes, err
+	})
+}
+
+func (i *tapInterceptor) BindRTCPWriter(writer interceptor.RTCPWriter) interceptor.RTCPWriter {
+	return interceptor.RTCPWriterFunc(func(pkts []rtcp.Packet, attributes interceptor.Attributes) (int, error) {
+		if payload, err := rtcp.Marshal(pkts); err == nil {
+			i.tap.write(payload, false)
+		}
+		return writer.Write(pkts, attributes)
+	})
+}
+
+func (i *tapInterceptor) BindLocalStream(
+	_ *interceptor.StreamInfo, writer interceptor.RTPWriter,
+) interceptor.RTPWriter {
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+		if packet, err := (&rtp.Packet{Header: *header, Payload: payload}).Marshal(); err == nil {
+			i.tap.write(packet, false)
+		}
+		return writer.Write(header, payload, attributes)
+	})
+}
+
+func (i *tapInterceptor) BindRemoteStream(
+	_ *interceptor.StreamInfo, reader interceptor.RTPReader,
+) interceptor.RTPReader {
+	return interceptor.RTPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		n, attributes, err := reader.Read(b, a)
+		if err == nil {
+			i.tap.write(append([]byte{}, b[:n]...), true)
+		}
+		return n, attributes, err
+	})
+}
@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package pcap
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTap(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer, err := NewWriter(buffer)
+	assert.NoError(t, err)
+
+	_, err = NewTap(nil)
+	assert.ErrorIs(t, err, errNilStream)
+	tap, err := NewTap(writer)
+	assert.NoError(t, err)
+	tap.SetAddressFunc(func() (*net.UDPAddr, *net.UDPAddr) {
+		return testSource, testDestination
+	})
+
+	i, err := tap.NewInterceptor("")
+	assert.NoError(t, err)
+
+	received := rtpPayload(t, 2, 20)
+	rtpReader := i.BindRemoteStream(&interceptor.StreamInfo{SSRC: 2}, interceptor.RTPReaderFunc(
+		func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+			return copy(b, received), a, nil
+		},
+	))
+	rtpWriter := i.BindLocalStream(&interceptor.StreamInfo{SSRC: 1}, interceptor.RTPWriterFunc(
+		func(_ *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+			return len(payload), nil
+		},
+	))
+	rtcpWriter := i.BindRTCPWriter(interceptor.RTCPWriterFunc(
+		func(pkts []rtcp.Packet, _ interceptor.Attributes) (int, error) {
+			return len(pkts), nil
+		},
+	))
+
+	_, err = rtpWriter.Write(&rtp.Header{Version: 2, PayloadType: 96, SequenceNumber: 10, SSRC: 1}, []byte{0xAA}, nil)
+	assert.NoError(t, err)
+	_, _, err = rtpReader.Read(make([]byte, 1500), nil)
+	assert.NoError(t, err)
+	_, err = rtcpWriter.Write([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: 2}}, nil)
+	assert.NoError(t, err)
+
+	reader, err := NewReader(bytes.NewReader(buffer.Bytes()))
+	assert.NoError(t, err)
+
+	// Sent packets go from local to remote, received packets the other way
+	for _, expected := range []struct {
+		source  *net.UDPAddr
+		payload []byte
+	}{
+		{testSource, rtpPayload(t, 1, 10)},
+		{testDestination, received},
+	} {
+		packet, err := reader.Next()
+		assert.NoError(t, err)
+		assert.True(t, expected.source.IP.Equal(packet.Source.IP))
+		assert.Equal(t, expected.source.Port, packet.Source.Port)
+		assert.Equal(t, expected.payload, packet.Payload)
+	}
+
+	packet, err := reader.Next()
+	assert.NoError(t, err)
+	pkts, err := rtcp.Unmarshal(packet.Payload)
+	assert.NoError(t, err)
+	assert.Equal(t, []rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: 2}}, pkts)
+
+	_, err = reader.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestTap_AddressChange(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer, err := NewWriter(buffer)
+	assert.NoError(t, err)
+	tap, err := NewTap(writer)
+	assert.NoError(t, err)
+
+	// The addresses are unknown, then the selected pair changes
+	local, remote := (*net.UDPAddr)(nil), (*net.UDPAddr)(nil)
+	tap.SetAddressFunc(func() (*net.UDPAddr, *net.UDPAddr) {
+		return local, remote
+	})
+
+	i, err := tap.NewInterceptor("")
+	assert.NoError(t, err)
+	rtpWriter := i.BindLocalStream(&interceptor.StreamInfo{SSRC: 1}, interceptor.RTPWriterFunc(
+		func(_ *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+			return len(payload), nil
+		},
+	))
+
+	changed := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 3), Port: 6000}
+	for _, source := range []*net.UDPAddr{nil, testSource, changed} {
+		local, remote = source, testDestination
+		_, err = rtpWriter.Write(&rtp.Header{Version: 2, PayloadType: 96, SequenceNumber: 10, SSRC: 1}, []byte{0xAA}, nil)
+		assert.NoError(t, err)
+	}
+
+	reader, err := NewReader(bytes.NewReader(buffer.Bytes()))
+	assert.NoError(t, err)
+	for _, expected := range []*net.UDPAddr{placeholderLocal, testSource, changed} {
+		packet, err := reader.Next()
+		assert.NoError(t, err)
+		assert.True(t, expected.IP.Equal(packet.Source.IP))
+		assert.Equal(t, expected.Port, packet.Source.Port)
+	}
+}
+
+func TestPacketConn(t *testing.T) {
+	local, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	assert.NoError(t, err)
+	remote, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, remote.Close())
+	}()
+
+	buffer := &bytes.Buffer{}
+	writer, err := NewWriter(buffer)
+	assert.NoError(t, err)
+	_, err = NewPacketConn(local, nil)
+	assert.ErrorIs(t, err, errNilStream)
+	conn, err := NewPacketConn(local, writer)
+	assert.NoError(t, err)
+
+	stun := []byte{0x00, 0x01, 0x00, 0x00}
+	dtls := []byte{22, 0xFE, 0xFD}
+	srtp := rtpPayload(t, 1, 10)
+
+	// Only the STUN request and the DTLS response are written
+	for _, payload := range [][]byte{stun, srtp} {
+		_, err = conn.WriteTo(payload, remote.LocalAddr())
+		assert.NoError(t, err)
+	}
+	for _, payload := range [][]byte{srtp, dtls} {
+		_, err = remote.WriteTo(payload, local.LocalAddr())
+		assert.NoError(t, err)
+		_, _, err = conn.ReadFrom(make([]byte, 1500))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, conn.Close())
+
+	reader, err := NewReader(bytes.NewReader(buffer.Bytes()))
+	assert.NoError(t, err)
+	for _, expected := range []struct {
+		source  net.Addr
+		payload []byte
+	}{
+		{local.LocalAddr(), stun},
+		{remote.LocalAddr(), dtls},
+	} {
+		packet, err := reader.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, expected.source.String(), packet.Source.String())
+		assert.Equal(t, expected.payload, packet.Payload)
+	}
+	_, err = reader.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package pcap
+
+import (
+	"encoding/binary"
+	"net"
+)
+
+const maxUDPPayload = 0xFFFF - udpHeaderLen
+
+// marshalIPUDP returns payload in a UDP datagram from src to dst, in an
+// IPv4 packet, or an IPv6 packet if one of the addresses is IPv6
+func marshalIPUDP(src, dst *net.UDPAddr, payload []byte) ([]byte, error) {
+	if src == nil || dst == nil || src.IP.To16() == nil || dst.IP.To16() == nil {
+		return nil, errMissingAddress
+	}
+	if len(payload) > maxUDPPayload-ipv6HeaderLen {
+		return nil, errPacketTooLarge
+	}
+
+	srcIP, dstIP := src.IP.To4(), dst.IP.To4()
+	ipv6 := srcIP == nil || dstIP == nil
+	if ipv6 {
+		srcIP, dstIP = src.IP.To16(), dst.IP.To16()
+	}
+
+	udpLen := udpHeaderLen + len(payload)
+	var packet []byte
+	if ipv6 {
+		packet = make([]byte, ipv6HeaderLen, ipv6HeaderLen+udpLen)
+		packet[0] = 0x60                                       // Version 6
+		binary.BigEndian.PutUint16(packet[4:], uint16(udpLen)) // Payload length
+		packet[6] = ipProtocolUDP                              // Next header
+		packet[7] = defaultTTL                                 // Hop limit
+		copy(packet[8:], srcIP)
+		copy(packet[24:], dstIP)
+	} else {
+		packet = make([]byte, ipv4HeaderLen, ipv4HeaderLen+udpLen)
+		packet[0] = 0x45                                                     // Version 4, 5 words header
+		binary.BigEndian.PutUint16(packet[2:], uint16(ipv4HeaderLen+udpLen)) // Total length
+		binary.BigEndian.PutUint16(packet[6:], 0x4000)                       // Don't fragment
+		packet[8] = defaultTTL                                               // TTL
+		packet[9] = ipProtocolUDP                                            // Protocol
+		copy(packet[12:], srcIP)
+		copy(packet[16:], dstIP)
+		binary.BigEndian.PutUint16(packet[10:], ^fold(sum(0, packet)))
+	}
+
+	udp := make([]byte, udpHeaderLen, udpLen)
+	binary.BigEndian.PutUint16(udp[0:], uint16(src.Port))
+	binary.BigEndian.PutUint16(udp[2:], uint16(dst.Port))
+	binary.BigEndian.PutUint16(udp[4:], uint16(udpLen))
+	udp = append(udp, payload...)
+
+	// The checksum covers a pseudo header with the addresses, see RFC 768
+	// and section 8.1 of RFC 8200
+	pseudoHeader := sum(sum(0, srcIP), dstIP) + ipProtocolUDP + uint32(udpLen)
+	udpChecksum := ^fold(sum(pseudoHeader, udp))
+	if udpChecksum == 0 {
+		udpChecksum = 0xFFFF
+	}
+	binary.BigEndian.PutUint16(udp[6:], udpChecksum)
+
+	return append(packet, udp...), nil
+}
+
+// sum adds the 16 bit words of data to the sum of an Internet checksum
+func sum(initial uint32, data []byte) uint32 {
+	for i := 0; i+1 < len(data); i += 2 {
+		initial += uint32(binary.BigEndian.Uint16(data[i:]))
+	}
+	if len(data)%2 == 1 {
+		initial += uint32(data[len(data)-1]) << 8
+	}
+	return initial
+}
+
+// fold returns the ones' complement sum of the 16 bit words of a sum
+func fold(sum uint32) uint16 {
+	for sum > 0xFFFF {
+		sum = sum>>16 + sum&0xFFFF
+	}
+	return uint16(sum)
+}
+
+// unmarshalIPUDP returns the UDP datagram of an IPv4 or IPv6 packet
+func unmarshalIPUDP(packet []byte) (*Packet, error) {
+	if len(packet) == 0 {
+		return nil, errMalformed
+	}
+
+	var (
+		srcIP, dstIP net.IP
+		udp          []byte
+	)
+	switch packet[0] >> 4 {
+	case 4:
+		headerLen := int(packet[0]&0x0F) * 4
+		if headerLen < ipv4HeaderLen || len(packet) < headerLen {
+			return nil, errMalformed
+		}
+		// Fragments other than the first have no UDP header
+		if packet[9] != ipProtocolUDP || binary.BigEndian.Uint16(packet[6:])&0x1FFF != 0 {
+			return nil, errNotUDP
+		}
+		if totalLen := int(binary.BigEndian.Uint16(packet[2:])); totalLen >= headerLen && totalLen < len(packet) {
+			packet = packet[:totalLen]
+		}
+		srcIP, dstIP = net.IP(packet[12:16]), net.IP(packet[16:20])
+		udp = packet[headerLen:]
+	case 6:
+		// Extension headers are not followed
+		if len(packet) < ipv6HeaderLen {
+			return nil, errMalformed
+		}
+		if packet[6] != ipProtocolUDP {
+			return nil, errNotUDP
+		}
+		srcIP, dstIP = net.IP(packet[8:24]), net.IP(packet[24:40])
+		udp = packet[ipv6HeaderLen:]
+	default:
+		return nil, errNotUDP
+	}
+
+	if len(udp) < udpHeaderLen {
+		return nil, errMalformed
+	}
+	if udpLen := int(binary.BigEndian.Uint16(udp[4:])); udpLen >= udpHeaderLen && udpLen <= len(udp) {
+		udp = udp[:udpLen]
+	}
+
+	return &Packet{
+		Source:      &net.UDPAddr{IP: append(net.IP{}, srcIP...), Port: int(binary.BigEndian.Uint16(udp[0:]))},
+		Destination: &net.UDPAddr{IP: append(net.IP{}, dstIP...), Port: int(binary.BigEndian.Uint16(udp[2:]))},
+		Payload:     append([]byte{}, udp[udpHeaderLen:]...),
+	}, nil
+}
@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package pcap
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+)
+
+// pcapng block types, see draft-ietf-opsawg-pcapng
+const (
+	blockTypeSectionHeader        = 0x0A0D0D0A
+	blockTypeInterfaceDescription = 0x00000001
+	blockTypeSimplePacket         = 0x00000003
+	blockTypeEnhancedPacket       = 0x00000006
+
+	byteOrderMagic = 0x1A2B3C4D
+
+	optionEndOfOpt   = 0
+	optionIfTSResol  = 9
+	tsResolNanoUnits = 9
+)
+
+// Writer writes UDP datagrams to a pcapng file, in an interface of raw IP
+// packets with nanosecond timestamps
+type Writer struct {
+	writerMu sync.Mutex
+	writer   io.Writer
+}
+
+// NewWriter makes a new Writer and immediately writes the section header
+// and the interface description to begin the file.
+func NewWriter(w io.Writer) (*Writer, error) {
+	if w == nil {
+		return nil, errNilStream
+	}
+
+	// Section header, with an unspecified section length
+	sectionHeader := make([]byte, 16)
+	binary.LittleEndian.PutUint32(sectionHeader[0:], byteOrderMagic)
+	binary.LittleEndian.PutUint16(sectionHeader[4:], 1) // Major version
+	binary.LittleEndian.PutUint16(sectionHeader[6:], 0) // Minor version
+	binary.LittleEndian.PutUint64(sectionHeader[8:], ^uint64(0))
+	if _, err := w.Write(marshalBlock(blockTypeSectionHeader, sectionHeader)); err != nil {
+		return nil, err
+	}
+
+	// Interface description, with the timestamp resolution option
+	interfaceDescription := make([]byte, 20)
+	binary.LittleEndian.PutUint16(interfaceDescription[0:], linkTypeRaw)
+	binary.LittleEndian.PutUint32(interfaceDescription[4:], 0) // No snap length
+	binary.LittleEndian.PutUint16(interfaceDescription[8:], optionIfTSResol)
+	binary.LittleEndian.PutUint16(interfaceDescription[10:], 1)
+	interfaceDescription[12] = tsResolNanoUnits
+	binary.LittleEndian.PutUint16(interfaceDescription[16:], optionEndOfOpt)
+	if _, err := w.Write(marshalBlock(blockTypeInterfaceDescription, interfaceDescription)); err != nil {
+		return nil, err
+	}
+
+	return &Writer{writer: w}, nil
+}
+
+// WritePacket writes the Payload of p in a UDP datagram from its Source to
+// its Destination, with synthetic IP and UDP headers. A zero Timestamp is
+// set to now.
+func (w *Writer) WritePacket(p Packet) error {
+	data, err := marshalIPUDP(p.Source, p.Destination, p.Payload)
+	if err != nil {
+		return err
+	}
+
+	if p.Timestamp.IsZero() {
+		p.Timestamp = time.Now()
+	}
+	timestamp := uint64(p.Timestamp.UnixNano())
+	body := make([]byte, 20, 20+len(data)+3)
+	binary.LittleEndian.PutUint32(body[0:], 0) // Interface ID
+	binary.LittleEndian.PutUint32(body[4:], uint32(timestamp>>32))
+	binary.LittleEndian.PutUint32(body[8:], uint32(timestamp))
+	binary.LittleEndian.PutUint32(body[12:], uint32(len(data))) // Captured length
+	binary.LittleEndian.PutUint32(body[16:], uint32(len(data))) // Original length
+	body = append(body, data...)
+
+	w.writerMu.Lock()
+	defer w.writerMu.Unlock()
+
+	_, err = w.writer.Write(marshalBlock(blockTypeEnhancedPacket, body))
+	return err
+}
+
+// marshalBlock returns a block with body padded to 32 bits, between the
+// block type and length and the repeated length
+func marshalBlock(blockType uint32, body []byte) []byte {
+	padding := (4 - len(body)%4) % 4
+	length := 12 + len(body) + padding
+
+	block := make([]byte, 8, length)
+	binary.LittleEndian.PutUint32(block[0:], blockType)
+	binary.LittleEndian.PutUint32(block[4:], uint32(length))
+	block = append(block, body...)
+	block = append(block, make([]byte, padding)...)
+	return binary.LittleEndian.AppendUint32(block, uint32(length))
+}
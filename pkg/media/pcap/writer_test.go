@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package pcap
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer, err := NewWriter(buffer)
+	assert.NoError(t, err)
+
+	packets := []Packet{
+		{
+			Timestamp:   time.Unix(1700000000, 123456789),
+			Source:      &net.UDPAddr{IP: net.IPv4(192, 168, 1, 2), Port: 50000},
+			Destination: &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 3478},
+			Payload:     []byte{0x80, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x12, 0x34, 0xAB},
+		},
+		{
+			Timestamp:   time.Unix(1700000001, 0),
+			Source:      &net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 9},
+			Destination: &net.UDPAddr{IP: net.ParseIP("2001:db8::2"), Port: 10},
+			Payload:     []byte{0x01, 0x02, 0x03},
+		},
+	}
+	for _, packet := range packets {
+		assert.NoError(t, writer.WritePacket(packet))
+	}
+	assert.Equal(t, 0, buffer.Len()%4)
+
+	reader, err := NewReader(bytes.NewReader(buffer.Bytes()))
+	assert.NoError(t, err)
+	for _, expected := range packets {
+		packet, err := reader.Next()
+		assert.NoError(t, err)
+		assert.True(t, expected.Timestamp.Equal(packet.Timestamp))
+		assert.True(t, expected.Source.IP.Equal(packet.Source.IP))
+		assert.Equal(t, expected.Source.Port, packet.Source.Port)
+		assert.True(t, expected.Destination.IP.Equal(packet.Destination.IP))
+		assert.Equal(t, expected.Destination.Port, packet.Destination.Port)
+		assert.Equal(t, expected.Payload, packet.Payload)
+	}
+
+	_, err = reader.Next()
+	assert.ErrorIs(t, err, io.EOF)
+
+	_, err = NewWriter(nil)
+	assert.ErrorIs(t, err, errNilStream)
+	assert.ErrorIs(t, writer.WritePacket(Packet{
+		Source: packets[0].Source, Destination: packets[0].Destination, Payload: make([]byte, 0x10000),
+	}), errPacketTooLarge)
+	assert.ErrorIs(t, writer.WritePacket(Packet{Source: packets[0].Source}), errMissingAddress)
+	assert.ErrorIs(t, writer.WritePacket(Packet{Destination: packets[0].Destination}), errMissingAddress)
+}
+
+func TestMarshalIPUDP_Checksums(t *testing.T) {
+	src := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 2), Port: 50000}
+	dst := &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 3478}
+	payload := []byte{0x00, 0x01, 0x00, 0x00, 0x21}
+
+	packet, err := marshalIPUDP(src, dst, payload)
+	assert.NoError(t, err)
+	assert.Len(t, packet, ipv4HeaderLen+udpHeaderLen+len(payload))
+
+	// A valid checksum sums to all ones with the data it covers
+	assert.Equal(t, uint16(0xFFFF), fold(sum(0, packet[:ipv4HeaderLen])))
+	pseudoHeader := sum(sum(0, packet[12:16]), packet[16:20]) + ipProtocolUDP + uint32(udpHeaderLen+len(payload))
+	assert.Equal(t, uint16(0xFFFF), fold(sum(pseudoHeader, packet[ipv4HeaderLen:])))
+
+	src.IP, dst.IP = net.ParseIP("2001:db8::1"), net.IPv4(10, 0, 0, 1)
+	packet, err = marshalIPUDP(src, dst, payload)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0x60), packet[0])
+	pseudoHeader = sum(sum(0, packet[8:24]), packet[24:40]) + ipProtocolUDP + uint32(udpHeaderLen+len(payload))
+	assert.Equal(t, uint16(0xFFFF), fold(sum(pseudoHeader, packet[ipv6HeaderLen:])))
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package player
+
+import (
+	"io"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/pion/webrtc/v4/pkg/media/h264reader"
+)
+
+// h264Reader reads access units from an H.264 Annex-B file
+type h264Reader struct {
+	in            io.ReadSeeker
+	reader        *h264reader.H264Reader
+	frameDuration time.Duration
+	timestamp     time.Duration
+}
+
+// NewH264Reader returns a Reader for an H.264 Annex-B file, with one Frame
+// per access unit. Frames last as given by the timing info of the SPS, or
+// frameDuration if it is missing. Timestamps are in decoding order.
+func NewH264Reader(in io.ReadSeeker, frameDuration time.Duration) (Reader, error) {
+	if in == nil {
+		return nil, errNilStream
+	}
+	if frameDuration <= 0 {
+		return nil, errInvalidFrameDuration
+	}
+
+	r := &h264Reader{in: in, frameDuration: frameDuration}
+	if err := r.Rewind(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *h264Reader) Rewind() error {
+	if err := rewind(r.in); err != nil {
+		return err
+	}
+
+	reader, err := h264reader.NewReader(r.in)
+	if err != nil {
+		return err
+	}
+	r.reader, r.timestamp = reader, 0
+	return nil
+}
+
+func (r *h264Reader) NextFrame() (*Frame, error) {
+	accessUnit, err := r.reader.NextAccessUnit()
+	if err != nil {
+		return nil, err
+	}
+
+	duration := accessUnit.Duration()
+	if duration <= 0 {
+		duration = r.frameDuration
+	}
+
+	frame := &Frame{
+		Samples:    []media.Sample{{Data: accessUnit.Data(), Duration: duration}},
+		Timestamp:  r.timestamp,
+		Duration:   duration,
+		IsKeyFrame: accessUnit.IsKeyFrame,
+	}
+	r.timestamp += duration
+	return frame, nil
+}
+
+func (r *h264Reader) Close() error {
+	return closeStream(r.in)
+}
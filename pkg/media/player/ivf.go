@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package player
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/pion/webrtc/v4/pkg/media/av1reader"
+	"github.com/pion/webrtc/v4/pkg/media/ivfreader"
+)
+
+const (
+	fourCCVP8 = "VP80"
+	fourCCVP9 = "VP90"
+	fourCCAV1 = "AV01"
+)
+
+// ivfReader reads VP8, VP9 and AV1 frames from an IVF file
+type ivfReader struct {
+	in     io.ReadSeeker
+	reader *ivfreader.IVFReader
+	header *ivfreader.IVFFileHeader
+
+	// next is read ahead to compute the duration of frames from
+	// timestamps
+	next         *Frame
+	lastDuration time.Duration
+
+	// av1 reads the OBUs of AV1 frames, it keeps the sequence header
+	// across frames
+	av1 *av1reader.AV1Reader
+}
+
+// NewIVFReader returns a Reader for an IVF file holding VP8, VP9 or AV1.
+// VP9 superframes and AV1 temporal units are split in one Sample per
+// frame or OBU.
+func NewIVFReader(in io.ReadSeeker) (Reader, error) {
+	if in == nil {
+		return nil, errNilStream
+	}
+
+	r := &ivfReader{in: in}
+	if err := r.Rewind(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *ivfReader) Rewind() error {
+	if err := rewind(r.in); err != nil {
+		return err
+	}
+
+	reader, header, err := ivfreader.NewWith(r.in)
+	if err != nil {
+		return err
+	}
+	switch header.FourCC {
+	case fourCCVP8, fourCCVP9, fourCCAV1:
+	default:
+		return errUnsupportedFourCC
+	}
+
+	r.reader, r.header, r.next, r.av1 = reader, header, nil, nil
+	return nil
+}
+
+func (r *ivfReader) NextFrame() (*Frame, error) {
+	frame := r.next
+	if frame == nil {
+		var err error
+		if frame, err = r.readFrame(); err != nil {
+			return nil, err
+		}
+	}
+
+	next, err := r.readFrame()
+	switch {
+	case errors.Is(err, io.EOF):
+		r.next = nil
+	case err != nil:
+		return nil, err
+	default:
+		r.next = next
+		frame.Duration = next.Timestamp - frame.Timestamp
+	}
+
+	// The last frame, and frames with timestamps out of order, last as
+	// long as the previous one
+	if frame.Duration <= 0 {
+		frame.Duration = r.lastDuration
+	}
+	if frame.Duration <= 0 {
+		frame.Duration = r.timestamp(1)
+	}
+	r.lastDuration = frame.Duration
+	frame.Samples[len(frame.Samples)-1].Duration = frame.Duration
+
+	return frame, nil
+}
+
+func (r *ivfReader) Close() error {
+	return closeStream(r.in)
+}
+
+func (r *ivfReader) readFrame() (*Frame, error) {
+	payload, header, err := r.reader.ParseNextFrame()
+	if err != nil {
+		return nil, err
+	}
+
+	frame := &Frame{Timestamp: r.timestamp(header.Timestamp)}
+	switch r.header.FourCC {
+	case fourCCVP8:
+		frame.Samples = []media.Sample{{Data: payload}}
+		frame.IsKeyFrame = isVP8KeyFrame(payload)
+	case fourCCVP9:
+		for _, vp9Frame := range ivfreader.SplitVP9Superframe(payload) {
+			frame.Samples = append(frame.Samples, media.Sample{Data: vp9Frame})
+			frame.IsKeyFrame = frame.IsKeyFrame || isVP9KeyFrame(vp9Frame)
+		}
+	default:
+		if err := r.readAV1Frame(frame, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(frame.Samples) == 0 {
+		frame.Samples = []media.Sample{{}}
+	}
+	return frame, nil
+}
+
+// timestamp converts a timestamp in the timebase of the file
+func (r *ivfReader) timestamp(timestamp uint64) time.Duration {
+	if r.header.TimebaseDenominator == 0 {
+		return 0
+	}
+	return time.Duration(float64(timestamp) * float64(time.Second) *
+		float64(r.header.TimebaseNumerator) / float64(r.header.TimebaseDenominator))
+}
+
+// readAV1Frame splits an IVF frame in the OBUs of its temporal units
+func (r *ivfReader) readAV1Frame(frame *Frame, payload []byte) error {
+	if r.av1 == nil {
+		reader, err := av1reader.NewWith(bytes.NewReader(payload), av1reader.FormatLowOverhead)
+		if err != nil {
+			return err
+		}
+		r.av1 = reader
+	} else {
+		r.av1.Reset(bytes.NewReader(payload))
+	}
+
+	for {
+		temporalUnit, err := r.av1.NextTemporalUnit()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		frame.Samples = append(frame.Samples, temporalUnit.Samples(0)...)
+		frame.IsKeyFrame = frame.IsKeyFrame || temporalUnit.IsKeyFrame
+	}
+}
+
+// isVP8KeyFrame reads the frame type of the frame tag, see section 9.1 of
+// RFC 6386
+func isVP8KeyFrame(frame []byte) bool {
+	return len(frame) != 0 && frame[0]&0x01 == 0
+}
+
+// isVP9KeyFrame reads the frame type of the uncompressed header, see
+// section 6.2 of the VP9 bitstream specification
+func isVP9KeyFrame(frame []byte) bool {
+	if len(frame) == 0 || frame[0]>>6 != 0x02 {
+		return false
+	}
+
+	// Profile 3 has a reserved bit after the profile
+	bit := 4
+	if frame[0]>>4&0x03 == 0x03 {
+		bit = 5
+	}
+
+	showExistingFrame := frame[0]>>(7-bit)&0x01 == 1
+	frameType := frame[0] >> (6 - bit) & 0x01
+	return !showExistingFrame && frameType == 0
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package player
+
+import (
+	"io"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/pion/webrtc/v4/pkg/media/oggreader"
+)
+
+// oggReader reads Opus packets from an Ogg file
+type oggReader struct {
+	in     io.ReadSeeker
+	reader *oggreader.OggReader
+}
+
+// NewOggReader returns a Reader for an Ogg Opus file, with one Frame per
+// Opus packet. The Timestamp of the packets in the pre-skip is negative.
+func NewOggReader(in io.ReadSeeker) (Reader, error) {
+	if in == nil {
+		return nil, errNilStream
+	}
+
+	r := &oggReader{in: in}
+	if err := r.Rewind(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *oggReader) Rewind() error {
+	if err := rewind(r.in); err != nil {
+		return err
+	}
+
+	reader, _, err := oggreader.NewWith(r.in)
+	if err != nil {
+		return err
+	}
+	r.reader = reader
+	return nil
+}
+
+func (r *oggReader) NextFrame() (*Frame, error) {
+	packet, err := r.reader.ParseNextPacket()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Frame{
+		Samples:    []media.Sample{{Data: packet.Data, Duration: packet.Duration}},
+		Timestamp:  packet.Timestamp,
+		Duration:   packet.Duration,
+		IsKeyFrame: true,
+	}, nil
+}
+
+func (r *oggReader) Close() error {
+	return closeStream(r.in)
+}
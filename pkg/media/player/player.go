@@ -0,0 +1,445 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package player plays media files on tracks, pacing the Samples of all
+// tracks against a shared clock to keep them in sync
+package player
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+var (
+	errAlreadyStarted = errors.New("tracks must be added before the player is started")
+	errPlayerClosed   = errors.New("player is closed")
+	errNoTracks       = errors.New("player has no tracks")
+	errPlayerDone     = errors.New("playback ended, see Err")
+)
+
+// SampleWriter writes Samples to a track, it is implemented by
+// webrtc.TrackLocalStaticSample
+type SampleWriter interface {
+	WriteSample(sample media.Sample) error
+}
+
+// track is a Reader and the SampleWriters its Frames are written to. It is
+// only used by the goroutine of the Player once started.
+type track struct {
+	reader  Reader
+	writers []SampleWriter
+
+	// queue holds the Frames read and not written yet
+	queue        []*Frame
+	eof          bool
+	waitKeyFrame bool
+}
+
+// Player plays the Frames of several tracks from files. The Frames of all
+// tracks are written when the clock of the Player reaches their Timestamp,
+// video tracks start on a key frame.
+type Player struct {
+	tracks []*track
+	loop   bool
+
+	// end is the end of the longest track seen so far
+	end time.Duration
+
+	mu      sync.Mutex
+	started bool
+	closed  bool
+	playing bool
+	// finished is set once the playback ended
+	finished bool
+	err      error
+
+	// The clock is the play time, clockOffset at clockBase. It runs
+	// across loops, while Frame timestamps restart at loopOffset.
+	clockBase   time.Time
+	clockOffset time.Duration
+	loopOffset  time.Duration
+	seekTo      *time.Duration
+
+	wake chan struct{}
+	done chan struct{}
+}
+
+// An Option configures a Player.
+type Option func(p *Player)
+
+// WithLoop sets whether the Player starts over at the end of the files
+func WithLoop(loop bool) Option {
+	return func(p *Player) {
+		p.loop = loop
+	}
+}
+
+// New returns a new Player, paused until Play is called
+func New(opts ...Option) *Player {
+	p := &Player{
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	return p
+}
+
+// AddTrack adds a track to play from reader, its Frames are written to all
+// writers. Tracks must be added before Play is called.
+func (p *Player) AddTrack(reader Reader, writers ...SampleWriter) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.closed:
+		return errPlayerClosed
+	case p.started:
+		return errAlreadyStarted
+	case reader == nil:
+		return errNilStream
+	}
+
+	p.tracks = append(p.tracks, &track{reader: reader, writers: writers, waitKeyFrame: true})
+	return nil
+}
+
+// Play starts the playback, or resumes it after Pause. It fails once the
+// playback ended.
+func (p *Player) Play() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.closed:
+		return errPlayerClosed
+	case p.finished:
+		return errPlayerDone
+	case len(p.tracks) == 0:
+		return errNoTracks
+	}
+
+	if !p.playing {
+		p.playing = true
+		p.clockBase = time.Now()
+	}
+	if !p.started {
+		p.started = true
+		go p.run()
+	}
+	p.signal()
+	return nil
+}
+
+// Pause stops the clock, no Samples are written until Play is called
+func (p *Player) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.playing {
+		p.clockOffset = p.clock()
+		p.playing = false
+		p.signal()
+	}
+}
+
+// Seek moves the playback to position from the start of the files. Video
+// tracks restart from the key frame before position, the frames before
+// position are written at once. Tracks that end before position are done.
+// It fails once the playback ended.
+func (p *Player) Seek(position time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.closed:
+		return errPlayerClosed
+	case p.finished:
+		return errPlayerDone
+	}
+	if position < 0 {
+		position = 0
+	}
+
+	p.clockOffset, p.clockBase = position, time.Now()
+	p.seekTo = &position
+	p.signal()
+	return nil
+}
+
+// Position returns the playback position from the start of the files
+func (p *Player) Position() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.clock() - p.loopOffset
+}
+
+// Done returns a channel that is closed when the playback ends, at the end
+// of the files, on error or on Close
+func (p *Player) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns the error that stopped the playback, if any
+func (p *Player) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.err
+}
+
+// Close stops the playback and closes the Readers
+func (p *Player) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	started := p.started
+	p.signal()
+	p.mu.Unlock()
+
+	if started {
+		<-p.done
+	} else {
+		close(p.done)
+	}
+
+	var closeErr error
+	for _, t := range p.tracks {
+		if err := t.reader.Close(); err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+	return closeErr
+}
+
+// clock returns the play time, the lock must be held
+func (p *Player) clock() time.Duration {
+	if !p.playing {
+		return p.clockOffset
+	}
+	return p.clockOffset + time.Since(p.clockBase)
+}
+
+// signal wakes up the goroutine of the Player, the lock must be held
+func (p *Player) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Player) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.err = err
+}
+
+func (p *Player) run() {
+	defer func() {
+		p.mu.Lock()
+		p.finished = true
+		p.mu.Unlock()
+
+		close(p.done)
+	}()
+
+	for {
+		p.mu.Lock()
+		closed, seekTo := p.closed, p.seekTo
+		p.seekTo = nil
+		p.mu.Unlock()
+
+		switch {
+		case closed:
+			return
+		case seekTo != nil:
+			if err := p.seek(*seekTo); err != nil {
+				p.fail(err)
+				return
+			}
+			continue
+		}
+
+		next, err := p.nextTrack()
+		if err != nil {
+			p.fail(err)
+			return
+		}
+
+		if next == nil {
+			// All tracks ended, an empty file is not looped
+			if !p.loop || p.end <= 0 {
+				return
+			}
+			if err := p.rewind(); err != nil {
+				p.fail(err)
+				return
+			}
+			continue
+		}
+
+		frame := next.queue[0]
+		if !p.waitUntil(frame.Timestamp) {
+			continue
+		}
+		next.queue = next.queue[1:]
+
+		for _, sample := range frame.Samples {
+			for _, writer := range next.writers {
+				if err := writer.WriteSample(sample); err != nil {
+					p.fail(err)
+					return
+				}
+			}
+		}
+	}
+}
+
+// waitUntil waits until the clock reaches the play time of timestamp. It
+// returns false if it was woken up by a call to the Player before.
+func (p *Player) waitUntil(timestamp time.Duration) bool {
+	p.mu.Lock()
+	playing := p.playing
+	delay := p.loopOffset + timestamp - p.clock()
+	p.mu.Unlock()
+
+	if !playing {
+		<-p.wake
+		return false
+	}
+	if delay <= 0 {
+		return true
+	}
+
+	// A timer is used instead of time.Sleep so the wait can be interrupted,
+	// and the delay is computed from the clock so no skew accumulates
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-p.wake:
+		return false
+	}
+}
+
+// nextTrack returns the track of the earliest Frame, or nil if all tracks
+// ended
+func (p *Player) nextTrack() (*track, error) {
+	var next *track
+	for _, t := range p.tracks {
+		if err := p.fill(t); err != nil {
+			return nil, err
+		}
+		if len(t.queue) != 0 && (next == nil || t.queue[0].Timestamp < next.queue[0].Timestamp) {
+			next = t
+		}
+	}
+	return next, nil
+}
+
+// fill reads a Frame of t if its queue is empty, dropping Frames until a
+// key frame after a rewind
+func (p *Player) fill(t *track) error {
+	for len(t.queue) == 0 && !t.eof {
+		frame, err := p.readFrame(t)
+		if err != nil {
+			return err
+		}
+		if frame == nil || (t.waitKeyFrame && !frame.IsKeyFrame) {
+			continue
+		}
+
+		t.waitKeyFrame = false
+		t.queue = append(t.queue, frame)
+	}
+	return nil
+}
+
+// readFrame returns the next Frame of t, or nil at the end of the file
+func (p *Player) readFrame(t *track) (*Frame, error) {
+	frame, err := t.reader.NextFrame()
+	if errors.Is(err, io.EOF) {
+		t.eof = true
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if end := frame.Timestamp + frame.Duration; end > p.end {
+		p.end = end
+	}
+	return frame, nil
+}
+
+// rewind starts the tracks over after the end of the longest one
+func (p *Player) rewind() error {
+	p.mu.Lock()
+	p.loopOffset += p.end
+	p.mu.Unlock()
+
+	for _, t := range p.tracks {
+		if err := t.reader.Rewind(); err != nil {
+			return err
+		}
+		t.queue, t.eof, t.waitKeyFrame = nil, false, true
+	}
+	return nil
+}
+
+// seek rewinds the tracks and queues, for each track, the Frames from the
+// last key frame before position to the one that holds position. The queue
+// of a track that ends before position stays empty.
+func (p *Player) seek(position time.Duration) error {
+	p.mu.Lock()
+	p.loopOffset = 0
+	p.mu.Unlock()
+
+	for _, t := range p.tracks {
+		if err := t.reader.Rewind(); err != nil {
+			return err
+		}
+		t.queue, t.eof, t.waitKeyFrame = nil, false, true
+
+		for !t.eof {
+			frame, err := p.readFrame(t)
+			if err != nil {
+				return err
+			}
+			if frame == nil {
+				break
+			}
+
+			if frame.IsKeyFrame {
+				if frame.Timestamp <= position {
+					t.queue = nil
+				}
+				t.waitKeyFrame = false
+			}
+			if t.waitKeyFrame {
+				continue
+			}
+
+			t.queue = append(t.queue, frame)
+			if frame.Timestamp+frame.Duration > position {
+				break
+			}
+		}
+
+		if t.eof {
+			t.queue = nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package player
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+const testFrameDuration = 10 * time.Millisecond
+
+type testReader struct {
+	frames  []*Frame
+	index   int
+	rewinds int
+	closed  bool
+}
+
+// newTestReader returns a Reader of frames lasting testFrameDuration,
+// with the given key frames. The Data of each Sample is its index.
+func newTestReader(count int, keyFrames ...int) *testReader {
+	r := &testReader{}
+	for i := 0; i < count; i++ {
+		r.frames = append(r.frames, &Frame{
+			Samples:   []media.Sample{{Data: []byte{byte(i)}, Duration: testFrameDuration}},
+			Timestamp: time.Duration(i) * testFrameDuration,
+			Duration:  testFrameDuration,
+		})
+	}
+	for _, i := range keyFrames {
+		r.frames[i].IsKeyFrame = true
+	}
+	return r
+}
+
+func (r *testReader) NextFrame() (*Frame, error) {
+	if r.index == len(r.frames) {
+		return nil, io.EOF
+	}
+	r.index++
+	return r.frames[r.index-1], nil
+}
+
+func (r *testReader) Rewind() error {
+	r.index = 0
+	r.rewinds++
+	return nil
+}
+
+func (r *testReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+type testWriter struct {
+	mu      sync.Mutex
+	start   time.Time
+	samples []byte
+	times   []time.Duration
+	written chan struct{}
+}
+
+func newTestWriter() *testWriter {
+	return &testWriter{start: time.Now(), written: make(chan struct{}, 100)}
+}
+
+func (w *testWriter) WriteSample(sample media.Sample) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.samples = append(w.samples, sample.Data[0])
+	w.times = append(w.times, time.Since(w.start))
+	w.written <- struct{}{}
+	return nil
+}
+
+func (w *testWriter) data() []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]byte{}, w.samples...)
+}
+
+func TestPlayer_Interleave(t *testing.T) {
+	video, audio := newTestReader(5, 1, 3), newTestReader(5, 0, 1, 2, 3, 4)
+	videoWriter, audioWriter := newTestWriter(), newTestWriter()
+
+	player := New()
+	assert.NoError(t, player.AddTrack(video, videoWriter))
+	assert.NoError(t, player.AddTrack(audio, audioWriter))
+	start := time.Now()
+	assert.NoError(t, player.Play())
+	assert.ErrorIs(t, player.AddTrack(newTestReader(1)), errAlreadyStarted)
+
+	<-player.Done()
+	assert.NoError(t, player.Err())
+	assert.GreaterOrEqual(t, time.Since(start), 4*testFrameDuration)
+
+	// Video starts on the first key frame, at its timestamp
+	assert.Equal(t, []byte{1, 2, 3, 4}, videoWriter.data())
+	assert.Equal(t, []byte{0, 1, 2, 3, 4}, audioWriter.data())
+	assert.GreaterOrEqual(t, videoWriter.times[0], testFrameDuration)
+
+	assert.NoError(t, player.Close())
+	assert.True(t, video.closed)
+	assert.True(t, audio.closed)
+	assert.ErrorIs(t, player.Play(), errPlayerClosed)
+}
+
+func TestPlayer_Loop(t *testing.T) {
+	reader, writer := newTestReader(2, 0), newTestWriter()
+
+	player := New(WithLoop(true))
+	assert.NoError(t, player.AddTrack(reader, writer))
+	assert.NoError(t, player.Play())
+
+	for i := 0; i < 6; i++ {
+		<-writer.written
+	}
+	assert.NoError(t, player.Close())
+
+	assert.Equal(t, []byte{0, 1, 0, 1, 0, 1}, writer.data()[:6])
+	assert.GreaterOrEqual(t, reader.rewinds, 2)
+	assert.GreaterOrEqual(t, writer.times[5], 5*testFrameDuration)
+}
+
+func TestPlayer_Pause(t *testing.T) {
+	reader, writer := newTestReader(3, 0), newTestWriter()
+
+	player := New()
+	assert.NoError(t, player.AddTrack(reader, writer))
+	player.Pause()
+	assert.NoError(t, player.Play())
+	<-writer.written
+	player.Pause()
+
+	position := player.Position()
+	time.Sleep(3 * testFrameDuration)
+	assert.Equal(t, position, player.Position())
+	assert.Len(t, writer.data(), 1)
+
+	assert.NoError(t, player.Play())
+	<-player.Done()
+	assert.Equal(t, []byte{0, 1, 2}, writer.data())
+	assert.GreaterOrEqual(t, writer.times[1]-writer.times[0], 3*testFrameDuration)
+}
+
+func TestPlayer_Seek(t *testing.T) {
+	video, audio := newTestReader(10, 0, 5), newTestReader(10, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	videoWriter, audioWriter := newTestWriter(), newTestWriter()
+
+	player := New()
+	assert.NoError(t, player.AddTrack(video, videoWriter))
+	assert.NoError(t, player.AddTrack(audio, audioWriter))
+	assert.NoError(t, player.Seek(7*testFrameDuration+testFrameDuration/2))
+	assert.NoError(t, player.Play())
+	<-player.Done()
+
+	// Video restarts from the key frame before the position
+	assert.Equal(t, []byte{5, 6, 7, 8, 9}, videoWriter.data())
+	assert.Equal(t, []byte{7, 8, 9}, audioWriter.data())
+	assert.Less(t, videoWriter.times[2], testFrameDuration)
+	assert.NoError(t, player.Close())
+}
+
+func TestPlayer_SeekPastEnd(t *testing.T) {
+	video, audio := newTestReader(10, 0, 5), newTestReader(20, 0, 5, 10, 15)
+	videoWriter, audioWriter := newTestWriter(), newTestWriter()
+
+	player := New()
+	assert.NoError(t, player.AddTrack(video, videoWriter))
+	assert.NoError(t, player.AddTrack(audio, audioWriter))
+	assert.NoError(t, player.Seek(15*testFrameDuration))
+	assert.NoError(t, player.Play())
+	<-player.Done()
+
+	// The video ended before the position, no frames of its last GOP are written
+	assert.Empty(t, videoWriter.data())
+	assert.Equal(t, []byte{15, 16, 17, 18, 19}, audioWriter.data())
+	assert.NoError(t, player.Close())
+}
+
+func TestPlayer_Errors(t *testing.T) {
+	player := New()
+	assert.ErrorIs(t, player.Play(), errNoTracks)
+	assert.ErrorIs(t, player.AddTrack(nil), errNilStream)
+	assert.NoError(t, player.Close())
+	assert.NoError(t, player.Close())
+	<-player.Done()
+	assert.ErrorIs(t, player.AddTrack(newTestReader(1)), errPlayerClosed)
+	assert.ErrorIs(t, player.Seek(0), errPlayerClosed)
+
+	// The playback can't be resumed once it ended
+	player = New()
+	assert.NoError(t, player.AddTrack(newTestReader(1, 0), newTestWriter()))
+	assert.NoError(t, player.Play())
+	<-player.Done()
+	assert.ErrorIs(t, player.Play(), errPlayerDone)
+	assert.ErrorIs(t, player.Seek(0), errPlayerDone)
+	assert.NoError(t, player.Close())
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package player
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+// defaultFrameDuration is used for H.264 streams without timing info
+const defaultFrameDuration = time.Second / 30
+
+var (
+	errNilStream            = errors.New("stream is nil")
+	errUnknownContainer     = errors.New("unknown container for file extension")
+	errUnsupportedFourCC    = errors.New("unsupported IVF FourCC")
+	errInvalidFrameDuration = errors.New("frame duration must be positive")
+)
+
+// Frame is a unit of media read from a file, a video frame or an audio
+// packet. It is written as one or more Samples, that share its Timestamp.
+type Frame struct {
+	// Samples of the Frame, all but the last usually have no Duration
+	Samples []media.Sample
+
+	// Timestamp is the presentation time of the Frame from the start of
+	// the file
+	Timestamp time.Duration
+
+	// Duration of the Frame
+	Duration time.Duration
+
+	// IsKeyFrame is set if decoding can start at the Frame. It is always
+	// set for audio.
+	IsKeyFrame bool
+}
+
+// Reader reads the Frames of a track from a file
+type Reader interface {
+	// NextFrame returns the next Frame in decoding order, or io.EOF at the
+	// end of the file
+	NextFrame() (*Frame, error)
+
+	// Rewind moves back to the start of the file
+	Rewind() error
+
+	// Close closes the file
+	Close() error
+}
+
+// Open opens fileName and returns a Reader for its container, from its
+// extension: .ivf, .ogg or .opus, and .h264 or .264 for H.264 Annex-B
+func Open(fileName string) (Reader, error) {
+	var newReader func(io.ReadSeeker) (Reader, error)
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".ivf":
+		newReader = NewIVFReader
+	case ".ogg", ".opus":
+		newReader = NewOggReader
+	case ".h264", ".264":
+		newReader = func(in io.ReadSeeker) (Reader, error) {
+			return NewH264Reader(in, defaultFrameDuration)
+		}
+	default:
+		return nil, errUnknownContainer
+	}
+
+	file, err := os.Open(fileName) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := newReader(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return reader, nil
+}
+
+// rewind seeks in back to its start
+func rewind(in io.ReadSeeker) error {
+	_, err := in.Seek(0, io.SeekStart)
+	return err
+}
+
+// closeStream closes in if it is an io.Closer
+func closeStream(in io.ReadSeeker) error {
+	if closer, ok := in.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package player
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/pkg/media/oggwriter"
+	"github.com/stretchr/testify/assert"
+)
+
+// buildIVF returns an IVF file at 30 frames per second
+func buildIVF(fourCC string, timestamps []uint64, frames ...[]byte) []byte {
+	ivf := make([]byte, 32)
+	copy(ivf, "DKIF")
+	binary.LittleEndian.PutUint16(ivf[6:], 32)
+	copy(ivf[8:], fourCC)
+	binary.LittleEndian.PutUint32(ivf[16:], 30)
+	binary.LittleEndian.PutUint32(ivf[20:], 1)
+	binary.LittleEndian.PutUint32(ivf[24:], uint32(len(frames)))
+
+	for i, frame := range frames {
+		ivf = binary.LittleEndian.AppendUint32(ivf, uint32(len(frame)))
+		ivf = binary.LittleEndian.AppendUint64(ivf, timestamps[i])
+		ivf = append(ivf, frame...)
+	}
+	return ivf
+}
+
+func TestIVFReader(t *testing.T) {
+	reader, err := NewIVFReader(bytes.NewReader(buildIVF("VP80", []uint64{0, 1, 3},
+		[]byte{0x10, 0x02}, []byte{0x11, 0x03}, []byte{0x11, 0x04})))
+	assert.NoError(t, err)
+
+	for pass := 0; pass < 2; pass++ {
+		for _, expected := range []struct {
+			data       byte
+			timestamp  time.Duration
+			duration   time.Duration
+			isKeyFrame bool
+		}{
+			{0x02, 0, time.Second / 30, true},
+			{0x03, time.Second / 30, 2 * time.Second / 30, false},
+			{0x04, 3 * time.Second / 30, 2 * time.Second / 30, false},
+		} {
+			frame, err := reader.NextFrame()
+			assert.NoError(t, err)
+			assert.Len(t, frame.Samples, 1)
+			assert.Equal(t, expected.data, frame.Samples[0].Data[1])
+			assert.Equal(t, frame.Duration, frame.Samples[0].Duration)
+			assert.InDelta(t, expected.timestamp, frame.Timestamp, 1)
+			assert.InDelta(t, expected.duration, frame.Duration, 1)
+			assert.Equal(t, expected.isKeyFrame, frame.IsKeyFrame)
+		}
+
+		_, err = reader.NextFrame()
+		assert.ErrorIs(t, err, io.EOF)
+		assert.NoError(t, reader.Rewind())
+	}
+
+	_, err = NewIVFReader(bytes.NewReader(buildIVF("H264", nil)))
+	assert.ErrorIs(t, err, errUnsupportedFourCC)
+	_, err = NewIVFReader(nil)
+	assert.ErrorIs(t, err, errNilStream)
+}
+
+func TestIVFReader_AV1(t *testing.T) {
+	var (
+		temporalDelimiter = []byte{0x12, 0x00}
+		sequenceHeader    = []byte{0x0A, 0x09, 0x04, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x7B}
+		keyFrame          = []byte{0x32, 0x02, 0x10, 0xAA}
+		interFrame        = []byte{0x32, 0x02, 0x30, 0xBB}
+	)
+
+	reader, err := NewIVFReader(bytes.NewReader(buildIVF("AV01", []uint64{0, 1, 2},
+		bytes.Join([][]byte{temporalDelimiter, sequenceHeader, keyFrame}, nil),
+		bytes.Join([][]byte{temporalDelimiter, interFrame}, nil),
+		bytes.Join([][]byte{temporalDelimiter, keyFrame}, nil),
+	)))
+	assert.NoError(t, err)
+
+	// Key frames are detected with the sequence header of an earlier frame
+	for _, expected := range []struct {
+		samples    int
+		isKeyFrame bool
+	}{
+		{2, true},
+		{1, false},
+		{1, true},
+	} {
+		frame, err := reader.NextFrame()
+		assert.NoError(t, err)
+		assert.Len(t, frame.Samples, expected.samples)
+		assert.Equal(t, expected.isKeyFrame, frame.IsKeyFrame)
+	}
+}
+
+func TestIVFReader_VP9Superframe(t *testing.T) {
+	// A hidden key frame and a shown frame in a superframe, with 1 byte
+	// sizes
+	superframe := []byte{0x82, 0x01, 0x86, 0x02, 0xC1, 0x02, 0x02, 0xC1}
+
+	reader, err := NewIVFReader(bytes.NewReader(buildIVF("VP90", []uint64{0}, superframe)))
+	assert.NoError(t, err)
+
+	frame, err := reader.NextFrame()
+	assert.NoError(t, err)
+	assert.True(t, frame.IsKeyFrame)
+	assert.Len(t, frame.Samples, 2)
+	assert.Equal(t, []byte{0x82, 0x01}, frame.Samples[0].Data)
+	assert.Equal(t, time.Duration(0), frame.Samples[0].Duration)
+	assert.Equal(t, []byte{0x86, 0x02}, frame.Samples[1].Data)
+	assert.Equal(t, frame.Duration, frame.Samples[1].Duration)
+}
+
+func TestIsVP9KeyFrame(t *testing.T) {
+	for _, test := range []struct {
+		frame      []byte
+		isKeyFrame bool
+	}{
+		{nil, false},
+		{[]byte{0x82}, true},  // Profile 0 key frame
+		{[]byte{0x86}, false}, // Profile 0 inter frame
+		{[]byte{0x88}, false}, // Show existing frame
+		{[]byte{0xB0}, true},  // Profile 3 key frame
+		{[]byte{0xB2}, false}, // Profile 3 inter frame
+		{[]byte{0x02}, false}, // Invalid frame marker
+	} {
+		assert.Equal(t, test.isKeyFrame, isVP9KeyFrame(test.frame), test.frame)
+	}
+}
+
+func TestOggReader(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test.opus")
+	writer, err := oggwriter.New(fileName, 48000, 2, oggwriter.WithPreSkip(480))
+	assert.NoError(t, err)
+	for i := uint32(0); i < 3; i++ {
+		assert.NoError(t, writer.WriteRTP(&rtp.Packet{
+			Header:  rtp.Header{Timestamp: 960 * i},
+			Payload: []byte{0xFC, byte(i)},
+		}))
+	}
+	assert.NoError(t, writer.Close())
+
+	reader, err := Open(fileName)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		frame, err := reader.NextFrame()
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0xFC, byte(i)}, frame.Samples[0].Data)
+		assert.Equal(t, time.Duration(i)*20*time.Millisecond-10*time.Millisecond, frame.Timestamp)
+		assert.Equal(t, 20*time.Millisecond, frame.Duration)
+		assert.True(t, frame.IsKeyFrame)
+	}
+
+	_, err = reader.NextFrame()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.NoError(t, reader.Close())
+}
+
+func TestOpen(t *testing.T) {
+	_, err := Open("test.mp4")
+	assert.ErrorIs(t, err, errUnknownContainer)
+
+	_, err = Open(filepath.Join(t.TempDir(), "missing.ivf"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = NewH264Reader(bytes.NewReader(nil), 0)
+	assert.ErrorIs(t, err, errInvalidFrameDuration)
+}
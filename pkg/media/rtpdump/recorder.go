@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package rtpdump
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+// RTPReader reads RTP packets, it is implemented by webrtc.TrackRemote
+type RTPReader interface {
+	ReadRTP() (*rtp.Packet, interceptor.Attributes, error)
+}
+
+// RTCPReader reads RTCP packets, it is implemented by webrtc.RTPReceiver
+type RTCPReader interface {
+	ReadRTCP() ([]rtcp.Packet, interceptor.Attributes, error)
+}
+
+// Recorder records RTP and RTCP packets to an rtpdump file. The Offset of
+// the packets is the time since the Start of the Header, read from a
+// single clock so RTP and RTCP packets stay in order.
+type Recorder struct {
+	writer *Writer
+	start  time.Time
+
+	// writeMu makes the offsets of the packets written increase
+	writeMu sync.Mutex
+
+	wg    sync.WaitGroup
+	errMu sync.Mutex
+	err   error
+}
+
+// NewRecorder makes a new Recorder and writes the Header to w. The Start
+// of the Header is set to now if it is zero, and the Source to 0.0.0.0 if
+// it is nil.
+func NewRecorder(w io.Writer, hdr Header) (*Recorder, error) {
+	if hdr.Start.IsZero() {
+		hdr.Start = time.Now()
+	}
+	if hdr.Source == nil {
+		hdr.Source = net.IPv4zero
+	}
+
+	writer, err := NewWriter(w, hdr)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{writer: writer, start: hdr.Start}, nil
+}
+
+// RecordRTP records the packets read from track, for instance a
+// webrtc.TrackRemote, until it returns an error
+func (r *Recorder) RecordRTP(track RTPReader) {
+	r.record(func() error {
+		packet, _, err := track.ReadRTP()
+		if err != nil {
+			return err
+		}
+		return r.WriteRTP(packet)
+	})
+}
+
+// RecordRTCP records the packets read from receiver, for instance a
+// webrtc.RTPReceiver, until it returns an error
+func (r *Recorder) RecordRTCP(receiver RTCPReader) {
+	r.record(func() error {
+		packets, _, err := receiver.ReadRTCP()
+		if err != nil {
+			return err
+		}
+		return r.WriteRTCP(packets)
+	})
+}
+
+// WriteRTP records an RTP packet at the current offset
+func (r *Recorder) WriteRTP(packet *rtp.Packet) error {
+	payload, err := packet.Marshal()
+	if err != nil {
+		return err
+	}
+	return r.write(payload, false)
+}
+
+// WriteRTCP records a compound RTCP packet at the current offset
+func (r *Recorder) WriteRTCP(packets []rtcp.Packet) error {
+	payload, err := rtcp.Marshal(packets)
+	if err != nil {
+		return err
+	}
+	return r.write(payload, true)
+}
+
+// write takes the offset under the same lock as the write, so the packets
+// of concurrent readers are written in the order of their offsets
+func (r *Recorder) write(payload []byte, isRTCP bool) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	return r.writer.WritePacket(Packet{Offset: time.Since(r.start), IsRTCP: isRTCP, Payload: payload})
+}
+
+// Wait waits until the readers passed to RecordRTP and RecordRTCP end, and
+// returns the first error other than io.EOF
+func (r *Recorder) Wait() error {
+	r.wg.Wait()
+
+	r.errMu.Lock()
+	defer r.errMu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) record(readAndWrite func() error) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		for {
+			err := readAndWrite()
+			if errors.Is(err, io.EOF) {
+				return
+			} else if err != nil {
+				r.errMu.Lock()
+				if r.err == nil {
+					r.err = err
+				}
+				r.errMu.Unlock()
+				return
+			}
+		}
+	}()
+}
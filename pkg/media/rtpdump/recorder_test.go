@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package rtpdump
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+type testRTPReader struct {
+	packets []*rtp.Packet
+	err     error
+}
+
+func (r *testRTPReader) ReadRTP() (*rtp.Packet, interceptor.Attributes, error) {
+	if len(r.packets) == 0 {
+		return nil, nil, r.err
+	}
+	packet := r.packets[0]
+	r.packets = r.packets[1:]
+	return packet, nil, nil
+}
+
+type testRTCPReader struct {
+	packets [][]rtcp.Packet
+}
+
+func (r *testRTCPReader) ReadRTCP() ([]rtcp.Packet, interceptor.Attributes, error) {
+	if len(r.packets) == 0 {
+		return nil, nil, io.EOF
+	}
+	packets := r.packets[0]
+	r.packets = r.packets[1:]
+	return packets, nil, nil
+}
+
+func TestRecorder(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	recorder, err := NewRecorder(buf, Header{Port: 5000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rtpPackets := []*rtp.Packet{
+		{Header: rtp.Header{Version: 2, SequenceNumber: 1, SSRC: 0x1234}, Payload: []byte{0x01}},
+		{Header: rtp.Header{Version: 2, SequenceNumber: 2, SSRC: 0x1234}, Payload: []byte{0x02}},
+	}
+	rtcpPackets := []rtcp.Packet{&rtcp.PictureLossIndication{SenderSSRC: 1, MediaSSRC: 0x1234}}
+
+	recorder.RecordRTP(&testRTPReader{packets: append([]*rtp.Packet{}, rtpPackets...), err: io.EOF})
+	recorder.RecordRTCP(&testRTCPReader{packets: [][]rtcp.Packet{rtcpPackets}})
+	if err = recorder.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, hdr, err := NewReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdr.Port != 5000 || hdr.Start.IsZero() {
+		t.Fatalf("unexpected header %v", hdr)
+	}
+
+	var (
+		gotRTP, gotRTCP int
+		lastOffset      time.Duration
+	)
+	for {
+		packet, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		// RTP and RTCP packets are recorded in the order of their offsets
+		if packet.Offset < lastOffset {
+			t.Fatalf("offset %v after %v", packet.Offset, lastOffset)
+		}
+		lastOffset = packet.Offset
+
+		if packet.IsRTCP {
+			got, err := rtcp.Unmarshal(packet.Payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, rtcpPackets) {
+				t.Fatalf("RTCP packets %v, want %v", got, rtcpPackets)
+			}
+			gotRTCP++
+			continue
+		}
+
+		got := &rtp.Packet{}
+		if err := got.Unmarshal(packet.Payload); err != nil {
+			t.Fatal(err)
+		}
+		if got.SequenceNumber != rtpPackets[gotRTP].SequenceNumber ||
+			!bytes.Equal(got.Payload, rtpPackets[gotRTP].Payload) {
+			t.Fatalf("RTP packet %v, want %v", got, rtpPackets[gotRTP])
+		}
+		gotRTP++
+	}
+
+	if gotRTP != 2 || gotRTCP != 1 {
+		t.Fatalf("recorded %d RTP and %d RTCP packets, want 2 and 1", gotRTP, gotRTCP)
+	}
+}
+
+func TestRecorder_ReadError(t *testing.T) {
+	errRead := errors.New("read error")
+
+	recorder, err := NewRecorder(bytes.NewBuffer(nil), Header{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder.RecordRTP(&testRTPReader{err: errRead})
+	if err := recorder.Wait(); !errors.Is(err, errRead) {
+		t.Fatalf("Wait() = %v, want %v", err, errRead)
+	}
+}
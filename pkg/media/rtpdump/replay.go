@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package rtpdump
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+var errInvalidSpeed = errors.New("replay speed must be positive")
+
+// RTPWriter writes RTP packets, it is implemented by
+// webrtc.TrackLocalStaticRTP
+type RTPWriter interface {
+	WriteRTP(packet *rtp.Packet) error
+}
+
+type replayConfig struct {
+	speed       float64
+	ssrc        *uint32
+	payloadType *uint8
+}
+
+// ReplayOption configures Replay
+type ReplayOption func(c *replayConfig) error
+
+// WithSpeed replays packets speed times faster than recorded. The RTP
+// timestamps are scaled by the same factor, so they keep advancing at the
+// rate packets are sent.
+func WithSpeed(speed float64) ReplayOption {
+	return func(c *replayConfig) error {
+		if speed <= 0 {
+			return errInvalidSpeed
+		}
+		c.speed = speed
+		return nil
+	}
+}
+
+// WithSSRC replaces the SSRC of the replayed packets
+func WithSSRC(ssrc uint32) ReplayOption {
+	return func(c *replayConfig) error {
+		c.ssrc = &ssrc
+		return nil
+	}
+}
+
+// WithPayloadType replaces the payload type of the replayed packets
+func WithPayloadType(payloadType uint8) ReplayOption {
+	return func(c *replayConfig) error {
+		c.payloadType = &payloadType
+		return nil
+	}
+}
+
+// Replay writes the RTP packets read from reader to writer, for instance a
+// webrtc.TrackLocalStaticRTP, with the timing of their Offset. RTCP packets
+// are skipped. Replay returns at the end of the file, or when ctx is done.
+//
+// The packets keep their recorded SSRC and payload type unless WithSSRC or
+// WithPayloadType replace them. A webrtc.TrackLocalStaticRTP replaces both
+// again with the ones negotiated for each of its bindings, the options apply
+// to other writers.
+func Replay(ctx context.Context, reader *Reader, writer RTPWriter, opts ...ReplayOption) error {
+	config := &replayConfig{speed: 1}
+	for _, o := range opts {
+		if err := o(config); err != nil {
+			return err
+		}
+	}
+
+	var (
+		start       time.Time
+		firstOffset time.Duration
+		// firstTimestamps are the RTP timestamps the scaling starts from,
+		// per recorded SSRC
+		firstTimestamps = map[uint32]uint32{}
+	)
+	for {
+		packet, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if packet.IsRTCP {
+			continue
+		}
+
+		rtpPacket := &rtp.Packet{}
+		if err := rtpPacket.Unmarshal(packet.Payload); err != nil {
+			return err
+		}
+
+		if config.speed != 1 {
+			firstTimestamp, ok := firstTimestamps[rtpPacket.SSRC]
+			if !ok {
+				firstTimestamp = rtpPacket.Timestamp
+				firstTimestamps[rtpPacket.SSRC] = firstTimestamp
+			}
+			// The signed difference keeps reordered packets before the
+			// first one and survives wraparound
+			elapsed := int32(rtpPacket.Timestamp - firstTimestamp)
+			rtpPacket.Timestamp = firstTimestamp + uint32(int32(float64(elapsed)/config.speed))
+		}
+		if config.ssrc != nil {
+			rtpPacket.SSRC = *config.ssrc
+		}
+		if config.payloadType != nil {
+			rtpPacket.PayloadType = *config.payloadType
+		}
+
+		// Offsets are relative to the first packet, so a recording that
+		// starts late is not delayed
+		if start.IsZero() {
+			start, firstOffset = time.Now(), packet.Offset
+		}
+		delay := time.Duration(float64(packet.Offset-firstOffset) / config.speed)
+		if err := sleepUntil(ctx, start.Add(delay)); err != nil {
+			return err
+		}
+
+		if err := writer.WriteRTP(rtpPacket); err != nil {
+			return err
+		}
+	}
+}
+
+// sleepUntil waits until t, or returns the error of ctx
+func sleepUntil(ctx context.Context, t time.Time) error {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
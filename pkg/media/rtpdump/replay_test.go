@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package rtpdump
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+type testRTPWriter struct {
+	start   time.Time
+	packets []*rtp.Packet
+	times   []time.Duration
+}
+
+func (w *testRTPWriter) WriteRTP(packet *rtp.Packet) error {
+	w.packets = append(w.packets, packet)
+	w.times = append(w.times, time.Since(w.start))
+	return nil
+}
+
+// firstTimestamp makes the RTP timestamps of buildDump wrap around
+const firstTimestamp = 0xFFFFFFFF - 90*1010
+
+// buildDump returns an rtpdump file with RTP packets at offsets, in
+// milliseconds, and an RTCP packet after the first one. The packets have
+// RTP timestamps of a 90 kHz clock.
+func buildDump(t *testing.T, offsets ...int) *Reader {
+	t.Helper()
+
+	buf := bytes.NewBuffer(nil)
+	writer, err := NewWriter(buf, Header{Start: time.Unix(9, 0), Source: net.IPv4(2, 2, 2, 2), Port: 2222})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, offset := range offsets {
+		payload, err := (&rtp.Packet{
+			Header: rtp.Header{
+				Version: 2, SequenceNumber: uint16(i), SSRC: 0x1234, PayloadType: 96,
+				Timestamp: firstTimestamp + uint32(offset*90),
+			},
+			Payload: []byte{byte(i)},
+		}).Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.WritePacket(Packet{Offset: time.Duration(offset) * time.Millisecond, Payload: payload}); err != nil {
+			t.Fatal(err)
+		}
+
+		if i == 0 {
+			rtcpPayload, err := rtcp.Marshal([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: 0x1234}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.WritePacket(Packet{Offset: time.Duration(offset) * time.Millisecond, IsRTCP: true, Payload: rtcpPayload}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	reader, _, err := NewReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reader
+}
+
+func TestReplay(t *testing.T) {
+	writer := &testRTPWriter{start: time.Now()}
+	err := Replay(context.Background(), buildDump(t, 1000, 1020, 1060), writer,
+		WithSpeed(2), WithSSRC(0x5678), WithPayloadType(111))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(writer.packets) != 3 {
+		t.Fatalf("replayed %d packets, want 3", len(writer.packets))
+	}
+	// Twice the speed halves the RTP timestamps elapsed since the first packet
+	for i, elapsed := range []uint32{0, 900, 2700} {
+		packet := writer.packets[i]
+		if packet.SequenceNumber != uint16(i) || packet.SSRC != 0x5678 || packet.PayloadType != 111 {
+			t.Fatalf("unexpected packet %v", packet)
+		}
+		if want := uint32(firstTimestamp + 90*1000 + elapsed); packet.Timestamp != want {
+			t.Fatalf("packet %d has timestamp %d, want %d", i, packet.Timestamp, want)
+		}
+	}
+
+	// Offsets are relative to the first packet, at twice the speed
+	if writer.times[0] > 10*time.Millisecond {
+		t.Fatalf("first packet replayed after %v", writer.times[0])
+	}
+	if elapsed := writer.times[2] - writer.times[0]; elapsed < 30*time.Millisecond {
+		t.Fatalf("last packet replayed after %v, want 30ms", elapsed)
+	}
+}
+
+func TestReplay_Errors(t *testing.T) {
+	if err := Replay(context.Background(), buildDump(t, 0), &testRTPWriter{}, WithSpeed(0)); !errors.Is(err, errInvalidSpeed) {
+		t.Fatalf("Replay() = %v, want %v", err, errInvalidSpeed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Replay(ctx, buildDump(t, 0, 1000), &testRTPWriter{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Replay() = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package rpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+// frameType identifies the purpose of a frame
+type frameType uint8
+
+const (
+	// frameTypeRequest starts a call
+	frameTypeRequest frameType = iota + 1
+	// frameTypeCancel aborts a call started by the sender
+	frameTypeCancel
+	// frameTypeResponse carries the result of a unary call and ends it
+	frameTypeResponse
+	// frameTypeStreamItem carries one result of a streaming call
+	frameTypeStreamItem
+	// frameTypeStreamEnd ends a streaming call successfully
+	frameTypeStreamEnd
+	// frameTypeError ends a call with the error message as payload
+	frameTypeError
+)
+
+const (
+	// type, call ID
+	frameHeaderLen = 9
+	// timeout in milliseconds, method length
+	requestHeaderLen = 5
+)
+
+var (
+	errFrameTooShort  = errors.New("rpc: frame too short")
+	errMethodTooLong  = errors.New("rpc: method name longer than 255 bytes")
+	errUnknownFrame   = errors.New("rpc: unknown frame type")
+	errMethodTooShort = errors.New("rpc: frame shorter than its method name")
+)
+
+// frame is the unit exchanged between two Conns, one frame per message
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|     Type      |                                               |
+//	+-+-+-+-+-+-+-+-+           Call ID (64 bits)                   +
+//	|                                               |               |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+               +
+//	|          Request only: Timeout in milliseconds                |
+//	+               +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|               | Method Length |    Method ...                 |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                          Payload ...                          |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+type frame struct {
+	typ    frameType
+	id     uint64
+	method string
+	// timeout is the remaining time of a request, zero means none
+	timeout time.Duration
+	payload []byte
+}
+
+func (f *frame) marshal() ([]byte, error) {
+	size := frameHeaderLen + len(f.payload)
+	if f.typ == frameTypeRequest {
+		if len(f.method) > math.MaxUint8 {
+			return nil, errMethodTooLong
+		}
+		size += requestHeaderLen + len(f.method)
+	}
+
+	out := make([]byte, frameHeaderLen, size)
+	out[0] = byte(f.typ)
+	binary.BigEndian.PutUint64(out[1:], f.id)
+
+	if f.typ == frameTypeRequest {
+		timeout := f.timeout.Milliseconds()
+		switch {
+		case timeout > math.MaxUint32:
+			timeout = math.MaxUint32
+		case timeout <= 0 && f.timeout > 0:
+			// Keep sub-millisecond deadlines from turning into no deadline
+			timeout = 1
+		}
+
+		out = out[:frameHeaderLen+requestHeaderLen]
+		binary.BigEndian.PutUint32(out[frameHeaderLen:], uint32(timeout))
+		out[frameHeaderLen+4] = uint8(len(f.method))
+		out = append(out, f.method...)
+	}
+
+	return append(out, f.payload...), nil
+}
+
+func (f *frame) unmarshal(data []byte) error {
+	if len(data) < frameHeaderLen {
+		return errFrameTooShort
+	}
+
+	f.typ = frameType(data[0])
+	f.id = binary.BigEndian.Uint64(data[1:])
+	data = data[frameHeaderLen:]
+
+	switch f.typ {
+	case frameTypeRequest:
+		if len(data) < requestHeaderLen {
+			return errFrameTooShort
+		}
+
+		f.timeout = time.Duration(binary.BigEndian.Uint32(data)) * time.Millisecond
+		methodLen := int(data[4])
+		data = data[requestHeaderLen:]
+		if len(data) < methodLen {
+			return errMethodTooShort
+		}
+
+		f.method = string(data[:methodLen])
+		data = data[methodLen:]
+	case frameTypeCancel, frameTypeResponse, frameTypeStreamItem, frameTypeStreamEnd, frameTypeError:
+	default:
+		return errUnknownFrame
+	}
+
+	f.payload = data
+	return nil
+}
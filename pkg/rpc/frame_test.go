@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package rpc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrame_RoundTrip(t *testing.T) {
+	for _, f := range []frame{
+		{typ: frameTypeRequest, id: 1, method: "echo", timeout: 1500 * time.Millisecond, payload: []byte(`"hi"`)},
+		{typ: frameTypeRequest, id: 2, method: "noDeadline", payload: []byte{}},
+		{typ: frameTypeCancel, id: 3, payload: []byte{}},
+		{typ: frameTypeResponse, id: 1 << 40, payload: []byte(`{}`)},
+		{typ: frameTypeStreamItem, id: 4, payload: []byte(`1`)},
+		{typ: frameTypeStreamEnd, id: 4, payload: []byte{}},
+		{typ: frameTypeError, id: 5, payload: []byte("failed")},
+	} {
+		data, err := f.marshal()
+		assert.NoError(t, err)
+
+		var parsed frame
+		assert.NoError(t, parsed.unmarshal(data))
+		assert.Equal(t, f, parsed)
+	}
+}
+
+func TestFrame_Timeout(t *testing.T) {
+	data, err := (&frame{typ: frameTypeRequest, timeout: time.Microsecond}).marshal()
+	assert.NoError(t, err)
+
+	var parsed frame
+	assert.NoError(t, parsed.unmarshal(data))
+	assert.Equal(t, time.Millisecond, parsed.timeout)
+}
+
+func TestFrame_Invalid(t *testing.T) {
+	_, err := (&frame{typ: frameTypeRequest, method: strings.Repeat("a", 256)}).marshal()
+	assert.ErrorIs(t, err, errMethodTooLong)
+
+	var f frame
+	assert.ErrorIs(t, f.unmarshal([]byte{byte(frameTypeResponse), 0, 0}), errFrameTooShort)
+	assert.ErrorIs(t, f.unmarshal([]byte{byte(frameTypeRequest), 0, 0, 0, 0, 0, 0, 0, 1}), errFrameTooShort)
+	assert.ErrorIs(t, f.unmarshal([]byte{byte(frameTypeRequest), 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 4, 'a'}), errMethodTooShort)
+	assert.ErrorIs(t, f.unmarshal([]byte{0xff, 0, 0, 0, 0, 0, 0, 0, 1}), errUnknownFrame)
+}
@@ -0,0 +1,563 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package rpc implements request/response calls on top of a DataChannel.
+// Both peers wrap their end of the DataChannel in a Conn, register the
+// methods they serve and call the methods registered by the remote peer.
+// Calls carry their deadline to the remote peer, and are cancelled there
+// when the caller's context is done. Every incoming call is served in its
+// own goroutine, WithMaxConcurrentCalls limits how many run at the same
+// time. Every frame is sent as one DataChannel message, so parameters and
+// results must fit the SCTP max message size.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+)
+
+const (
+	// defaultReadBufferSize is large enough for any message Chromium sends
+	defaultReadBufferSize = 65535
+
+	// streamBufferSize is how many results of a streaming call are held
+	// until they are received, the call is cancelled once it is exceeded
+	streamBufferSize = 16
+)
+
+var (
+	// ErrClosed is returned by calls when the Conn or its DataChannel closed
+	ErrClosed = errors.New("rpc: connection closed")
+
+	// ErrStreamClosed is returned by ClientStream.Recv after Close
+	ErrStreamClosed = errors.New("rpc: stream closed")
+
+	// ErrStreamOverflow is returned by ClientStream.Recv when results arrived
+	// faster than they were received, the call is cancelled then
+	ErrStreamOverflow = errors.New("rpc: stream results not received in time")
+
+	errUnexpectedFrame = errors.New("rpc: unexpected frame for call")
+	errUnknownMethod   = errors.New("rpc: unknown method")
+	errTooManyCalls    = errors.New("rpc: too many concurrent calls")
+)
+
+// Error is returned to the caller when the remote handler failed, it
+// carries the message of the remote error.
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Handler serves a unary call. The result is encoded with the Codec and
+// returned to the caller. ctx is done when the caller cancelled the call, its
+// deadline passed or the Conn closed.
+type Handler func(ctx context.Context, req *Request) (interface{}, error)
+
+// StreamHandler serves a streaming call. It sends any number of results
+// with ServerStream.Send, returning ends the stream.
+type StreamHandler func(ctx context.Context, req *Request, stream *ServerStream) error
+
+// Request is an incoming call
+type Request struct {
+	Method string
+
+	payload []byte
+	codec   Codec
+}
+
+// Decode decodes the parameters of the call into v
+func (r *Request) Decode(v interface{}) error {
+	return r.codec.Unmarshal(r.payload, v)
+}
+
+// ServerStream sends the results of a streaming call
+type ServerStream struct {
+	conn *Conn
+	id   uint64
+	ctx  context.Context //nolint:containedctx
+}
+
+// Send encodes v with the Codec and sends it to the caller
+func (s *ServerStream) Send(v interface{}) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	payload, err := s.conn.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.conn.sendFrame(&frame{typ: frameTypeStreamItem, id: s.id, payload: payload})
+}
+
+// Option configures a Conn
+type Option func(*Conn)
+
+// WithCodec sets the Codec of parameters and results, the default is JSONCodec
+func WithCodec(codec Codec) Option {
+	return func(c *Conn) {
+		c.codec = codec
+	}
+}
+
+// WithReadBufferSize sets the largest message NewDetachedConn can read,
+// it must be raised with SettingEngine.SetSCTPMaxMessageSize
+func WithReadBufferSize(size int) Option {
+	return func(c *Conn) {
+		c.readBufferSize = size
+	}
+}
+
+// WithMaxConcurrentCalls limits how many calls of the remote peer are served
+// at the same time, further calls fail right away. By default every call is
+// served in its own goroutine without a limit.
+func WithMaxConcurrentCalls(n int) Option {
+	return func(c *Conn) {
+		c.maxConcurrentCalls = n
+	}
+}
+
+// call is an outgoing call waiting for frames from the remote peer
+type call struct {
+	id        uint64
+	frames    chan *frame
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// overflow is closed once a frame didn't fit in frames
+	overflow     chan struct{}
+	overflowOnce sync.Once
+}
+
+// Conn serves and makes calls over a DataChannel
+type Conn struct {
+	codec              Codec
+	readBufferSize     int
+	maxConcurrentCalls int
+	send               func([]byte) error
+	closer             io.Closer
+
+	mu             sync.Mutex
+	handlers       map[string]Handler
+	streamHandlers map[string]StreamHandler
+	calls          map[uint64]*call
+	served         map[uint64]context.CancelFunc
+	nextID         uint64
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewConn creates a Conn on an open DataChannel. It takes over the
+// OnMessage and OnClose handlers of the DataChannel.
+func NewConn(dc *webrtc.DataChannel, opts ...Option) *Conn {
+	c := newConn(dc.Send, dc, opts)
+
+	dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+		c.handleMessage(msg.Data)
+	})
+	dc.OnClose(c.shutdown)
+
+	return c
+}
+
+// NewDetachedConn creates a Conn on a detached DataChannel, or anything
+// else that preserves message boundaries.
+func NewDetachedConn(rwc io.ReadWriteCloser, opts ...Option) *Conn {
+	c := newConn(func(data []byte) error {
+		_, err := rwc.Write(data)
+		return err
+	}, rwc, opts)
+
+	go c.readLoop(rwc)
+
+	return c
+}
+
+func newConn(send func([]byte) error, closer io.Closer, opts []Option) *Conn {
+	c := &Conn{
+		codec:          JSONCodec{},
+		readBufferSize: defaultReadBufferSize,
+		send:           send,
+		closer:         closer,
+		handlers:       map[string]Handler{},
+		streamHandlers: map[string]StreamHandler{},
+		calls:          map[uint64]*call{},
+		served:         map[uint64]context.CancelFunc{},
+		done:           make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *Conn) readLoop(r io.Reader) {
+	defer c.shutdown()
+
+	buffer := make([]byte, c.readBufferSize)
+	for {
+		n, err := r.Read(buffer)
+		if err != nil {
+			return
+		}
+
+		data := make([]byte, n)
+		copy(data, buffer[:n])
+		c.handleMessage(data)
+	}
+}
+
+// Register serves method with h, replacing a previously registered handler
+func (c *Conn) Register(method string, h Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.streamHandlers, method)
+	c.handlers[method] = h
+}
+
+// RegisterStream serves the streaming method with h, replacing a previously
+// registered handler
+func (c *Conn) RegisterStream(method string, h StreamHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.handlers, method)
+	c.streamHandlers[method] = h
+}
+
+// Call calls method on the remote peer and decodes its result into result,
+// which may be nil to discard it. A remote failure is returned as *Error.
+func (c *Conn) Call(ctx context.Context, method string, params, result interface{}) error {
+	cl, err := c.startCall(ctx, method, params, 1)
+	if err != nil {
+		return err
+	}
+	defer c.finishCall(cl)
+
+	select {
+	case f := <-cl.frames:
+		switch f.typ {
+		case frameTypeResponse:
+			if result == nil {
+				return nil
+			}
+			return c.codec.Unmarshal(f.payload, result)
+		case frameTypeError:
+			return &Error{Message: string(f.payload)}
+		default:
+			return errUnexpectedFrame
+		}
+	case <-ctx.Done():
+		c.cancelCall(cl)
+		return ctx.Err()
+	case <-c.done:
+		return ErrClosed
+	}
+}
+
+// Stream calls the streaming method on the remote peer, the results are read
+// with ClientStream.Recv.
+func (c *Conn) Stream(ctx context.Context, method string, params interface{}) (*ClientStream, error) {
+	cl, err := c.startCall(ctx, method, params, streamBufferSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClientStream{conn: c, call: cl, ctx: ctx, closed: make(chan struct{})}, nil
+}
+
+func (c *Conn) startCall(ctx context.Context, method string, params interface{}, bufferSize int) (*call, error) {
+	var timeout time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		if timeout = time.Until(deadline); timeout <= 0 {
+			return nil, context.DeadlineExceeded
+		}
+	}
+
+	payload, err := c.codec.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	select {
+	case <-c.done:
+		c.mu.Unlock()
+		return nil, ErrClosed
+	default:
+	}
+	c.nextID++
+	cl := &call{
+		id:       c.nextID,
+		frames:   make(chan *frame, bufferSize),
+		done:     make(chan struct{}),
+		overflow: make(chan struct{}),
+	}
+	c.calls[cl.id] = cl
+	c.mu.Unlock()
+
+	if err = c.sendFrame(&frame{
+		typ:     frameTypeRequest,
+		id:      cl.id,
+		method:  method,
+		timeout: timeout,
+		payload: payload,
+	}); err != nil {
+		c.finishCall(cl)
+		return nil, err
+	}
+
+	return cl, nil
+}
+
+func (c *Conn) finishCall(cl *call) {
+	cl.closeOnce.Do(func() {
+		c.mu.Lock()
+		delete(c.calls, cl.id)
+		c.mu.Unlock()
+
+		close(cl.done)
+	})
+}
+
+// cancelCall tells the remote peer to stop serving cl
+func (c *Conn) cancelCall(cl *call) {
+	_ = c.sendFrame(&frame{typ: frameTypeCancel, id: cl.id}) //nolint:errcheck
+}
+
+func (c *Conn) sendFrame(f *frame) error {
+	data, err := f.marshal()
+	if err != nil {
+		return err
+	}
+	return c.send(data)
+}
+
+func (c *Conn) handleMessage(data []byte) {
+	f := &frame{}
+	if err := f.unmarshal(data); err != nil {
+		return
+	}
+
+	switch f.typ {
+	case frameTypeRequest:
+		c.serve(f)
+	case frameTypeCancel:
+		c.mu.Lock()
+		cancel := c.served[f.id]
+		c.mu.Unlock()
+
+		if cancel != nil {
+			cancel()
+		}
+	default:
+		c.mu.Lock()
+		cl := c.calls[f.id]
+		c.mu.Unlock()
+
+		if cl == nil {
+			return
+		}
+
+		// Messages of all calls are read in order, so a call that doesn't
+		// receive its results in time is cancelled instead of stalling the
+		// others
+		select {
+		case <-cl.overflow:
+		case cl.frames <- f:
+		default:
+			cl.overflowOnce.Do(func() {
+				close(cl.overflow)
+				c.cancelCall(cl)
+			})
+		}
+	}
+}
+
+func (c *Conn) serve(f *frame) {
+	c.mu.Lock()
+	handler := c.handlers[f.method]
+	streamHandler := c.streamHandlers[f.method]
+	if _, ok := c.served[f.id]; ok {
+		c.mu.Unlock()
+		return
+	} else if handler == nil && streamHandler == nil {
+		c.mu.Unlock()
+		c.sendError(f.id, fmt.Errorf("%w %q", errUnknownMethod, f.method))
+		return
+	} else if c.maxConcurrentCalls > 0 && len(c.served) >= c.maxConcurrentCalls {
+		c.mu.Unlock()
+		c.sendError(f.id, errTooManyCalls)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if f.timeout != 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), f.timeout)
+	}
+	c.served[f.id] = cancel
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.served, f.id)
+			c.mu.Unlock()
+
+			cancel()
+		}()
+
+		// The caller gives up on its own once the context is done, so the
+		// outcome of the handler isn't returned then
+		req := &Request{Method: f.method, payload: f.payload, codec: c.codec}
+		if streamHandler != nil {
+			err := streamHandler(ctx, req, &ServerStream{conn: c, id: f.id, ctx: ctx})
+			switch {
+			case ctx.Err() != nil:
+			case err != nil:
+				c.sendError(f.id, err)
+			default:
+				_ = c.sendFrame(&frame{typ: frameTypeStreamEnd, id: f.id}) //nolint:errcheck
+			}
+			return
+		}
+
+		result, err := handler(ctx, req)
+		if ctx.Err() != nil {
+			return
+		} else if err != nil {
+			c.sendError(f.id, err)
+			return
+		}
+
+		payload, err := c.codec.Marshal(result)
+		if err != nil {
+			c.sendError(f.id, err)
+			return
+		}
+		_ = c.sendFrame(&frame{typ: frameTypeResponse, id: f.id, payload: payload}) //nolint:errcheck
+	}()
+}
+
+// sendError ends the call id with err, the caller is gone if it fails
+func (c *Conn) sendError(id uint64, err error) {
+	_ = c.sendFrame(&frame{typ: frameTypeError, id: id, payload: []byte(err.Error())}) //nolint:errcheck
+}
+
+// Close closes the DataChannel, pending calls return ErrClosed and the
+// contexts of served calls are cancelled.
+func (c *Conn) Close() error {
+	err := c.closer.Close()
+	c.shutdown()
+
+	return err
+}
+
+// Done is closed once the Conn or its DataChannel closed
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Conn) shutdown() {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		close(c.done)
+		for _, cancel := range c.served {
+			cancel()
+		}
+		c.mu.Unlock()
+	})
+}
+
+// ClientStream receives the results of a streaming call. Recv must not be
+// called concurrently.
+type ClientStream struct {
+	conn *Conn
+	call *call
+	ctx  context.Context //nolint:containedctx
+	err  error
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// Recv decodes the next result into v. It returns io.EOF once the remote
+// handler returned successfully, a remote failure is returned as *Error.
+func (s *ClientStream) Recv(v interface{}) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	select {
+	case <-s.closed:
+		s.err = ErrStreamClosed
+		return s.err
+	default:
+	}
+
+	// The results received before an overflow are returned first
+	var f *frame
+	select {
+	case f = <-s.call.frames:
+	default:
+		select {
+		case f = <-s.call.frames:
+		case <-s.call.overflow:
+			s.err = ErrStreamOverflow
+		case <-s.ctx.Done():
+			s.conn.cancelCall(s.call)
+			s.err = s.ctx.Err()
+		case <-s.closed:
+			s.err = ErrStreamClosed
+		case <-s.conn.done:
+			s.err = ErrClosed
+		}
+	}
+
+	if f != nil {
+		switch f.typ {
+		case frameTypeStreamItem:
+			if v == nil {
+				return nil
+			}
+			return s.conn.codec.Unmarshal(f.payload, v)
+		case frameTypeStreamEnd:
+			s.err = io.EOF
+		case frameTypeError:
+			s.err = &Error{Message: string(f.payload)}
+		default:
+			s.err = errUnexpectedFrame
+		}
+	}
+
+	s.conn.finishCall(s.call)
+	return s.err
+}
+
+// Close stops receiving results and cancels the call if it is still running.
+// It may be called concurrently with Recv.
+func (s *ClientStream) Close() error {
+	s.closeOnce.Do(func() {
+		select {
+		case <-s.call.done:
+		default:
+			s.conn.cancelCall(s.call)
+		}
+		close(s.closed)
+		s.conn.finishCall(s.call)
+	})
+
+	return nil
+}
@@ -0,0 +1,355 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type echoParams struct {
+	Text  string `json:"text"`
+	Count int    `json:"count"`
+}
+
+// connectPair connects two PeerConnections and returns both ends of a
+// DataChannel once they are open
+func connectPair(t *testing.T, api *webrtc.API) (offer, answer *webrtc.DataChannel, closePair func()) {
+	t.Helper()
+
+	offerPC, err := api.NewPeerConnection(webrtc.Configuration{})
+	assert.NoError(t, err)
+	answerPC, err := api.NewPeerConnection(webrtc.Configuration{})
+	assert.NoError(t, err)
+
+	answerChannel := make(chan *webrtc.DataChannel, 1)
+	answerPC.OnDataChannel(func(d *webrtc.DataChannel) {
+		d.OnOpen(func() {
+			answerChannel <- d
+		})
+	})
+
+	offer, err = offerPC.CreateDataChannel("rpc", nil)
+	assert.NoError(t, err)
+	offerOpened := make(chan struct{})
+	offer.OnOpen(func() {
+		close(offerOpened)
+	})
+
+	offerDesc, err := offerPC.CreateOffer(nil)
+	assert.NoError(t, err)
+	offerGatheringComplete := webrtc.GatheringCompletePromise(offerPC)
+	assert.NoError(t, offerPC.SetLocalDescription(offerDesc))
+	<-offerGatheringComplete
+
+	assert.NoError(t, answerPC.SetRemoteDescription(*offerPC.LocalDescription()))
+	answerDesc, err := answerPC.CreateAnswer(nil)
+	assert.NoError(t, err)
+	answerGatheringComplete := webrtc.GatheringCompletePromise(answerPC)
+	assert.NoError(t, answerPC.SetLocalDescription(answerDesc))
+	<-answerGatheringComplete
+	assert.NoError(t, offerPC.SetRemoteDescription(*answerPC.LocalDescription()))
+
+	<-offerOpened
+	answer = <-answerChannel
+
+	return offer, answer, func() {
+		assert.NoError(t, offerPC.Close())
+		assert.NoError(t, answerPC.Close())
+	}
+}
+
+func TestConn_Call(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offer, answer, closePair := connectPair(t, webrtc.NewAPI())
+	defer closePair()
+
+	client, server := NewConn(offer), NewConn(answer)
+	server.Register("echo", func(_ context.Context, req *Request) (interface{}, error) {
+		var params echoParams
+		if err := req.Decode(&params); err != nil {
+			return nil, err
+		}
+		params.Count++
+		return params, nil
+	})
+	server.Register("fail", func(context.Context, *Request) (interface{}, error) {
+		return nil, errors.New("handler failed") //nolint:goerr113
+	})
+
+	var result echoParams
+	assert.NoError(t, client.Call(context.Background(), "echo", echoParams{Text: "hello", Count: 1}, &result))
+	assert.Equal(t, echoParams{Text: "hello", Count: 2}, result)
+
+	var rpcErr *Error
+	assert.ErrorAs(t, client.Call(context.Background(), "fail", nil, nil), &rpcErr)
+	assert.Equal(t, "handler failed", rpcErr.Message)
+
+	assert.ErrorAs(t, client.Call(context.Background(), "missing", nil, nil), &rpcErr)
+	assert.Contains(t, rpcErr.Message, errUnknownMethod.Error())
+
+	// Both peers serve and call
+	client.Register("ping", func(context.Context, *Request) (interface{}, error) {
+		return "pong", nil
+	})
+	var pong string
+	assert.NoError(t, server.Call(context.Background(), "ping", nil, &pong))
+	assert.Equal(t, "pong", pong)
+
+	assert.NoError(t, client.Close())
+	<-server.Done()
+	assert.ErrorIs(t, client.Call(context.Background(), "echo", nil, nil), ErrClosed)
+}
+
+func TestConn_Deadline(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offer, answer, closePair := connectPair(t, webrtc.NewAPI())
+	defer closePair()
+
+	client, server := NewConn(offer), NewConn(answer)
+
+	handlerDone := make(chan error, 2)
+	server.Register("block", func(ctx context.Context, _ *Request) (interface{}, error) {
+		_, hasDeadline := ctx.Deadline()
+		assert.Equal(t, true, hasDeadline)
+
+		<-ctx.Done()
+		handlerDone <- ctx.Err()
+		return nil, ctx.Err()
+	})
+	server.Register("wait", func(ctx context.Context, _ *Request) (interface{}, error) {
+		_, hasDeadline := ctx.Deadline()
+		assert.Equal(t, false, hasDeadline)
+
+		<-ctx.Done()
+		handlerDone <- ctx.Err()
+		return nil, ctx.Err()
+	})
+
+	// The deadline is enforced on both peers
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	assert.ErrorIs(t, client.Call(ctx, "block", nil, nil), context.DeadlineExceeded)
+	cancel()
+	assert.ErrorIs(t, <-handlerDone, context.DeadlineExceeded)
+
+	// Cancelling the caller cancels the handler
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	assert.ErrorIs(t, client.Call(ctx, "wait", nil, nil), context.Canceled)
+	assert.ErrorIs(t, <-handlerDone, context.Canceled)
+
+	assert.NoError(t, client.Close())
+}
+
+func TestConn_Stream(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offer, answer, closePair := connectPair(t, webrtc.NewAPI())
+	defer closePair()
+
+	client, server := NewConn(offer), NewConn(answer)
+
+	cancelled := make(chan struct{})
+	server.RegisterStream("count", func(ctx context.Context, req *Request, stream *ServerStream) error {
+		var limit int
+		if err := req.Decode(&limit); err != nil {
+			return err
+		}
+
+		for i := 0; limit == 0 || i < limit; i++ {
+			if err := stream.Send(i); err != nil {
+				close(cancelled)
+				return err
+			}
+		}
+		if limit == 3 {
+			return errors.New("limit reached") //nolint:goerr113
+		}
+		return nil
+	})
+
+	stream, err := client.Stream(context.Background(), "count", 5)
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		var n int
+		assert.NoError(t, stream.Recv(&n))
+		assert.Equal(t, i, n)
+	}
+	assert.ErrorIs(t, stream.Recv(nil), io.EOF)
+	assert.ErrorIs(t, stream.Recv(nil), io.EOF)
+
+	// A failing handler ends the stream with its error
+	stream, err = client.Stream(context.Background(), "count", 3)
+	assert.NoError(t, err)
+	var rpcErr *Error
+	for err == nil {
+		err = stream.Recv(nil)
+	}
+	assert.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, "limit reached", rpcErr.Message)
+
+	// Closing an endless stream cancels the handler
+	stream, err = client.Stream(context.Background(), "count", 0)
+	assert.NoError(t, err)
+	assert.NoError(t, stream.Recv(nil))
+	assert.NoError(t, stream.Close())
+	assert.ErrorIs(t, stream.Recv(nil), ErrStreamClosed)
+	<-cancelled
+
+	assert.NoError(t, client.Close())
+}
+
+func TestConn_StreamOverflow(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offer, answer, closePair := connectPair(t, webrtc.NewAPI())
+	defer closePair()
+
+	client, server := NewConn(offer), NewConn(answer)
+
+	cancelled := make(chan struct{})
+	server.RegisterStream("count", func(ctx context.Context, _ *Request, stream *ServerStream) error {
+		for i := 0; ; i++ {
+			if err := stream.Send(i); err != nil {
+				close(cancelled)
+				return err
+			}
+		}
+	})
+	server.Register("echo", func(_ context.Context, req *Request) (interface{}, error) {
+		var params echoParams
+		err := req.Decode(&params)
+		return params, err
+	})
+
+	// A stream that isn't received is cancelled, other calls go on
+	stream, err := client.Stream(context.Background(), "count", nil)
+	assert.NoError(t, err)
+	<-cancelled
+
+	var result echoParams
+	assert.NoError(t, client.Call(context.Background(), "echo", echoParams{Text: "hi"}, &result))
+	assert.Equal(t, "hi", result.Text)
+
+	for i := 0; i < streamBufferSize; i++ {
+		var n int
+		assert.NoError(t, stream.Recv(&n))
+		assert.Equal(t, i, n)
+	}
+	assert.ErrorIs(t, stream.Recv(nil), ErrStreamOverflow)
+
+	assert.NoError(t, client.Close())
+}
+
+func TestConn_MaxConcurrentCalls(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offer, answer, closePair := connectPair(t, webrtc.NewAPI())
+	defer closePair()
+
+	client, server := NewConn(offer), NewConn(answer, WithMaxConcurrentCalls(1))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	server.Register("block", func(context.Context, *Request) (interface{}, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+
+	blocked := make(chan error)
+	go func() {
+		blocked <- client.Call(context.Background(), "block", nil, nil)
+	}()
+	<-started
+
+	var rpcErr *Error
+	assert.ErrorAs(t, client.Call(context.Background(), "block", nil, nil), &rpcErr)
+	assert.Equal(t, errTooManyCalls.Error(), rpcErr.Message)
+
+	close(release)
+	assert.NoError(t, <-blocked)
+
+	assert.NoError(t, client.Close())
+}
+
+// gobCodec is a Codec other than the default
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func TestDetachedConn_Codec(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := webrtc.SettingEngine{}
+	s.DetachDataChannels()
+	offer, answer, closePair := connectPair(t, webrtc.NewAPI(webrtc.WithSettingEngine(s)))
+	defer closePair()
+
+	offerRWC, err := offer.Detach()
+	assert.NoError(t, err)
+	answerRWC, err := answer.Detach()
+	assert.NoError(t, err)
+
+	client := NewDetachedConn(offerRWC, WithCodec(gobCodec{}))
+	server := NewDetachedConn(answerRWC, WithCodec(gobCodec{}))
+	server.Register("echo", func(_ context.Context, req *Request) (interface{}, error) {
+		var params echoParams
+		err := req.Decode(&params)
+		return params, err
+	})
+
+	var result echoParams
+	assert.NoError(t, client.Call(context.Background(), "echo", echoParams{Text: "gob", Count: 7}, &result))
+	assert.Equal(t, echoParams{Text: "gob", Count: 7}, result)
+
+	assert.NoError(t, client.Close())
+	<-server.Done()
+	assert.NoError(t, server.Close())
+}
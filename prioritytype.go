@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"encoding/json"
+)
+
+// PriorityType indicates the relative priority of a DataChannel. Messages of
+// higher priority channels get a larger share of the SCTP association.
+// https://www.w3.org/TR/webrtc-priority/#rtc-priority-type
+type PriorityType int
+
+const (
+	// PriorityTypeUnknown is the enum's zero-value
+	PriorityTypeUnknown PriorityType = iota
+
+	// PriorityTypeVeryLow is the lowest priority
+	PriorityTypeVeryLow
+
+	// PriorityTypeLow is the default priority
+	PriorityTypeLow
+
+	// PriorityTypeMedium is a higher than default priority
+	PriorityTypeMedium
+
+	// PriorityTypeHigh is the highest priority
+	PriorityTypeHigh
+)
+
+// This is done this way because of a linter.
+const (
+	priorityTypeVeryLowStr = "very-low"
+	priorityTypeLowStr     = "low"
+	priorityTypeMediumStr  = "medium"
+	priorityTypeHighStr    = "high"
+)
+
+// Priority values of the DATA_CHANNEL_OPEN message
+// https://www.rfc-editor.org/rfc/rfc8832.html#section-5.1
+const (
+	dcepPriorityBelowNormal uint16 = 128
+	dcepPriorityNormal      uint16 = 256
+	dcepPriorityHigh        uint16 = 512
+	dcepPriorityExtraHigh   uint16 = 1024
+)
+
+func newPriorityType(raw string) PriorityType {
+	switch raw {
+	case priorityTypeVeryLowStr:
+		return PriorityTypeVeryLow
+	case priorityTypeLowStr:
+		return PriorityTypeLow
+	case priorityTypeMediumStr:
+		return PriorityTypeMedium
+	case priorityTypeHighStr:
+		return PriorityTypeHigh
+	default:
+		return PriorityTypeUnknown
+	}
+}
+
+// newPriorityTypeFromDCEP maps the priority of a DATA_CHANNEL_OPEN message,
+// values between the defined ones round up
+// https://www.w3.org/TR/webrtc-priority/#data-channel-priority
+func newPriorityTypeFromDCEP(priority uint16) PriorityType {
+	switch {
+	case priority <= dcepPriorityBelowNormal:
+		return PriorityTypeVeryLow
+	case priority <= dcepPriorityNormal:
+		return PriorityTypeLow
+	case priority <= dcepPriorityHigh:
+		return PriorityTypeMedium
+	default:
+		return PriorityTypeHigh
+	}
+}
+
+// dcepPriority returns the priority used in the DATA_CHANNEL_OPEN message,
+// it is also the scheduling weight of the channel
+func (p PriorityType) dcepPriority() uint16 {
+	switch p {
+	case PriorityTypeVeryLow:
+		return dcepPriorityBelowNormal
+	case PriorityTypeMedium:
+		return dcepPriorityHigh
+	case PriorityTypeHigh:
+		return dcepPriorityExtraHigh
+	default:
+		return dcepPriorityNormal
+	}
+}
+
+func (p PriorityType) String() string {
+	switch p {
+	case PriorityTypeVeryLow:
+		return priorityTypeVeryLowStr
+	case PriorityTypeLow:
+		return priorityTypeLowStr
+	case PriorityTypeMedium:
+		return priorityTypeMediumStr
+	case PriorityTypeHigh:
+		return priorityTypeHighStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// UnmarshalJSON parses the JSON-encoded data and stores the result
+func (p *PriorityType) UnmarshalJSON(b []byte) error {
+	var val string
+	if err := json.Unmarshal(b, &val); err != nil {
+		return err
+	}
+	*p = newPriorityType(val)
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding
+func (p PriorityType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPriorityType(t *testing.T) {
+	testCases := []struct {
+		priorityString   string
+		expectedPriority PriorityType
+	}{
+		{ErrUnknownType.Error(), PriorityTypeUnknown},
+		{"very-low", PriorityTypeVeryLow},
+		{"low", PriorityTypeLow},
+		{"medium", PriorityTypeMedium},
+		{"high", PriorityTypeHigh},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedPriority,
+			newPriorityType(testCase.priorityString),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestPriorityType_String(t *testing.T) {
+	testCases := []struct {
+		priority       PriorityType
+		expectedString string
+	}{
+		{PriorityTypeUnknown, ErrUnknownType.Error()},
+		{PriorityTypeVeryLow, "very-low"},
+		{PriorityTypeLow, "low"},
+		{PriorityTypeMedium, "medium"},
+		{PriorityTypeHigh, "high"},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.priority.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestPriorityType_DCEP(t *testing.T) {
+	testCases := []struct {
+		priority     PriorityType
+		dcepPriority uint16
+	}{
+		{PriorityTypeVeryLow, 128},
+		{PriorityTypeLow, 256},
+		{PriorityTypeMedium, 512},
+		{PriorityTypeHigh, 1024},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t, testCase.dcepPriority, testCase.priority.dcepPriority(), "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.priority, newPriorityTypeFromDCEP(testCase.dcepPriority), "testCase: %d %v", i, testCase)
+	}
+
+	assert.Equal(t, uint16(256), PriorityTypeUnknown.dcepPriority())
+	assert.Equal(t, PriorityTypeVeryLow, newPriorityTypeFromDCEP(0))
+	assert.Equal(t, PriorityTypeMedium, newPriorityTypeFromDCEP(300))
+	assert.Equal(t, PriorityTypeHigh, newPriorityTypeFromDCEP(65535))
+}
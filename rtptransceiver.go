@@ -24,8 +24,19 @@ type RTPTransceiver struct {
 
 	codecs []RTPCodecParameters // User provided codecs via SetCodecPreferences
 
-	stopped bool
-	kind    RTPCodecType
+	// stopping is set by Stop, stopped once the media section of the
+	// transceiver has been rejected by a completed negotiation.
+	stopping bool
+	stopped  bool
+	kind     RTPCodecType
+
+	// onStopping is fired by Stop so the owning PeerConnection can update
+	// its negotiation-needed flag.
+	onStopping func()
+
+	// createdByRemote is set for transceivers created by applying a remote
+	// offer, they are removed if that offer is rolled back.
+	createdByRemote bool
 
 	api *API
 	mu  sync.RWMutex
@@ -151,8 +162,60 @@ func (t *RTPTransceiver) Direction() RTPTransceiverDirection {
 	return RTPTransceiverDirection(0)
 }
 
-// Stop irreversibly stops the RTPTransceiver
+// Stop irreversibly stops the RTPTransceiver. The RTPSender and RTPReceiver
+// are stopped immediately, the media section is rejected in the next offer or
+// answer and, once that negotiation completes, the transceiver is removed from
+// the PeerConnection so a new transceiver may recycle its media section.
 func (t *RTPTransceiver) Stop() error {
+	changed, err := t.stop()
+	if !changed {
+		return err
+	}
+
+	// Fired outside of t.mu, as the PeerConnection takes its own lock
+	t.mu.RLock()
+	onStopping := t.onStopping
+	t.mu.RUnlock()
+
+	if onStopping != nil {
+		onStopping()
+	}
+	return err
+}
+
+// stop implements Stop without updating the negotiation-needed flag. It
+// returns false if the transceiver was already stopping.
+// https://www.w3.org/TR/webrtc/#dfn-stop-sending-and-receiving
+func (t *RTPTransceiver) stop() (bool, error) {
+	t.mu.Lock()
+	if t.stopping || t.stopped {
+		t.mu.Unlock()
+		return false, nil
+	}
+	t.stopping = true
+	t.setDirection(RTPTransceiverDirectionStopped)
+	t.mu.Unlock()
+
+	return true, t.stopSendingAndReceiving()
+}
+
+// setStopped completes stopping the transceiver after its media section has
+// been rejected.
+// https://www.w3.org/TR/webrtc/#dfn-stop-the-rtcrtptransceiver
+func (t *RTPTransceiver) setStopped() error {
+	err := t.stopSendingAndReceiving()
+
+	t.mu.Lock()
+	t.stopping = true
+	t.stopped = true
+	t.mu.Unlock()
+
+	t.setDirection(RTPTransceiverDirectionStopped)
+	t.setCurrentDirection(RTPTransceiverDirectionStopped)
+	return err
+}
+
+func (t *RTPTransceiver) stopSendingAndReceiving() error {
 	if sender := t.Sender(); sender != nil {
 		if err := sender.Stop(); err != nil {
 			return err
@@ -164,11 +227,32 @@ func (t *RTPTransceiver) Stop() error {
 		}
 	}
 
-	t.setDirection(RTPTransceiverDirectionInactive)
-	t.setCurrentDirection(RTPTransceiverDirectionInactive)
 	return nil
 }
 
+// isStopping returns true once Stop has been called, or the transceiver has
+// been stopped by a negotiation
+func (t *RTPTransceiver) isStopping() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.stopping || t.stopped
+}
+
+func (t *RTPTransceiver) isStopped() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.stopped
+}
+
+func (t *RTPTransceiver) setOnStopping(f func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.onStopping = f
+}
+
 func (t *RTPTransceiver) setReceiver(r *RTPReceiver) {
 	if r != nil {
 		r.setRTPTransceiver(t)
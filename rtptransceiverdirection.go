@@ -25,6 +25,10 @@ const (
 	// RTPTransceiverDirectionInactive indicates the RTPSender won't offer
 	// to send RTP and the RTPReceiver won't offer to receive RTP.
 	RTPTransceiverDirectionInactive
+
+	// RTPTransceiverDirectionStopped indicates the RTPTransceiver has been
+	// stopped and will neither send nor receive RTP.
+	RTPTransceiverDirectionStopped
 )
 
 // This is done this way because of a linter.
@@ -33,6 +37,7 @@ const (
 	rtpTransceiverDirectionSendonlyStr = "sendonly"
 	rtpTransceiverDirectionRecvonlyStr = "recvonly"
 	rtpTransceiverDirectionInactiveStr = "inactive"
+	rtpTransceiverDirectionStoppedStr  = "stopped"
 )
 
 // NewRTPTransceiverDirection defines a procedure for creating a new
@@ -47,6 +52,8 @@ func NewRTPTransceiverDirection(raw string) RTPTransceiverDirection {
 		return RTPTransceiverDirectionRecvonly
 	case rtpTransceiverDirectionInactiveStr:
 		return RTPTransceiverDirectionInactive
+	case rtpTransceiverDirectionStoppedStr:
+		return RTPTransceiverDirectionStopped
 	default:
 		return RTPTransceiverDirectionUnknown
 	}
@@ -62,6 +69,8 @@ func (t RTPTransceiverDirection) String() string {
 		return rtpTransceiverDirectionRecvonlyStr
 	case RTPTransceiverDirectionInactive:
 		return rtpTransceiverDirectionInactiveStr
+	case RTPTransceiverDirectionStopped:
+		return rtpTransceiverDirectionStoppedStr
 	default:
 		return ErrUnknownType.Error()
 	}
@@ -19,6 +19,7 @@ func TestNewRTPTransceiverDirection(t *testing.T) {
 		{"sendonly", RTPTransceiverDirectionSendonly},
 		{"recvonly", RTPTransceiverDirectionRecvonly},
 		{"inactive", RTPTransceiverDirectionInactive},
+		{"stopped", RTPTransceiverDirectionStopped},
 	}
 
 	for i, testCase := range testCases {
@@ -40,6 +41,7 @@ func TestRTPTransceiverDirection_String(t *testing.T) {
 		{RTPTransceiverDirectionSendonly, "sendonly"},
 		{RTPTransceiverDirectionRecvonly, "recvonly"},
 		{RTPTransceiverDirectionInactive, "inactive"},
+		{RTPTransceiverDirectionStopped, "stopped"},
 	}
 
 	for i, testCase := range testCases {
@@ -8,6 +8,7 @@ package webrtc
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"math"
 	"sync"
@@ -47,15 +48,20 @@ type SCTPTransport struct {
 	onErrorHandler func(error)
 
 	sctpAssociation            *sctp.Association
+	scheduler                  *dataChannelScheduler
 	onDataChannelHandler       func(*DataChannel)
 	onDataChannelOpenedHandler func(*DataChannel)
+	listener                   *dataChannelListener
 
 	// DataChannels
-	dataChannels          []*DataChannel
-	dataChannelIDsUsed    map[uint16]struct{}
-	dataChannelsOpened    uint32
-	dataChannelsRequested uint32
-	dataChannelsAccepted  uint32
+	dataChannels       []*DataChannel
+	dataChannelIDsUsed map[uint16]struct{}
+	// reservedDataChannelIDs are the IDs of NegotiatedDataChannelGroups, the
+	// remote peer can't open in-band DataChannels with them
+	reservedDataChannelIDs []dataChannelIDRange
+	dataChannelsOpened     uint32
+	dataChannelsRequested  uint32
+	dataChannelsAccepted   uint32
 
 	api *API
 	log logging.LeveledLogger
@@ -73,9 +79,13 @@ func (api *API) NewSCTPTransport(dtls *DTLSTransport) *SCTPTransport {
 		dataChannelIDsUsed: make(map[uint16]struct{}),
 	}
 
-	res.updateMessageSize()
+	res.updateMessageSize(sctpDefaultMaxMessageSize)
 	res.updateMaxChannels()
 
+	if api.settingEngine.sctp.schedulerBufferSize != 0 {
+		res.scheduler = newDataChannelScheduler(res, api.settingEngine.sctp.schedulerBufferSize)
+	}
+
 	return res
 }
 
@@ -90,19 +100,22 @@ func (r *SCTPTransport) Transport() *DTLSTransport {
 // GetCapabilities returns the SCTPCapabilities of the SCTPTransport.
 func (r *SCTPTransport) GetCapabilities() SCTPCapabilities {
 	return SCTPCapabilities{
-		MaxMessageSize: 0,
+		MaxMessageSize: r.localMaxMessageSize(),
 	}
 }
 
 // Start the SCTPTransport. Since both local and remote parties must mutually
 // create an SCTPTransport, SCTP SO (Simultaneous Open) is used to establish
-// a connection over SCTP.
-func (r *SCTPTransport) Start(SCTPCapabilities) error {
+// a connection over SCTP. remoteCaps.MaxMessageSize is the largest message
+// the remote can receive, 0 means no limit.
+func (r *SCTPTransport) Start(remoteCaps SCTPCapabilities) error {
 	if r.isStarted {
 		return nil
 	}
 	r.isStarted = true
 
+	r.updateMessageSize(remoteCaps.MaxMessageSize)
+
 	dtlsTransport := r.Transport()
 	if dtlsTransport == nil || dtlsTransport.conn == nil {
 		return errSCTPTransportDTLS
@@ -113,6 +126,7 @@ func (r *SCTPTransport) Start(SCTPCapabilities) error {
 		EnableZeroChecksum:   r.api.settingEngine.sctp.enableZeroChecksum,
 		LoggerFactory:        r.api.settingEngine.LoggerFactory,
 		RTOMax:               float64(r.api.settingEngine.sctp.rtoMax) / float64(time.Millisecond),
+		MaxMessageSize:       r.sendMaxMessageSize(),
 	})
 	if err != nil {
 		return err
@@ -147,6 +161,33 @@ func (r *SCTPTransport) Start(SCTPCapabilities) error {
 
 // Stop stops the SCTPTransport
 func (r *SCTPTransport) Stop() error {
+	if r.scheduler != nil {
+		r.scheduler.close()
+	}
+
+	r.lock.RLock()
+	listener := r.listener
+	dataChannels := append([]*DataChannel{}, r.dataChannels...)
+	r.lock.RUnlock()
+
+	// Read loops blocked on a full DataChannel.Messages channel must see
+	// the association close
+	for _, d := range dataChannels {
+		d.mu.RLock()
+		messageQueue := d.messageQueue
+		d.mu.RUnlock()
+
+		if messageQueue != nil {
+			messageQueue.stop()
+		}
+	}
+
+	if listener != nil {
+		if err := listener.Close(); err != nil {
+			return err
+		}
+	}
+
 	r.lock.Lock()
 	defer r.lock.Unlock()
 	if r.sctpAssociation == nil {
@@ -194,6 +235,16 @@ ACCEPT:
 			}
 		}
 
+		if r.isDataChannelIDReserved(dc.StreamIdentifier()) {
+			err = fmt.Errorf("%w: %d", ErrDataChannelIDReserved, dc.StreamIdentifier())
+			r.log.Warnf("Rejecting data channel: %v", err)
+			if closeErr := dc.Close(); closeErr != nil {
+				r.log.Warnf("Failed to close data channel: %v", closeErr)
+			}
+			r.onError(err)
+			continue
+		}
+
 		var (
 			maxRetransmits    *uint16
 			maxPacketLifeTime *uint16
@@ -227,6 +278,7 @@ ACCEPT:
 			Label:             dc.Config.Label,
 			Protocol:          dc.Config.Protocol,
 			Negotiated:        dc.Config.Negotiated,
+			Priority:          newPriorityTypeFromDCEP(dc.Config.Priority),
 			Ordered:           ordered,
 			MaxPacketLifeTime: maxPacketLifeTime,
 			MaxRetransmits:    maxRetransmits,
@@ -238,6 +290,17 @@ ACCEPT:
 		}
 
 		<-r.onDataChannel(rtcDC)
+
+		// DataChannels handed to a listener are always detached
+		r.lock.RLock()
+		listener := r.listener
+		r.lock.RUnlock()
+		if listener != nil {
+			rtcDC.mu.Lock()
+			rtcDC.detach = true
+			rtcDC.mu.Unlock()
+		}
+
 		rtcDC.handleOpen(dc, true, dc.Config.Negotiated)
 
 		r.lock.Lock()
@@ -248,6 +311,9 @@ ACCEPT:
 		if handler != nil {
 			handler(rtcDC)
 		}
+		if listener != nil {
+			listener.deliver(rtcDC)
+		}
 	}
 }
 
@@ -315,14 +381,41 @@ func (r *SCTPTransport) onDataChannel(dc *DataChannel) (done chan struct{}) {
 	return
 }
 
-func (r *SCTPTransport) updateMessageSize() {
+// https://www.w3.org/TR/webrtc/#sctp-transport-update-mms
+func (r *SCTPTransport) updateMessageSize(remoteMaxMessageSize uint32) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	var remoteMaxMessageSize float64 = 65536 // pion/webrtc#758
-	var canSendSize float64 = 65536          // pion/webrtc#758
+	// Messages of any size are fragmented by the SCTP association
+	var canSendSize float64
+
+	r.maxMessageSize = r.calcMessageSize(float64(remoteMaxMessageSize), canSendSize)
+}
+
+// MaxMessageSize is the size of the largest message that can be passed to
+// DataChannel.Send, it is +Inf when the remote doesn't limit the message size.
+func (r *SCTPTransport) MaxMessageSize() float64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.maxMessageSize
+}
+
+// sendMaxMessageSize is MaxMessageSize as a limit for the SCTP association
+func (r *SCTPTransport) sendMaxMessageSize() uint32 {
+	maxMessageSize := r.MaxMessageSize()
+	if maxMessageSize > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint32(maxMessageSize)
+}
 
-	r.maxMessageSize = r.calcMessageSize(remoteMaxMessageSize, canSendSize)
+// localMaxMessageSize is the size of the largest message we can receive
+func (r *SCTPTransport) localMaxMessageSize() uint32 {
+	if r.api.settingEngine.sctp.maxMessageSize != 0 {
+		return r.api.settingEngine.sctp.maxMessageSize
+	}
+	return sctpDefaultMaxMessageSize
 }
 
 func (r *SCTPTransport) calcMessageSize(remoteMaxMessageSize, canSendSize float64) float64 {
@@ -345,6 +438,33 @@ func (r *SCTPTransport) calcMessageSize(remoteMaxMessageSize, canSendSize float6
 	}
 }
 
+// associationBufferedAmount is the number of bytes of all channels waiting
+// to be sent by the SCTP association
+func (r *SCTPTransport) associationBufferedAmount() uint64 {
+	_, _, bufferedAmount := r.dataChannelBufferedAmounts()
+	return bufferedAmount
+}
+
+// dataChannelBufferedAmounts returns the bytes of each channel waiting to be
+// sent by the SCTP association, and their sum
+func (r *SCTPTransport) dataChannelBufferedAmounts() ([]*DataChannel, []uint64, uint64) {
+	r.lock.RLock()
+	dataChannels := append([]*DataChannel{}, r.dataChannels...)
+	r.lock.RUnlock()
+
+	var sum uint64
+	bufferedAmounts := make([]uint64, len(dataChannels))
+	for i, d := range dataChannels {
+		d.mu.RLock()
+		if d.dataChannel != nil {
+			bufferedAmounts[i] = d.dataChannel.BufferedAmount()
+			sum += bufferedAmounts[i]
+		}
+		d.mu.RUnlock()
+	}
+	return dataChannels, bufferedAmounts, sum
+}
+
 func (r *SCTPTransport) updateMaxChannels() {
 	val := sctpMaxChannels
 	r.maxChannels = &val
@@ -373,9 +493,10 @@ func (r *SCTPTransport) collectStats(collector *statsReportCollector) {
 	collector.Collecting()
 
 	stats := SCTPTransportStats{
-		Timestamp: statsTimestampFrom(time.Now()),
-		Type:      StatsTypeSCTPTransport,
-		ID:        "sctpTransport",
+		Timestamp:   statsTimestampFrom(time.Now()),
+		Type:        StatsTypeSCTPTransport,
+		ID:          "sctpTransport",
+		TransportID: "iceTransport",
 	}
 
 	association := r.association()
@@ -414,6 +535,43 @@ func (r *SCTPTransport) generateAndSetDataChannelID(dtlsRole DTLSRole, idOut **u
 	return &rtcerr.OperationError{Err: ErrMaxDataChannelID}
 }
 
+// isDataChannelIDReserved returns whether id belongs to a
+// NegotiatedDataChannelGroup
+func (r *SCTPTransport) isDataChannelIDReserved(id uint16) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, reserved := range r.reservedDataChannelIDs {
+		if reserved.contains(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseDataChannelIDs drops the IDs and ranges reserved for a
+// NegotiatedDataChannelGroup
+func (r *SCTPTransport) releaseDataChannelIDs(ids []uint16, ranges []dataChannelIDRange) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, id := range ids {
+		delete(r.dataChannelIDsUsed, id)
+	}
+
+	reserved := r.reservedDataChannelIDs[:0]
+RANGES:
+	for _, reservedRange := range r.reservedDataChannelIDs {
+		for _, released := range ranges {
+			if reservedRange == released {
+				continue RANGES
+			}
+		}
+		reserved = append(reserved, reservedRange)
+	}
+	r.reservedDataChannelIDs = reserved
+}
+
 func (r *SCTPTransport) association() *sctp.Association {
 	if r == nil {
 		return nil
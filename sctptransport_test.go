@@ -6,7 +6,12 @@
 
 package webrtc
 
-import "testing"
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
 
 func TestGenerateDataChannelID(t *testing.T) {
 	sctpTransportWithChannels := func(ids []uint16) *SCTPTransport {
@@ -55,3 +60,25 @@ func TestGenerateDataChannelID(t *testing.T) {
 		}
 	}
 }
+
+func TestSCTPTransport_MaxMessageSize(t *testing.T) {
+	testCases := []struct {
+		remoteMaxMessageSize uint32
+		expected             float64
+	}{
+		{0, math.Inf(1)},
+		{1024, 1024},
+		{sctpDefaultMaxMessageSize, sctpDefaultMaxMessageSize},
+		{1 << 30, 1 << 30},
+	}
+
+	for _, testCase := range testCases {
+		r := &SCTPTransport{}
+		r.updateMessageSize(testCase.remoteMaxMessageSize)
+		assert.Equal(t, testCase.expected, r.MaxMessageSize())
+	}
+
+	r := &SCTPTransport{}
+	r.updateMessageSize(0)
+	assert.Equal(t, uint32(math.MaxUint32), r.sendMaxMessageSize())
+}
@@ -305,7 +305,7 @@ func addCandidatesToMediaDescriptions(candidates []ICECandidate, m *sdp.MediaDes
 	return nil
 }
 
-func addDataMediaSection(d *sdp.SessionDescription, shouldAddCandidates bool, dtlsFingerprints []DTLSFingerprint, midValue string, iceParams ICEParameters, candidates []ICECandidate, dtlsRole sdp.ConnectionRole, iceGatheringState ICEGatheringState) error {
+func addDataMediaSection(d *sdp.SessionDescription, shouldAddCandidates bool, dtlsFingerprints []DTLSFingerprint, midValue string, iceParams ICEParameters, candidates []ICECandidate, dtlsRole sdp.ConnectionRole, iceGatheringState ICEGatheringState, sctpMaxMessageSize uint32) error {
 	media := (&sdp.MediaDescription{
 		MediaName: sdp.MediaName{
 			Media:   mediaSectionApplication,
@@ -325,6 +325,7 @@ func addDataMediaSection(d *sdp.SessionDescription, shouldAddCandidates bool, dt
 		WithValueAttribute(sdp.AttrKeyMID, midValue).
 		WithPropertyAttribute(RTPTransceiverDirectionSendrecv.String()).
 		WithPropertyAttribute("sctp-port:5000").
+		WithValueAttribute(sdpAttributeMaxMessageSize, strconv.FormatUint(uint64(sctpMaxMessageSize), 10)).
 		WithICECredentials(iceParams.UsernameFragment, iceParams.Password)
 
 	for _, f := range dtlsFingerprints {
@@ -352,11 +353,15 @@ func populateLocalCandidates(sessionDescription *SessionDescription, i *ICEGathe
 	}
 
 	parsed := sessionDescription.parsed
-	if len(parsed.MediaDescriptions) > 0 {
-		m := parsed.MediaDescriptions[0]
+	for _, m := range parsed.MediaDescriptions {
+		// Candidates belong to the first media section that hasn't been rejected
+		if isMediaSectionRejected(m) {
+			continue
+		}
 		if err = addCandidatesToMediaDescriptions(candidates, m, iceGatheringState); err != nil {
 			return sessionDescription
 		}
+		break
 	}
 
 	sdp, err := parsed.Marshal()
@@ -431,6 +436,16 @@ func addTransceiverSDP(
 	}
 	// Use the first transceiver to generate the section attributes
 	t := transceivers[0]
+
+	// A stopped transceiver keeps its media section in place, but rejected
+	// https://datatracker.ietf.org/doc/html/rfc8829#section-5.2.2
+	if t.isStopping() {
+		d.WithMedia(newRejectedMediaDescription(t.kind).
+			WithValueAttribute(sdp.AttrKeyMID, midValue).
+			WithPropertyAttribute(RTPTransceiverDirectionInactive.String()))
+		return false, nil
+	}
+
 	media := sdp.NewJSEPMediaDescription(t.kind.String(), []string{}).
 		WithValueAttribute(sdp.AttrKeyConnectionSetup, dtlsRole.String()).
 		WithValueAttribute(sdp.AttrKeyMID, midValue).
@@ -459,21 +474,7 @@ func addTransceiverSDP(
 		// parse the SDP with an error like:
 		// SIPCC Failed to parse SDP: SDP Parse Error on line 50:  c= connection line not specified for every media level, validation failed.
 		// In addition this makes our SDP compliant with RFC 4566 Section 5.7: https://datatracker.ietf.org/doc/html/rfc4566#section-5.7
-		d.WithMedia(&sdp.MediaDescription{
-			MediaName: sdp.MediaName{
-				Media:   t.kind.String(),
-				Port:    sdp.RangedPort{Value: 0},
-				Protos:  []string{"UDP", "TLS", "RTP", "SAVPF"},
-				Formats: []string{"0"},
-			},
-			ConnectionInformation: &sdp.ConnectionInformation{
-				NetworkType: "IN",
-				AddressType: "IP4",
-				Address: &sdp.Address{
-					Address: "0.0.0.0",
-				},
-			},
-		})
+		d.WithMedia(newRejectedMediaDescription(t.kind))
 		return false, nil
 	}
 
@@ -527,6 +528,45 @@ func addTransceiverSDP(
 	return true, nil
 }
 
+func newRejectedMediaDescription(kind RTPCodecType) *sdp.MediaDescription {
+	return &sdp.MediaDescription{
+		MediaName: sdp.MediaName{
+			Media:   kind.String(),
+			Port:    sdp.RangedPort{Value: 0},
+			Protos:  []string{"UDP", "TLS", "RTP", "SAVPF"},
+			Formats: []string{"0"},
+		},
+		ConnectionInformation: &sdp.ConnectionInformation{
+			NetworkType: "IN",
+			AddressType: "IP4",
+			Address: &sdp.Address{
+				Address: "0.0.0.0",
+			},
+		},
+	}
+}
+
+// isMediaSectionRejected returns true if the media section has a zero port and
+// isn't marked bundle-only
+// https://datatracker.ietf.org/doc/html/rfc8829#section-5.2.2
+func isMediaSectionRejected(media *sdp.MediaDescription) bool {
+	if media.MediaName.Port.Value != 0 {
+		return false
+	}
+	_, bundleOnly := media.Attribute(sdpAttributeBundleOnly)
+	return !bundleOnly
+}
+
+// isMidRejected returns true if desc has a rejected media section with the
+// given mid
+func isMidRejected(mid string, desc *SessionDescription) bool {
+	if desc == nil || desc.parsed == nil {
+		return false
+	}
+	m := getByMid(mid, desc)
+	return m != nil && isMediaSectionRejected(m)
+}
+
 type simulcastRid struct {
 	attrValue string
 	paused    bool
@@ -570,6 +610,7 @@ func populateSDP(
 	iceParams ICEParameters,
 	mediaSections []mediaSection,
 	iceGatheringState ICEGatheringState,
+	sctpMaxMessageSize uint32,
 	matchBundleGroup *string,
 ) (*sdp.SessionDescription, error) {
 	var err error
@@ -588,7 +629,8 @@ func populateSDP(
 		bundleCount++
 	}
 
-	for i, m := range mediaSections {
+	haveCandidates := false
+	for _, m := range mediaSections {
 		if m.data && len(m.transceivers) != 0 {
 			return nil, errSDPMediaSectionMediaDataChanInvalid
 		} else if !isPlanB && len(m.transceivers) > 1 {
@@ -596,9 +638,10 @@ func populateSDP(
 		}
 
 		shouldAddID := true
-		shouldAddCandidates := i == 0
+		// Candidates go in the first media section that isn't rejected
+		shouldAddCandidates := !haveCandidates
 		if m.data {
-			if err = addDataMediaSection(d, shouldAddCandidates, mediaDtlsFingerprints, m.id, iceParams, candidates, connectionRole, iceGatheringState); err != nil {
+			if err = addDataMediaSection(d, shouldAddCandidates, mediaDtlsFingerprints, m.id, iceParams, candidates, connectionRole, iceGatheringState, sctpMaxMessageSize); err != nil {
 				return nil, err
 			}
 		} else {
@@ -609,6 +652,7 @@ func populateSDP(
 		}
 
 		if shouldAddID {
+			haveCandidates = true
 			if bundleMatch(m.id) {
 				appendBundle(m.id)
 			} else {
@@ -813,6 +857,26 @@ func haveDataChannel(desc *SessionDescription) *sdp.MediaDescription {
 	return nil
 }
 
+// getMaxMessageSize returns the a=max-message-size of a data media section.
+// The default of 65536 applies when the attribute is missing, 0 means no limit.
+// https://www.rfc-editor.org/rfc/rfc8841.html#section-6
+func getMaxMessageSize(media *sdp.MediaDescription) uint32 {
+	if media == nil {
+		return sctpDefaultMaxMessageSize
+	}
+
+	value, ok := media.Attribute(sdpAttributeMaxMessageSize)
+	if !ok {
+		return sctpDefaultMaxMessageSize
+	}
+
+	maxMessageSize, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return sctpDefaultMaxMessageSize
+	}
+	return uint32(maxMessageSize)
+}
+
 func codecsFromMediaDescription(m *sdp.MediaDescription) (out []RTPCodecParameters, err error) {
 	s := &sdp.SessionDescription{
 		MediaDescriptions: []*sdp.MediaDescription{m},
@@ -44,6 +44,12 @@ type SettingEngine struct {
 		ICERelayAcceptanceMinWait *time.Duration
 		ICESTUNGatherTimeout      *time.Duration
 	}
+	iceRestart struct {
+		enabled           bool
+		disconnectedDelay time.Duration
+		initialBackoff    time.Duration
+		maxBackoff        time.Duration
+	}
 	candidates struct {
 		ICELite                  bool
 		ICENetworkTypes          []NetworkType
@@ -56,6 +62,7 @@ type SettingEngine struct {
 		UsernameFragment         string
 		Password                 string
 		IncludeLoopbackCandidate bool
+		Rewriter                 func(ICECandidate) []ICECandidate
 	}
 	replayProtection struct {
 		DTLS  *uint
@@ -77,6 +84,8 @@ type SettingEngine struct {
 	}
 	sctp struct {
 		maxReceiveBufferSize uint32
+		maxMessageSize       uint32
+		schedulerBufferSize  uint64
 		enableZeroChecksum   bool
 		rtoMax               time.Duration
 	}
@@ -110,7 +119,8 @@ func (e *SettingEngine) getReceiveMTU() uint {
 
 // DetachDataChannels enables detaching data channels. When enabled
 // data channels have to be detached in the OnOpen callback using the
-// DataChannel.Detach method.
+// DataChannel.Detach method. Use DataChannelInit.Detach or
+// DataChannel.DetachWhenOpen to detach individual data channels instead.
 func (e *SettingEngine) DetachDataChannels() {
 	e.detach.DataChannels = true
 }
@@ -140,6 +150,20 @@ func (e *SettingEngine) SetICETimeouts(disconnectedTimeout, failedTimeout, keepA
 	e.timeout.ICEKeepaliveInterval = &keepAliveInterval
 }
 
+// SetICERestartPolicy enables restarting ICE automatically when connectivity is lost.
+// PeerConnection.RestartICE is called once the ICE connection has been disconnected for
+// disconnectedDelay or has failed, which fires OnNegotiationNeeded so the application
+// renegotiates. A zero disconnectedDelay only restarts on failure.
+//
+// While the connection doesn't recover ICE is restarted again, waiting initialBackoff
+// first and doubling the wait after every attempt up to maxBackoff.
+func (e *SettingEngine) SetICERestartPolicy(disconnectedDelay, initialBackoff, maxBackoff time.Duration) {
+	e.iceRestart.enabled = true
+	e.iceRestart.disconnectedDelay = disconnectedDelay
+	e.iceRestart.initialBackoff = initialBackoff
+	e.iceRestart.maxBackoff = maxBackoff
+}
+
 // SetHostAcceptanceMinWait sets the ICEHostAcceptanceMinWait
 func (e *SettingEngine) SetHostAcceptanceMinWait(t time.Duration) {
 	e.timeout.ICEHostAcceptanceMinWait = &t
@@ -436,12 +460,30 @@ func (e *SettingEngine) SetDTLSKeyLogWriter(writer io.Writer) {
 	e.dtls.keyLogWriter = writer
 }
 
+// SetSCTPMaxMessageSize sets the size of the largest data channel message that can
+// be received, it is announced to the remote with a=max-message-size. Messages are
+// reassembled in the receive buffer, so this must not exceed SetSCTPMaxReceiveBufferSize.
+// Leave this 0 for the default of 65536 bytes.
+func (e *SettingEngine) SetSCTPMaxMessageSize(maxMessageSize uint32) {
+	e.sctp.maxMessageSize = maxMessageSize
+}
+
 // SetSCTPMaxReceiveBufferSize sets the maximum receive buffer size.
 // Leave this 0 for the default maxReceiveBufferSize.
 func (e *SettingEngine) SetSCTPMaxReceiveBufferSize(maxReceiveBufferSize uint32) {
 	e.sctp.maxReceiveBufferSize = maxReceiveBufferSize
 }
 
+// EnableDataChannelScheduler schedules data channel messages by the priority of their
+// channel. Messages are held back while more than maxBufferedAmount bytes of all channels
+// wait in the SCTP association, and are then released weighted by priority, so control
+// messages on a high priority channel aren't stuck behind bulk transfers. Messages written
+// to the ReadWriteCloser returned by DataChannel.Detach are not scheduled, the net.Conn
+// returned by DataChannel.DetachConn writes through the scheduler.
+func (e *SettingEngine) EnableDataChannelScheduler(maxBufferedAmount uint64) {
+	e.sctp.schedulerBufferSize = maxBufferedAmount
+}
+
 // EnableSCTPZeroChecksum controls the zero checksum feature in SCTP.
 // This removes the need to checksum every incoming/outgoing packet and will reduce
 // latency and CPU usage. This feature is not backwards compatible so is disabled by default
@@ -461,6 +503,15 @@ func (e *SettingEngine) SetSCTPRTOMax(rtoMax time.Duration) {
 	e.sctp.rtoMax = rtoMax
 }
 
+// SetICECandidateRewriter sets a callback that is invoked for every gathered local
+// candidate before it is emitted by OnICECandidate and added to the local description.
+// The callback returns the candidates to use instead: the candidate itself to keep it,
+// nil to drop it, or additional candidates such as ones with addresses and ports
+// discovered through UPnP or NAT-PMP. It is invoked once per gathered candidate.
+func (e *SettingEngine) SetICECandidateRewriter(rewriter func(ICECandidate) []ICECandidate) {
+	e.candidates.Rewriter = rewriter
+}
+
 // SetICEBindingRequestHandler sets a callback that is fired on a STUN BindingRequest
 // This allows users to do things like
 // - Log incoming Binding Requests for debugging
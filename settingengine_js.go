@@ -17,7 +17,8 @@ type SettingEngine struct {
 
 // DetachDataChannels enables detaching data channels. When enabled
 // data channels have to be detached in the OnOpen callback using the
-// DataChannel.Detach method.
+// DataChannel.Detach method. Use DataChannelInit.Detach or
+// DataChannel.DetachWhenOpen to detach individual data channels instead.
 func (e *SettingEngine) DetachDataChannels() {
 	e.detach.DataChannels = true
 }
@@ -56,6 +56,17 @@ func TestSetConnectionTimeout(t *testing.T) {
 	assert.Equal(t, *s.timeout.ICEKeepaliveInterval, 3*time.Second)
 }
 
+func TestSetICERestartPolicy(t *testing.T) {
+	s := SettingEngine{}
+	assert.False(t, s.iceRestart.enabled)
+
+	s.SetICERestartPolicy(1*time.Second, 2*time.Second, 3*time.Second)
+	assert.True(t, s.iceRestart.enabled)
+	assert.Equal(t, s.iceRestart.disconnectedDelay, 1*time.Second)
+	assert.Equal(t, s.iceRestart.initialBackoff, 2*time.Second)
+	assert.Equal(t, s.iceRestart.maxBackoff, 3*time.Second)
+}
+
 func TestDetachDataChannels(t *testing.T) {
 	s := SettingEngine{}
 
@@ -1131,6 +1131,11 @@ type DataChannelStats struct {
 	// BytesReceived represents the total number of bytes received on this
 	// datachannel not including headers or padding.
 	BytesReceived uint64 `json:"bytesReceived"`
+
+	// BufferedAmount is the number of bytes queued to be sent on the SCTP
+	// stream of this datachannel, including bytes held by the SettingEngine
+	// data channel scheduler.
+	BufferedAmount uint64 `json:"bufferedAmount"`
 }
 
 func (s DataChannelStats) statsMarker() {}
@@ -478,6 +478,7 @@ func getStatsSamples() []statSample {
 		BytesSent:             16,
 		MessagesReceived:      2,
 		BytesReceived:         20,
+		BufferedAmount:        512,
 	}
 	dataChannelStatsJSON := `
 {
@@ -492,7 +493,8 @@ func getStatsSamples() []statSample {
   "messagesSent": 1,
   "bytesSent": 16,
   "messagesReceived": 2,
-  "bytesReceived": 20
+  "bytesReceived": 20,
+  "bufferedAmount": 512
 }
 `
 	streamStats := MediaStreamStats{
@@ -1327,6 +1329,11 @@ func TestPeerConnection_GetStats(t *testing.T) {
 	offerSCTPTransportStats := getSctpTransportStats(t, reportPCOffer)
 	assert.GreaterOrEqual(t, offerSCTPTransportStats.BytesSent, answerSCTPTransportStats.BytesReceived)
 	assert.GreaterOrEqual(t, answerSCTPTransportStats.BytesSent, offerSCTPTransportStats.BytesReceived)
+	assert.Equal(t, "iceTransport", offerSCTPTransportStats.TransportID)
+	assert.NotZero(t, offerSCTPTransportStats.CongestionWindow)
+	assert.NotZero(t, offerSCTPTransportStats.ReceiverWindow)
+	assert.NotZero(t, offerSCTPTransportStats.MTU)
+	assert.Zero(t, dcStatsOffer.BufferedAmount)
 
 	certificates := offerPC.configuration.Certificates
 
@@ -32,6 +32,8 @@ type TrackLocalStaticRTP struct {
 	bindings          []trackBinding
 	codec             RTPCodecCapability
 	id, rid, streamID string
+
+	parameterSetInjection bool
 }
 
 // NewTrackLocalStaticRTP returns a TrackLocalStaticRTP.
@@ -57,6 +59,17 @@ func WithRTPStreamID(rid string) func(*TrackLocalStaticRTP) {
 	}
 }
 
+// WithParameterSetInjection makes a TrackLocalStaticSample cache the
+// latest H264 SPS and PPS written, and send them with every IDR picture
+// that is written without them. Receivers that join late or lose the
+// first keyframe can then decode the next one. It has no effect on other
+// codecs or on a TrackLocalStaticRTP.
+func WithParameterSetInjection() func(*TrackLocalStaticRTP) {
+	return func(t *TrackLocalStaticRTP) {
+		t.parameterSetInjection = true
+	}
+}
+
 // Bind is called by the PeerConnection after negotiation is complete
 // This asserts that the code requested is supported by the remote peer.
 // If so it setups all the state (SSRC and PayloadType) to have a call
@@ -196,6 +209,8 @@ type TrackLocalStaticSample struct {
 	sequencer  rtp.Sequencer
 	rtpTrack   *TrackLocalStaticRTP
 	clockRate  float64
+	// maxNALSize is set when H264 NALs can't be fragmented
+	maxNALSize int
 }
 
 // NewTrackLocalStaticSample returns a TrackLocalStaticSample
@@ -250,6 +265,12 @@ func (s *TrackLocalStaticSample) Bind(t TrackLocalContext) (RTPCodecParameters,
 	if err != nil {
 		return codec, err
 	}
+	if _, ok := payloader.(h264SingleNALPayloader); ok {
+		s.maxNALSize = rtpOutboundMTU - rtpFixedHeaderSize
+	}
+	if s.rtpTrack.parameterSetInjection && strings.EqualFold(codec.MimeType, MimeTypeH264) {
+		payloader = &h264ParameterSetInjector{payloader: payloader}
+	}
 
 	s.sequencer = rtp.NewRandomSequencer()
 	s.packetizer = rtp.NewPacketizer(
@@ -278,12 +299,19 @@ func (s *TrackLocalStaticSample) WriteSample(sample media.Sample) error {
 	s.rtpTrack.mu.RLock()
 	p := s.packetizer
 	clockRate := s.clockRate
+	maxNALSize := s.maxNALSize
 	s.rtpTrack.mu.RUnlock()
 
 	if p == nil {
 		return nil
 	}
 
+	if maxNALSize != 0 {
+		if err := checkH264SingleNALSize(maxNALSize, sample.Data); err != nil {
+			return err
+		}
+	}
+
 	// skip packets by the number of previously dropped packets
 	for i := uint16(0); i < sample.PrevDroppedPackets; i++ {
 		s.sequencer.NextSequenceNumber()